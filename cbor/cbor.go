@@ -0,0 +1,379 @@
+// Package cbor implements the subset of CBOR (RFC 8949) used by the credential encodings:
+// integers, byte and text strings, arrays, maps with text or integer keys, tags, booleans and null.
+// Encoding is deterministic: integers and lengths use their shortest form and map keys are sorted
+// by their encoded bytes, as required by the core deterministic encoding of RFC 8949 section 4.2.1.
+package cbor
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"unicode/utf8"
+)
+
+// Major types of CBOR data items.
+const (
+	majorUnsigned = 0
+	majorNegative = 1
+	majorBytes    = 2
+	majorText     = 3
+	majorArray    = 4
+	majorMap      = 5
+	majorTag      = 6
+	majorSimple   = 7
+)
+
+// Simple values.
+const (
+	simpleFalse = 20
+	simpleTrue  = 21
+	simpleNull  = 22
+)
+
+// maxDepth bounds the nesting of decoded data items.
+const maxDepth = 32
+
+// ErrInvalid is returned when decoding malformed or unsupported CBOR.
+var ErrInvalid = errors.New("cbor: invalid data")
+
+// Tag is a tagged data item.
+type Tag struct {
+	Number  uint64
+	Content interface{}
+}
+
+// Marshal encodes a value deterministically.
+//
+// Supported types are nil, bool, the integer types, []byte, string, []interface{}, []string, []int,
+// map[string]interface{}, map[int]interface{}, map[int64]interface{} and Tag.
+func Marshal(value interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := encode(&buf, value); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Unmarshal decodes a single data item that must span the whole input.
+//
+// Unsigned integers decode to uint64, negative integers to int64, byte strings to []byte, text strings to string,
+// arrays to []interface{}, maps to map[string]interface{} or map[int64]interface{}, tags to Tag, and simple values
+// to bool or nil.
+func Unmarshal(data []byte) (interface{}, error) {
+	d := decoder{data: data}
+	value, err := d.decode(0)
+	if err != nil {
+		return nil, err
+	}
+	if d.offset != len(data) {
+		return nil, fmt.Errorf("%w: trailing data", ErrInvalid)
+	}
+	return value, nil
+}
+
+// encode appends the encoding of the value to the buffer.
+func encode(buf *bytes.Buffer, value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		buf.WriteByte(majorSimple<<5 | simpleNull)
+	case bool:
+		if v {
+			buf.WriteByte(majorSimple<<5 | simpleTrue)
+		} else {
+			buf.WriteByte(majorSimple<<5 | simpleFalse)
+		}
+	case int:
+		encodeInt(buf, int64(v))
+	case int64:
+		encodeInt(buf, v)
+	case uint:
+		writeHead(buf, majorUnsigned, uint64(v))
+	case uint64:
+		writeHead(buf, majorUnsigned, v)
+	case []byte:
+		writeHead(buf, majorBytes, uint64(len(v)))
+		buf.Write(v)
+	case string:
+		writeHead(buf, majorText, uint64(len(v)))
+		buf.WriteString(v)
+	case []interface{}:
+		writeHead(buf, majorArray, uint64(len(v)))
+		for _, element := range v {
+			if err := encode(buf, element); err != nil {
+				return err
+			}
+		}
+	case []string:
+		writeHead(buf, majorArray, uint64(len(v)))
+		for _, element := range v {
+			encode(buf, element)
+		}
+	case []int:
+		writeHead(buf, majorArray, uint64(len(v)))
+		for _, element := range v {
+			encodeInt(buf, int64(element))
+		}
+	case map[string]interface{}:
+		entries := make(map[interface{}]interface{}, len(v))
+		for key, value := range v {
+			entries[key] = value
+		}
+		return encodeMap(buf, entries)
+	case map[int]interface{}:
+		entries := make(map[interface{}]interface{}, len(v))
+		for key, value := range v {
+			entries[key] = value
+		}
+		return encodeMap(buf, entries)
+	case map[int64]interface{}:
+		entries := make(map[interface{}]interface{}, len(v))
+		for key, value := range v {
+			entries[key] = value
+		}
+		return encodeMap(buf, entries)
+	case Tag:
+		writeHead(buf, majorTag, v.Number)
+		return encode(buf, v.Content)
+	default:
+		return fmt.Errorf("cbor: unsupported type %T", value)
+	}
+	return nil
+}
+
+// encodeInt appends a signed integer.
+func encodeInt(buf *bytes.Buffer, v int64) {
+	if v >= 0 {
+		writeHead(buf, majorUnsigned, uint64(v))
+	} else {
+		writeHead(buf, majorNegative, uint64(-(v + 1)))
+	}
+}
+
+// encodeMap appends a map with its entries sorted by the bytewise order of the encoded keys.
+func encodeMap(buf *bytes.Buffer, m map[interface{}]interface{}) error {
+	type entry struct {
+		key   []byte
+		value interface{}
+	}
+	entries := make([]entry, 0, len(m))
+	for key, value := range m {
+		var encodedKey bytes.Buffer
+		if err := encode(&encodedKey, key); err != nil {
+			return err
+		}
+		entries = append(entries, entry{key: encodedKey.Bytes(), value: value})
+	}
+	sort.Slice(entries, func(i, j int) bool { return bytes.Compare(entries[i].key, entries[j].key) < 0 })
+
+	writeHead(buf, majorMap, uint64(len(m)))
+	for _, e := range entries {
+		buf.Write(e.key)
+		if err := encode(buf, e.value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeHead appends the initial byte and argument of a data item in their shortest form.
+func writeHead(buf *bytes.Buffer, major byte, argument uint64) {
+	switch {
+	case argument < 24:
+		buf.WriteByte(major<<5 | byte(argument))
+	case argument <= math.MaxUint8:
+		buf.WriteByte(major<<5 | 24)
+		buf.WriteByte(byte(argument))
+	case argument <= math.MaxUint16:
+		buf.WriteByte(major<<5 | 25)
+		buf.Write(binary.BigEndian.AppendUint16(nil, uint16(argument)))
+	case argument <= math.MaxUint32:
+		buf.WriteByte(major<<5 | 26)
+		buf.Write(binary.BigEndian.AppendUint32(nil, uint32(argument)))
+	default:
+		buf.WriteByte(major<<5 | 27)
+		buf.Write(binary.BigEndian.AppendUint64(nil, argument))
+	}
+}
+
+// decoder reads data items from a byte slice.
+type decoder struct {
+	data   []byte
+	offset int
+}
+
+// decode reads the next data item.
+func (d *decoder) decode(depth int) (interface{}, error) {
+	if depth > maxDepth {
+		return nil, fmt.Errorf("%w: nesting too deep", ErrInvalid)
+	}
+	major, argument, err := d.readHead()
+	if err != nil {
+		return nil, err
+	}
+
+	switch major {
+	case majorUnsigned:
+		return argument, nil
+	case majorNegative:
+		if argument > math.MaxInt64 {
+			return nil, fmt.Errorf("%w: negative integer out of range", ErrInvalid)
+		}
+		return -int64(argument) - 1, nil
+	case majorBytes:
+		content, err := d.read(argument)
+		if err != nil {
+			return nil, err
+		}
+		return append([]byte(nil), content...), nil
+	case majorText:
+		content, err := d.read(argument)
+		if err != nil {
+			return nil, err
+		}
+		if !utf8.Valid(content) {
+			return nil, fmt.Errorf("%w: text string is not valid UTF-8", ErrInvalid)
+		}
+		return string(content), nil
+	case majorArray:
+		// Every element takes at least one byte, which bounds the allocation
+		if argument > uint64(len(d.data)-d.offset) {
+			return nil, fmt.Errorf("%w: array length exceeds the input", ErrInvalid)
+		}
+		array := make([]interface{}, argument)
+		for i := range array {
+			if array[i], err = d.decode(depth + 1); err != nil {
+				return nil, err
+			}
+		}
+		return array, nil
+	case majorMap:
+		if argument > uint64(len(d.data)-d.offset)/2 {
+			return nil, fmt.Errorf("%w: map length exceeds the input", ErrInvalid)
+		}
+		return d.decodeMap(argument, depth)
+	case majorTag:
+		content, err := d.decode(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		return Tag{Number: argument, Content: content}, nil
+	default:
+		switch argument {
+		case simpleFalse:
+			return false, nil
+		case simpleTrue:
+			return true, nil
+		case simpleNull:
+			return nil, nil
+		}
+		return nil, fmt.Errorf("%w: unsupported simple value %d", ErrInvalid, argument)
+	}
+}
+
+// decodeMap reads the entries of a map. Maps with text keys decode to map[string]interface{} and maps with
+// integer keys to map[int64]interface{}; mixing both kinds of keys is not supported.
+func (d *decoder) decodeMap(count uint64, depth int) (interface{}, error) {
+	textMap := make(map[string]interface{})
+	intMap := make(map[int64]interface{})
+	for i := uint64(0); i < count; i++ {
+		key, err := d.decode(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		value, err := d.decode(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		switch k := key.(type) {
+		case string:
+			if _, exists := textMap[k]; exists {
+				return nil, fmt.Errorf("%w: duplicate map key %q", ErrInvalid, k)
+			}
+			textMap[k] = value
+		case uint64:
+			if k > math.MaxInt64 {
+				return nil, fmt.Errorf("%w: map key out of range", ErrInvalid)
+			}
+			if _, exists := intMap[int64(k)]; exists {
+				return nil, fmt.Errorf("%w: duplicate map key %d", ErrInvalid, k)
+			}
+			intMap[int64(k)] = value
+		case int64:
+			if _, exists := intMap[k]; exists {
+				return nil, fmt.Errorf("%w: duplicate map key %d", ErrInvalid, k)
+			}
+			intMap[k] = value
+		default:
+			return nil, fmt.Errorf("%w: map key is neither a text string nor an integer", ErrInvalid)
+		}
+		if len(textMap) > 0 && len(intMap) > 0 {
+			return nil, fmt.Errorf("%w: map mixes text and integer keys", ErrInvalid)
+		}
+	}
+	if len(intMap) > 0 {
+		return intMap, nil
+	}
+	return textMap, nil
+}
+
+// readHead reads the initial byte and argument of a data item, rejecting indefinite lengths and non-shortest forms.
+func (d *decoder) readHead() (byte, uint64, error) {
+	head, err := d.read(1)
+	if err != nil {
+		return 0, 0, err
+	}
+	major, info := head[0]>>5, head[0]&0x1f
+	if info < 24 {
+		return major, uint64(info), nil
+	}
+	if major == majorSimple {
+		return 0, 0, fmt.Errorf("%w: floating-point and extended simple values are not supported", ErrInvalid)
+	}
+
+	var argument, minimum uint64
+	switch info {
+	case 24:
+		b, err := d.read(1)
+		if err != nil {
+			return 0, 0, err
+		}
+		argument, minimum = uint64(b[0]), 24
+	case 25:
+		b, err := d.read(2)
+		if err != nil {
+			return 0, 0, err
+		}
+		argument, minimum = uint64(binary.BigEndian.Uint16(b)), math.MaxUint8+1
+	case 26:
+		b, err := d.read(4)
+		if err != nil {
+			return 0, 0, err
+		}
+		argument, minimum = uint64(binary.BigEndian.Uint32(b)), math.MaxUint16+1
+	case 27:
+		b, err := d.read(8)
+		if err != nil {
+			return 0, 0, err
+		}
+		argument, minimum = binary.BigEndian.Uint64(b), math.MaxUint32+1
+	default:
+		return 0, 0, fmt.Errorf("%w: indefinite lengths are not supported", ErrInvalid)
+	}
+	if argument < minimum {
+		return 0, 0, fmt.Errorf("%w: argument not in its shortest form", ErrInvalid)
+	}
+	return major, argument, nil
+}
+
+// read consumes n bytes of input.
+func (d *decoder) read(n uint64) ([]byte, error) {
+	if n > uint64(len(d.data)-d.offset) {
+		return nil, fmt.Errorf("%w: unexpected end of input", ErrInvalid)
+	}
+	content := d.data[d.offset : d.offset+int(n)]
+	d.offset += int(n)
+	return content, nil
+}
@@ -0,0 +1,90 @@
+package cbor
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Test for the encoding of the RFC 8949 appendix A examples
+func TestMarshal_Examples(t *testing.T) {
+	cases := []struct {
+		value    interface{}
+		expected string
+	}{
+		{0, "00"},
+		{23, "17"},
+		{24, "1818"},
+		{1000, "1903e8"},
+		{uint64(1000000000000), "1b000000e8d4a51000"},
+		{-1, "20"},
+		{-1000, "3903e7"},
+		{false, "f4"},
+		{true, "f5"},
+		{nil, "f6"},
+		{[]byte{1, 2, 3, 4}, "4401020304"},
+		{"IETF", "6449455446"},
+		{[]int{1, 2, 3}, "83010203"},
+		{[]interface{}{1, []int{2, 3}, []int{4, 5}}, "8301820203820405"},
+		{map[string]interface{}{"a": 1, "b": []int{2, 3}}, "a26161016162820203"},
+		{Tag{Number: 1, Content: 1363896240}, "c11a514b67b0"},
+		{map[int]interface{}{4: []byte{1}, 1: -7}, "a20126044101"},
+	}
+	for _, c := range cases {
+		encoded, err := Marshal(c.value)
+		assert.NoError(t, err, "Expected no error encoding %v", c.value)
+		assert.Equal(t, c.expected, hex.EncodeToString(encoded), "Unexpected encoding of %v", c.value)
+	}
+}
+
+// Test for the deterministic ordering of map keys
+func TestMarshal_MapOrder(t *testing.T) {
+	encoded, err := Marshal(map[string]interface{}{"aa": 1, "b": 2, "a": 3})
+
+	assert.NoError(t, err, "Expected no error encoding the map")
+	assert.Equal(t, "a361610361620262616101", hex.EncodeToString(encoded), "Expected keys sorted by their encoding")
+}
+
+// Test for a round trip through Marshal and Unmarshal
+func TestUnmarshal_RoundTrip(t *testing.T) {
+	value := []interface{}{
+		[]byte{0xde, 0xad},
+		"text",
+		uint64(1 << 40),
+		int64(-5),
+		map[string]interface{}{"k": []interface{}{true, nil}},
+		map[int64]interface{}{1: int64(-7), 4: []byte{1}},
+		Tag{Number: 0x5d02, Content: []interface{}{}},
+	}
+
+	encoded, err := Marshal(value)
+	assert.NoError(t, err, "Expected no error encoding the value")
+	decoded, err := Unmarshal(encoded)
+	assert.NoError(t, err, "Expected no error decoding the value")
+
+	assert.Equal(t, value, decoded, "Expected the decoded value to equal the original")
+}
+
+// Test for malformed and non-deterministic input
+func TestUnmarshal_Invalid(t *testing.T) {
+	cases := map[string]string{
+		"truncated":       "4401",
+		"trailing":        "0000",
+		"non-shortest":    "1817",
+		"indefinite":      "5f",
+		"float":           "f93c00",
+		"huge array":      "9b00000000ffffffff",
+		"byte string key": "a1410102",
+		"mixed keys":      "a26161010102",
+		"duplicate key":   "a2616101616102",
+		"invalid utf-8":   "62c328",
+		"negative range":  "3bffffffffffffffff",
+		"deeply nested":   "8181818181818181818181818181818181818181818181818181818181818181818100",
+	}
+	for name, input := range cases {
+		data, _ := hex.DecodeString(input)
+		_, err := Unmarshal(data)
+		assert.ErrorIs(t, err, ErrInvalid, "Expected an error for %s input", name)
+	}
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/aniagut/msc-bbs-anonymous-credentials/models"
+)
+
+// Version of the on-disk format written by the tool.
+const formatVersion = 1
+
+// File types of the on-disk format.
+const (
+	typePublicParameters = "public-parameters"
+	typePublicKey        = "public-key"
+	typeSecretKey        = "secret-key"
+	typeCredential       = "credential"
+	typePresentation     = "presentation"
+)
+
+// envelope is the on-disk form of every file written by the tool.
+// It contains the following elements:
+// - Type: The kind of the content, one of the type* constants.
+// - Version: The version of the format.
+// - Data: The JSON encoding of the content.
+type envelope struct {
+	Type    string          `json:"type"`
+	Version int             `json:"version"`
+	Data    json.RawMessage `json:"data"`
+}
+
+// attribute is an element of an attribute file, which is a JSON array of named values in signing order.
+type attribute struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// presentationFile is the content of a presentation file: the response of the holder and the nonce it is bound to.
+type presentationFile struct {
+	Nonce []byte `json:"nonce"`
+	models.PresentationResponse
+}
+
+// writeFile writes the content to path inside an envelope of the given type.
+func writeFile(path string, fileType string, content interface{}, perm os.FileMode) error {
+	data, err := json.Marshal(content)
+	if err != nil {
+		return err
+	}
+	out, err := json.MarshalIndent(envelope{Type: fileType, Version: formatVersion, Data: data}, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(out, '\n'), perm)
+}
+
+// readEnvelope reads the envelope stored at path.
+func readEnvelope(path string) (envelope, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return envelope{}, err
+	}
+	var env envelope
+	if err := json.Unmarshal(data, &env); err != nil {
+		return envelope{}, fmt.Errorf("%s: %w", path, err)
+	}
+	if env.Version != formatVersion {
+		return envelope{}, fmt.Errorf("%s: unsupported format version %d", path, env.Version)
+	}
+	return env, nil
+}
+
+// readFile reads the content of the given type stored at path into content.
+func readFile(path string, fileType string, content interface{}) error {
+	env, err := readEnvelope(path)
+	if err != nil {
+		return err
+	}
+	if env.Type != fileType {
+		return fmt.Errorf("%s: expected a %s file, got %s", path, fileType, env.Type)
+	}
+	if err := json.Unmarshal(env.Data, content); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	return nil
+}
+
+// readCredential reads a credential file and checks that every attribute name has a value.
+func readCredential(path string) (models.Credential, error) {
+	var credential models.Credential
+	if err := readFile(path, typeCredential, &credential); err != nil {
+		return models.Credential{}, err
+	}
+	if len(credential.Schema) != len(credential.Attributes) {
+		return models.Credential{}, fmt.Errorf("%s: %w: %d attribute names, %d values", path, models.ErrLengthMismatch, len(credential.Schema), len(credential.Attributes))
+	}
+	return credential, nil
+}
+
+// readPresentation reads a presentation file and checks that every revealed index has a value.
+func readPresentation(path string) (presentationFile, error) {
+	var in presentationFile
+	if err := readFile(path, typePresentation, &in); err != nil {
+		return presentationFile{}, err
+	}
+	if len(in.RevealedIndices) != len(in.RevealedAttributes) {
+		return presentationFile{}, fmt.Errorf("%s: %w: %d revealed indices, %d revealed attributes", path, models.ErrLengthMismatch, len(in.RevealedIndices), len(in.RevealedAttributes))
+	}
+	return in, nil
+}
+
+// readAttributes reads an attribute file and returns the attribute names and values.
+func readAttributes(path string) ([]string, []string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	var attributes []attribute
+	if err := json.Unmarshal(data, &attributes); err != nil {
+		return nil, nil, fmt.Errorf("%s: %w", path, err)
+	}
+	schema := make([]string, len(attributes))
+	values := make([]string, len(attributes))
+	seen := make(map[string]bool, len(attributes))
+	for i, a := range attributes {
+		if a.Name == "" || seen[a.Name] {
+			return nil, nil, fmt.Errorf("%s: attribute names must be unique and non-empty", path)
+		}
+		seen[a.Name] = true
+		schema[i] = a.Name
+		values[i] = a.Value
+	}
+	return schema, values, nil
+}
@@ -0,0 +1,440 @@
+// Command bbscred runs the BBS++ anonymous credential protocols over files.
+//
+// Usage:
+//
+//	bbscred setup   -l <attributes> -dir <directory>
+//	bbscred issue   -params <file> (-sk <file> | -signer <socket> -pk <file>) -attrs <file> -out <file>
+//	bbscred signer  -sk <file> -socket <path>
+//	bbscred present -params <file> -cred <file> -reveal <names> -nonce <hex> -out <file>
+//	bbscred verify  -params <file> -pk <file> -presentation <file> -nonce <hex> [-schema <file>]
+//	bbscred inspect <file>
+//
+// The signer command runs a keystore process that holds the secret key and signs for issue -signer over a Unix socket,
+// so that the issuing process never reads the secret key file. It serves until interrupted.
+//
+// Keys, parameters, credentials and presentations are stored as JSON envelopes holding the file type,
+// the format version and the content. Attribute files are JSON arrays of {"name", "value"} objects in signing order.
+//
+// Exit codes:
+//
+//	0 - success (for verify: the presentation is valid)
+//	1 - the presentation is invalid
+//	2 - invalid command line
+//	3 - the command failed (unreadable or malformed files, protocol errors)
+//
+// verify requires the nonce the verifier issued for the presentation: the nonce stored in the presentation file is
+// chosen by the holder, so checking against it would accept replayed presentations.
+package main
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+
+	e "github.com/cloudflare/circl/ecc/bls12381"
+
+	"github.com/aniagut/msc-bbs-anonymous-credentials/issue"
+	"github.com/aniagut/msc-bbs-anonymous-credentials/models"
+	"github.com/aniagut/msc-bbs-anonymous-credentials/presentation"
+	"github.com/aniagut/msc-bbs-anonymous-credentials/remotesigner"
+	"github.com/aniagut/msc-bbs-anonymous-credentials/setup"
+	"github.com/aniagut/msc-bbs-anonymous-credentials/verify"
+)
+
+// Exit codes of the tool.
+const (
+	exitOK      = 0
+	exitInvalid = 1
+	exitUsage   = 2
+	exitFailure = 3
+)
+
+// errUsage marks errors caused by an invalid command line.
+var errUsage = errors.New("usage error")
+
+// errInvalid marks presentations that fail verification.
+var errInvalid = errors.New("invalid presentation")
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+// run executes the subcommand given in args and returns the exit code.
+func run(args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		fmt.Fprintln(stderr, "usage: bbscred <setup|issue|signer|present|verify|inspect> [flags]")
+		return exitUsage
+	}
+
+	var err error
+	switch args[0] {
+	case "setup":
+		err = runSetup(args[1:], stdout)
+	case "issue":
+		err = runIssue(args[1:], stdout)
+	case "signer":
+		err = runSigner(args[1:], stdout)
+	case "present":
+		err = runPresent(args[1:], stdout)
+	case "verify":
+		err = runVerify(args[1:], stdout)
+	case "inspect":
+		err = runInspect(args[1:], stdout)
+	default:
+		err = fmt.Errorf("%w: unknown command %q", errUsage, args[0])
+	}
+
+	switch {
+	case err == nil:
+		return exitOK
+	case errors.Is(err, errInvalid):
+		fmt.Fprintf(stderr, "bbscred: %v\n", err)
+		return exitInvalid
+	case errors.Is(err, errUsage), errors.Is(err, flag.ErrHelp):
+		fmt.Fprintf(stderr, "bbscred: %v\n", err)
+		return exitUsage
+	default:
+		fmt.Fprintf(stderr, "bbscred: %v\n", err)
+		return exitFailure
+	}
+}
+
+// parseFlags parses the flags of a subcommand and checks that the required ones are set.
+func parseFlags(fs *flag.FlagSet, args []string, required ...string) error {
+	fs.SetOutput(io.Discard)
+	if err := fs.Parse(args); err != nil {
+		return fmt.Errorf("%w: %v", errUsage, err)
+	}
+	for _, name := range required {
+		if fs.Lookup(name).Value.String() == "" {
+			return fmt.Errorf("%w: %s: -%s is required", errUsage, fs.Name(), name)
+		}
+	}
+	return nil
+}
+
+// runSetup generates the public parameters and the issuer keys.
+func runSetup(args []string, stdout io.Writer) error {
+	fs := flag.NewFlagSet("setup", flag.ContinueOnError)
+	l := fs.Int("l", 0, "number of attributes")
+	dir := fs.String("dir", ".", "output directory")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if *l <= 0 {
+		return fmt.Errorf("%w: setup: -l must be greater than 0", errUsage)
+	}
+
+	result, err := setup.Setup(*l)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(*dir, 0o755); err != nil {
+		return err
+	}
+	if err := writeFile(filepath.Join(*dir, "params.json"), typePublicParameters, result.PublicParameters, 0o644); err != nil {
+		return err
+	}
+	if err := writeFile(filepath.Join(*dir, "public_key.json"), typePublicKey, result.PublicKey, 0o644); err != nil {
+		return err
+	}
+	if err := writeFile(filepath.Join(*dir, "secret_key.json"), typeSecretKey, result.SecretKey, 0o600); err != nil {
+		return err
+	}
+	fmt.Fprintf(stdout, "key ID: %s\n", result.PublicKey.KeyID())
+	return nil
+}
+
+// runIssue issues a credential over the attributes of an attribute file, with the secret key file or a keystore process.
+func runIssue(args []string, stdout io.Writer) error {
+	fs := flag.NewFlagSet("issue", flag.ContinueOnError)
+	paramsPath := fs.String("params", "", "public parameters file")
+	skPath := fs.String("sk", "", "secret key file")
+	signerPath := fs.String("signer", "", "socket of the keystore process started with bbscred signer")
+	pkPath := fs.String("pk", "", "public key file, required with -signer")
+	attrsPath := fs.String("attrs", "", "attribute file")
+	outPath := fs.String("out", "", "credential file")
+	if err := parseFlags(fs, args, "params", "attrs", "out"); err != nil {
+		return err
+	}
+	if (*skPath == "") == (*signerPath == "") {
+		return fmt.Errorf("%w: issue: exactly one of -sk and -signer is required", errUsage)
+	}
+	if *signerPath != "" && *pkPath == "" {
+		return fmt.Errorf("%w: issue: -pk is required with -signer", errUsage)
+	}
+
+	var params models.PublicParameters
+	if err := readFile(*paramsPath, typePublicParameters, &params); err != nil {
+		return err
+	}
+	schema, values, err := readAttributes(*attrsPath)
+	if err != nil {
+		return err
+	}
+
+	// Select the signer and the public key identifying the issuer
+	var signer issue.Signer
+	var pk models.PublicKey
+	if *signerPath != "" {
+		if err := readFile(*pkPath, typePublicKey, &pk); err != nil {
+			return err
+		}
+		signer = remotesigner.NewClient(*signerPath, pk)
+	} else {
+		var sk models.SecretKey
+		if err := readFile(*skPath, typeSecretKey, &sk); err != nil {
+			return err
+		}
+		signer = issue.NewKeySigner(sk)
+		// The public key is X2 ← g2^x
+		x2 := new(e.G2)
+		x2.ScalarMult(sk.X, params.G2)
+		pk = models.PublicKey{X2: x2}
+	}
+
+	signature, err := issue.IssueWithSigner(values, params, signer)
+	if err != nil {
+		return err
+	}
+
+	credential := models.Credential{
+		Schema:      schema,
+		Attributes:  values,
+		Signature:   signature,
+		IssuerKeyID: pk.KeyID(),
+	}
+	if err := writeFile(*outPath, typeCredential, credential, 0o600); err != nil {
+		return err
+	}
+	fmt.Fprintf(stdout, "issued credential over %d attributes\n", len(values))
+	return nil
+}
+
+// runSigner serves the secret key on a Unix socket until the process is interrupted.
+func runSigner(args []string, stdout io.Writer) error {
+	fs := flag.NewFlagSet("signer", flag.ContinueOnError)
+	skPath := fs.String("sk", "", "secret key file")
+	socketPath := fs.String("socket", "", "path of the Unix socket")
+	if err := parseFlags(fs, args, "sk", "socket"); err != nil {
+		return err
+	}
+
+	var sk models.SecretKey
+	if err := readFile(*skPath, typeSecretKey, &sk); err != nil {
+		return err
+	}
+	listener, err := remotesigner.Listen(*socketPath)
+	if err != nil {
+		return err
+	}
+
+	// Stop serving on SIGINT or SIGTERM, which also removes the socket
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		listener.Close()
+	}()
+	fmt.Fprintf(stdout, "serving signer on %s\n", *socketPath)
+	return remotesigner.NewServer(issue.NewKeySigner(sk)).Serve(listener)
+}
+
+// runPresent presents a credential, revealing the named attributes.
+func runPresent(args []string, stdout io.Writer) error {
+	fs := flag.NewFlagSet("present", flag.ContinueOnError)
+	paramsPath := fs.String("params", "", "public parameters file")
+	credPath := fs.String("cred", "", "credential file")
+	reveal := fs.String("reveal", "", "comma-separated names of the attributes to reveal (empty reveals nothing)")
+	nonceHex := fs.String("nonce", "", "hex-encoded nonce issued by the verifier")
+	outPath := fs.String("out", "", "presentation file")
+	if err := parseFlags(fs, args, "params", "cred", "nonce", "out"); err != nil {
+		return err
+	}
+	nonce, err := hex.DecodeString(*nonceHex)
+	if err != nil {
+		return fmt.Errorf("%w: present: invalid nonce: %v", errUsage, err)
+	}
+
+	var params models.PublicParameters
+	if err := readFile(*paramsPath, typePublicParameters, &params); err != nil {
+		return err
+	}
+	credential, err := readCredential(*credPath)
+	if err != nil {
+		return err
+	}
+	revealed, err := revealedIndices(credential.Schema, *reveal)
+	if err != nil {
+		return err
+	}
+
+	proof, err := presentation.Presentation(credential.Attributes, credential.Signature, revealed, params, nonce)
+	if err != nil {
+		return err
+	}
+	revealedAttributes := make([]string, len(revealed))
+	for i, index := range revealed {
+		revealedAttributes[i] = credential.Attributes[index]
+	}
+	out := presentationFile{
+		Nonce: nonce,
+		PresentationResponse: models.PresentationResponse{
+			IssuerKeyID:        credential.IssuerKeyID,
+			Proof:              proof,
+			RevealedIndices:    revealed,
+			RevealedAttributes: revealedAttributes,
+		},
+	}
+	if err := writeFile(*outPath, typePresentation, out, 0o644); err != nil {
+		return err
+	}
+	fmt.Fprintf(stdout, "presented credential revealing %d of %d attributes\n", len(revealed), len(credential.Attributes))
+	return nil
+}
+
+// runVerify verifies a presentation against the issuer key.
+func runVerify(args []string, stdout io.Writer) error {
+	fs := flag.NewFlagSet("verify", flag.ContinueOnError)
+	paramsPath := fs.String("params", "", "public parameters file")
+	pkPath := fs.String("pk", "", "public key file")
+	schemaPath := fs.String("schema", "", "attribute file defining the attribute names (values are ignored)")
+	presentationPath := fs.String("presentation", "", "presentation file")
+	nonceHex := fs.String("nonce", "", "hex-encoded nonce the presentation must be bound to")
+	if err := parseFlags(fs, args, "params", "pk", "presentation", "nonce"); err != nil {
+		return err
+	}
+
+	var params models.PublicParameters
+	if err := readFile(*paramsPath, typePublicParameters, &params); err != nil {
+		return err
+	}
+	var pk models.PublicKey
+	if err := readFile(*pkPath, typePublicKey, &pk); err != nil {
+		return err
+	}
+	in, err := readPresentation(*presentationPath)
+	if err != nil {
+		return err
+	}
+	nonce, err := hex.DecodeString(*nonceHex)
+	if err != nil {
+		return fmt.Errorf("%w: verify: invalid nonce: %v", errUsage, err)
+	}
+	if string(nonce) != string(in.Nonce) {
+		return fmt.Errorf("%w: the presentation is bound to another nonce", errInvalid)
+	}
+	if in.IssuerKeyID != pk.KeyID() {
+		return fmt.Errorf("%w: the presentation was issued under key %s", errInvalid, in.IssuerKeyID)
+	}
+
+	// Only a failed check of the proof makes the presentation invalid; malformed input fails the command
+	valid, err := verify.Verify(in.Proof, nonce, in.RevealedAttributes, in.RevealedIndices, params, pk)
+	switch {
+	case errors.Is(err, models.ErrChallengeMismatch), errors.Is(err, models.ErrPairingFailed):
+		return fmt.Errorf("%w: %v", errInvalid, err)
+	case err != nil:
+		return fmt.Errorf("verify: %w", err)
+	case !valid:
+		return fmt.Errorf("%w: the proof does not verify", errInvalid)
+	}
+
+	var schema []string
+	if *schemaPath != "" {
+		if schema, _, err = readAttributes(*schemaPath); err != nil {
+			return err
+		}
+	}
+	fmt.Fprintln(stdout, "valid")
+	for i, index := range in.RevealedIndices {
+		name := fmt.Sprintf("#%d", index)
+		if index < len(schema) {
+			name = schema[index]
+		}
+		fmt.Fprintf(stdout, "%s: %s\n", name, in.RevealedAttributes[i])
+	}
+	return nil
+}
+
+// runInspect prints a summary of a file written by the tool.
+func runInspect(args []string, stdout io.Writer) error {
+	if len(args) != 1 {
+		return fmt.Errorf("%w: inspect takes exactly one file", errUsage)
+	}
+	env, err := readEnvelope(args[0])
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(stdout, "type: %s\nversion: %d\n", env.Type, env.Version)
+	switch env.Type {
+	case typePublicParameters:
+		var params models.PublicParameters
+		if err := readFile(args[0], env.Type, &params); err != nil {
+			return err
+		}
+		fmt.Fprintf(stdout, "attributes: %d\n", len(params.H1))
+	case typePublicKey:
+		var pk models.PublicKey
+		if err := readFile(args[0], env.Type, &pk); err != nil {
+			return err
+		}
+		fmt.Fprintf(stdout, "key ID: %s\n", pk.KeyID())
+	case typeSecretKey:
+		var sk models.SecretKey
+		if err := readFile(args[0], env.Type, &sk); err != nil {
+			return err
+		}
+		fmt.Fprintln(stdout, "secret key: present")
+	case typeCredential:
+		credential, err := readCredential(args[0])
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(stdout, "issuer key ID: %s\n", credential.IssuerKeyID)
+		for i, name := range credential.Schema {
+			fmt.Fprintf(stdout, "%s: %s\n", name, credential.Attributes[i])
+		}
+	case typePresentation:
+		in, err := readPresentation(args[0])
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(stdout, "issuer key ID: %s\nnonce: %s\nhidden attributes: %d\n", in.IssuerKeyID, hex.EncodeToString(in.Nonce), len(in.Proof.Zi))
+		for i, index := range in.RevealedIndices {
+			fmt.Fprintf(stdout, "#%d: %s\n", index, in.RevealedAttributes[i])
+		}
+	default:
+		return fmt.Errorf("unknown file type %q", env.Type)
+	}
+	return nil
+}
+
+// revealedIndices maps comma-separated attribute names to their sorted indices in the schema.
+func revealedIndices(schema []string, names string) ([]int, error) {
+	reveal := make(map[string]bool)
+	if names != "" {
+		for _, name := range strings.Split(names, ",") {
+			reveal[strings.TrimSpace(name)] = true
+		}
+	}
+	indices := make([]int, 0, len(reveal))
+	for i, name := range schema {
+		if reveal[name] {
+			indices = append(indices, i)
+			delete(reveal, name)
+		}
+	}
+	for name := range reveal {
+		return nil, fmt.Errorf("%w: attribute %q is not part of the credential", errUsage, name)
+	}
+	return indices, nil
+}
@@ -0,0 +1,177 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/aniagut/msc-bbs-anonymous-credentials/issue"
+	"github.com/aniagut/msc-bbs-anonymous-credentials/models"
+	"github.com/aniagut/msc-bbs-anonymous-credentials/remotesigner"
+	"github.com/stretchr/testify/assert"
+)
+
+// runTool runs the tool with the given arguments and returns the exit code and the output
+func runTool(args ...string) (int, string) {
+	var stdout, stderr bytes.Buffer
+	code := run(args, &stdout, &stderr)
+	return code, stdout.String() + stderr.String()
+}
+
+// Test for the full setup, issue, present and verify flow over files
+func TestRun_Flow(t *testing.T) {
+	dir := t.TempDir()
+	attrs := filepath.Join(dir, "attributes.json")
+	err := os.WriteFile(attrs, []byte(`[{"name":"name","value":"Alice"},{"name":"age","value":"30"},{"name":"country","value":"PL"}]`), 0o644)
+	assert.NoError(t, err, "Expected no error writing the attribute file")
+
+	code, out := runTool("setup", "-l", "3", "-dir", dir)
+	assert.Equal(t, exitOK, code, out)
+
+	cred := filepath.Join(dir, "credential.json")
+	code, out = runTool("issue", "-params", filepath.Join(dir, "params.json"), "-sk", filepath.Join(dir, "secret_key.json"), "-attrs", attrs, "-out", cred)
+	assert.Equal(t, exitOK, code, out)
+
+	pres := filepath.Join(dir, "presentation.json")
+	code, out = runTool("present", "-params", filepath.Join(dir, "params.json"), "-cred", cred, "-reveal", "country", "-nonce", "0a0b0c", "-out", pres)
+	assert.Equal(t, exitOK, code, out)
+
+	code, out = runTool("verify", "-params", filepath.Join(dir, "params.json"), "-pk", filepath.Join(dir, "public_key.json"), "-schema", attrs, "-presentation", pres, "-nonce", "0a0b0c")
+	assert.Equal(t, exitOK, code, out)
+	assert.Contains(t, out, "country: PL", "Expected the revealed attribute to be printed")
+
+	code, out = runTool("verify", "-params", filepath.Join(dir, "params.json"), "-pk", filepath.Join(dir, "public_key.json"), "-presentation", pres, "-nonce", "ffff")
+	assert.Equal(t, exitInvalid, code, "Expected a presentation bound to another nonce to be invalid: "+out)
+	code, out = runTool("verify", "-params", filepath.Join(dir, "params.json"), "-pk", filepath.Join(dir, "public_key.json"), "-presentation", pres)
+	assert.Equal(t, exitUsage, code, "Expected verifying without the nonce of the verifier to be refused: "+out)
+
+	// A presentation whose revealed value was changed is invalid
+	var in presentationFile
+	assert.NoError(t, readFile(pres, typePresentation, &in), "Expected no error reading the presentation")
+	in.RevealedAttributes[0] = "DE"
+	forged := filepath.Join(dir, "forged.json")
+	assert.NoError(t, writeFile(forged, typePresentation, in, 0o644), "Expected no error writing the presentation")
+	code, out = runTool("verify", "-params", filepath.Join(dir, "params.json"), "-pk", filepath.Join(dir, "public_key.json"), "-presentation", forged, "-nonce", "0a0b0c")
+	assert.Equal(t, exitInvalid, code, "Expected a modified presentation to be invalid: "+out)
+
+	// A presentation revealing an index out of range is malformed, not invalid
+	in.RevealedIndices[0] = 7
+	assert.NoError(t, writeFile(forged, typePresentation, in, 0o644), "Expected no error writing the presentation")
+	code, out = runTool("verify", "-params", filepath.Join(dir, "params.json"), "-pk", filepath.Join(dir, "public_key.json"), "-presentation", forged, "-nonce", "0a0b0c")
+	assert.Equal(t, exitFailure, code, "Expected a malformed presentation to fail: "+out)
+
+	code, out = runTool("inspect", pres)
+	assert.Equal(t, exitOK, code, out)
+	assert.Contains(t, out, "type: presentation", "Expected the file type to be printed")
+}
+
+// Test for a presentation checked against another issuer key
+func TestRun_WrongKey(t *testing.T) {
+	dir, other := t.TempDir(), t.TempDir()
+	attrs := filepath.Join(dir, "attributes.json")
+	err := os.WriteFile(attrs, []byte(`[{"name":"name","value":"Alice"}]`), 0o644)
+	assert.NoError(t, err, "Expected no error writing the attribute file")
+
+	for _, d := range []string{dir, other} {
+		code, out := runTool("setup", "-l", "1", "-dir", d)
+		assert.Equal(t, exitOK, code, out)
+	}
+	cred := filepath.Join(dir, "credential.json")
+	code, out := runTool("issue", "-params", filepath.Join(dir, "params.json"), "-sk", filepath.Join(dir, "secret_key.json"), "-attrs", attrs, "-out", cred)
+	assert.Equal(t, exitOK, code, out)
+	pres := filepath.Join(dir, "presentation.json")
+	code, out = runTool("present", "-params", filepath.Join(dir, "params.json"), "-cred", cred, "-nonce", "01", "-out", pres)
+	assert.Equal(t, exitOK, code, out)
+
+	code, _ = runTool("verify", "-params", filepath.Join(dir, "params.json"), "-pk", filepath.Join(other, "public_key.json"), "-presentation", pres, "-nonce", "01")
+	assert.Equal(t, exitInvalid, code, "Expected a presentation checked against another key to be invalid")
+}
+
+// Test for issuing with the secret key held by a keystore process
+func TestRun_IssueWithSigner(t *testing.T) {
+	dir := t.TempDir()
+	attrs := filepath.Join(dir, "attributes.json")
+	err := os.WriteFile(attrs, []byte(`[{"name":"name","value":"Alice"}]`), 0o644)
+	assert.NoError(t, err, "Expected no error writing the attribute file")
+	code, out := runTool("setup", "-l", "1", "-dir", dir)
+	assert.Equal(t, exitOK, code, out)
+
+	// Serve the key as the signer command does; socket paths are limited in length
+	var sk models.SecretKey
+	assert.NoError(t, readFile(filepath.Join(dir, "secret_key.json"), typeSecretKey, &sk), "Expected no error reading the secret key")
+	socketDir, err := os.MkdirTemp("", "signer")
+	assert.NoError(t, err, "Expected no error creating the socket directory")
+	defer os.RemoveAll(socketDir)
+	socket := filepath.Join(socketDir, "signer.sock")
+	listener, err := remotesigner.Listen(socket)
+	assert.NoError(t, err, "Expected no error listening on the socket")
+	defer listener.Close()
+	go remotesigner.NewServer(issue.NewKeySigner(sk)).Serve(listener)
+
+	cred := filepath.Join(dir, "credential.json")
+	code, out = runTool("issue", "-params", filepath.Join(dir, "params.json"), "-signer", socket, "-pk", filepath.Join(dir, "public_key.json"), "-attrs", attrs, "-out", cred)
+	assert.Equal(t, exitOK, code, out)
+	pres := filepath.Join(dir, "presentation.json")
+	code, out = runTool("present", "-params", filepath.Join(dir, "params.json"), "-cred", cred, "-nonce", "01", "-out", pres)
+	assert.Equal(t, exitOK, code, out)
+	code, out = runTool("verify", "-params", filepath.Join(dir, "params.json"), "-pk", filepath.Join(dir, "public_key.json"), "-presentation", pres, "-nonce", "01")
+	assert.Equal(t, exitOK, code, out)
+
+	code, _ = runTool("issue", "-params", filepath.Join(dir, "params.json"), "-signer", socket, "-attrs", attrs, "-out", cred)
+	assert.Equal(t, exitUsage, code, "Expected a usage error for -signer without -pk")
+	code, _ = runTool("issue", "-params", filepath.Join(dir, "params.json"), "-sk", "sk.json", "-signer", socket, "-attrs", attrs, "-out", cred)
+	assert.Equal(t, exitUsage, code, "Expected a usage error for both -sk and -signer")
+}
+
+// Test for usage and file errors
+func TestRun_Errors(t *testing.T) {
+	code, _ := runTool()
+	assert.Equal(t, exitUsage, code, "Expected a usage error without a command")
+
+	code, _ = runTool("unknown")
+	assert.Equal(t, exitUsage, code, "Expected a usage error for an unknown command")
+
+	code, _ = runTool("issue", "-params", "params.json")
+	assert.Equal(t, exitUsage, code, "Expected a usage error for missing flags")
+
+	code, _ = runTool("inspect", filepath.Join(t.TempDir(), "missing.json"))
+	assert.Equal(t, exitFailure, code, "Expected a failure for a missing file")
+}
+
+// Test for hand-edited credential and presentation files failing instead of panicking
+func TestRun_MalformedFiles(t *testing.T) {
+	dir := t.TempDir()
+	attrs := filepath.Join(dir, "attributes.json")
+	err := os.WriteFile(attrs, []byte(`[{"name":"name","value":"Alice"},{"name":"age","value":"30"}]`), 0o644)
+	assert.NoError(t, err, "Expected no error writing the attribute file")
+	params := filepath.Join(dir, "params.json")
+	code, out := runTool("setup", "-l", "2", "-dir", dir)
+	assert.Equal(t, exitOK, code, out)
+	cred := filepath.Join(dir, "credential.json")
+	code, out = runTool("issue", "-params", params, "-sk", filepath.Join(dir, "secret_key.json"), "-attrs", attrs, "-out", cred)
+	assert.Equal(t, exitOK, code, out)
+	pres := filepath.Join(dir, "presentation.json")
+	code, out = runTool("present", "-params", params, "-cred", cred, "-reveal", "name,age", "-nonce", "0a", "-out", pres)
+	assert.Equal(t, exitOK, code, out)
+
+	// Drop the value of the last attribute from the credential
+	var credential models.Credential
+	assert.NoError(t, readFile(cred, typeCredential, &credential), "Expected no error reading the credential")
+	credential.Attributes = credential.Attributes[:1]
+	assert.NoError(t, writeFile(cred, typeCredential, credential, 0o644), "Expected no error writing the credential")
+	code, out = runTool("inspect", cred)
+	assert.Equal(t, exitFailure, code, "Expected a failure inspecting a credential with missing values: "+out)
+	code, out = runTool("present", "-params", params, "-cred", cred, "-reveal", "age", "-nonce", "0a", "-out", filepath.Join(dir, "other.json"))
+	assert.Equal(t, exitFailure, code, "Expected a failure presenting a credential with missing values: "+out)
+
+	// Drop the value of the last revealed attribute from the presentation
+	var in presentationFile
+	assert.NoError(t, readFile(pres, typePresentation, &in), "Expected no error reading the presentation")
+	in.RevealedAttributes = in.RevealedAttributes[:1]
+	assert.NoError(t, writeFile(pres, typePresentation, in, 0o644), "Expected no error writing the presentation")
+	code, out = runTool("inspect", pres)
+	assert.Equal(t, exitFailure, code, "Expected a failure inspecting a presentation with missing values: "+out)
+	code, out = runTool("verify", "-params", params, "-pk", filepath.Join(dir, "public_key.json"), "-presentation", pres, "-nonce", "0a")
+	assert.Equal(t, exitFailure, code, "Expected a failure verifying a presentation with missing values: "+out)
+}
@@ -0,0 +1,359 @@
+// Package cose wraps credential encodings in a COSE-style envelope (RFC 9052) for constrained devices.
+//
+// An envelope is the CBOR array [protected, unprotected, payload], where protected is a byte string holding the
+// deterministic CBOR encoding of the header map {1: alg, 3: content type, 4: kid}, unprotected is an empty map and
+// payload is the CBOR encoding of a signature, presentation, public key or public parameters. The envelope carries
+// no COSE signature of its own: signatures and presentations are authenticated by the BBS++ proof they contain.
+package cose
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/aniagut/msc-bbs-anonymous-credentials/cbor"
+	"github.com/aniagut/msc-bbs-anonymous-credentials/models"
+)
+
+// Header parameter labels, as registered for COSE.
+const (
+	HeaderAlgorithm   int64 = 1
+	HeaderContentType int64 = 3
+	HeaderKeyID       int64 = 4
+)
+
+// Algorithm is the algorithm identifier of BBS++ over BLS12-381, taken from the COSE private-use range.
+const Algorithm int64 = -65601
+
+// Content types of the payloads.
+const (
+	ContentTypeSignature    = "bbs-signature"
+	ContentTypePresentation = "bbs-presentation"
+	ContentTypePublicKey    = "bbs-public-key"
+	ContentTypeParameters   = "bbs-parameters"
+)
+
+// ErrInvalidEnvelope is returned when an envelope is malformed or does not carry the expected content.
+var ErrInvalidEnvelope = errors.New("invalid COSE envelope")
+
+// Envelope is a decoded envelope.
+// It contains the following elements:
+// - Algorithm: The algorithm identifier.
+// - ContentType: The content type of the payload.
+// - KeyID: The identifier of the issuer key, empty when absent.
+// - Payload: The CBOR encoded payload.
+type Envelope struct {
+	Algorithm   int64
+	ContentType string
+	KeyID       []byte
+	Payload     []byte
+}
+
+// Seal encodes an envelope for the payload.
+//
+// Parameters:
+//   - contentType: The content type of the payload.
+//   - keyID: The identifier of the issuer key, or nil.
+//   - payload: The CBOR encoded payload.
+//
+// Returns:
+//   - []byte: The encoded envelope.
+//   - error: An error if the encoding fails.
+func Seal(contentType string, keyID []byte, payload []byte) ([]byte, error) {
+	// Step 1: Encode the protected header
+	header := map[int64]interface{}{
+		HeaderAlgorithm:   Algorithm,
+		HeaderContentType: contentType,
+	}
+	if len(keyID) > 0 {
+		header[HeaderKeyID] = keyID
+	}
+	protected, err := cbor.Marshal(header)
+	if err != nil {
+		return nil, err
+	}
+
+	// Step 2: Encode the envelope
+	return cbor.Marshal([]interface{}{protected, map[int64]interface{}{}, payload})
+}
+
+// Open decodes an envelope and checks its algorithm and content type.
+//
+// Parameters:
+//   - data: The encoded envelope.
+//   - contentType: The expected content type.
+//
+// Returns:
+//   - Envelope: The decoded envelope.
+//   - error: An error if the envelope is malformed or carries another algorithm or content type.
+func Open(data []byte, contentType string) (Envelope, error) {
+	// Step 1: Decode the envelope
+	value, err := cbor.Unmarshal(data)
+	if err != nil {
+		return Envelope{}, fmt.Errorf("%w: %v", ErrInvalidEnvelope, err)
+	}
+	items, ok := value.([]interface{})
+	if !ok || len(items) != 3 {
+		return Envelope{}, fmt.Errorf("%w: expected an array of three items", ErrInvalidEnvelope)
+	}
+	protected, ok := items[0].([]byte)
+	if !ok {
+		return Envelope{}, fmt.Errorf("%w: protected header is not a byte string", ErrInvalidEnvelope)
+	}
+	switch unprotected := items[1].(type) {
+	case map[string]interface{}:
+		if len(unprotected) != 0 {
+			return Envelope{}, fmt.Errorf("%w: unexpected unprotected header", ErrInvalidEnvelope)
+		}
+	case map[int64]interface{}:
+		if len(unprotected) != 0 {
+			return Envelope{}, fmt.Errorf("%w: unexpected unprotected header", ErrInvalidEnvelope)
+		}
+	default:
+		return Envelope{}, fmt.Errorf("%w: unprotected header is not a map", ErrInvalidEnvelope)
+	}
+	payload, ok := items[2].([]byte)
+	if !ok {
+		return Envelope{}, fmt.Errorf("%w: payload is not a byte string", ErrInvalidEnvelope)
+	}
+
+	// Step 2: Decode the protected header
+	value, err = cbor.Unmarshal(protected)
+	if err != nil {
+		return Envelope{}, fmt.Errorf("%w: %v", ErrInvalidEnvelope, err)
+	}
+	header, ok := value.(map[int64]interface{})
+	if !ok {
+		return Envelope{}, fmt.Errorf("%w: protected header is not a map with integer labels", ErrInvalidEnvelope)
+	}
+	envelope := Envelope{Payload: payload}
+	if envelope.Algorithm, ok = header[HeaderAlgorithm].(int64); !ok || envelope.Algorithm != Algorithm {
+		return Envelope{}, fmt.Errorf("%w: unsupported algorithm", ErrInvalidEnvelope)
+	}
+	if envelope.ContentType, ok = header[HeaderContentType].(string); !ok || envelope.ContentType != contentType {
+		return Envelope{}, fmt.Errorf("%w: expected content type %q", ErrInvalidEnvelope, contentType)
+	}
+	if kid, present := header[HeaderKeyID]; present {
+		if envelope.KeyID, ok = kid.([]byte); !ok {
+			return Envelope{}, fmt.Errorf("%w: key ID is not a byte string", ErrInvalidEnvelope)
+		}
+	}
+	return envelope, nil
+}
+
+// SealSignature wraps a signature issued under the given key.
+//
+// Parameters:
+//   - signature: The signature.
+//   - keyID: The hex identifier of the issuer key, as returned by models.PublicKey.KeyID.
+//
+// Returns:
+//   - []byte: The encoded envelope.
+//   - error: An error if the key ID or the signature cannot be encoded.
+func SealSignature(signature models.Signature, keyID string) ([]byte, error) {
+	kid, err := hex.DecodeString(keyID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: key ID is not hex", ErrInvalidEnvelope)
+	}
+	payload, err := signature.MarshalCBOR()
+	if err != nil {
+		return nil, err
+	}
+	return Seal(ContentTypeSignature, kid, payload)
+}
+
+// OpenSignature unwraps a signature.
+//
+// Parameters:
+//   - data: The encoded envelope.
+//
+// Returns:
+//   - models.Signature: The signature.
+//   - string: The hex identifier of the issuer key.
+//   - error: An error if the envelope or the signature is malformed.
+func OpenSignature(data []byte) (models.Signature, string, error) {
+	envelope, err := Open(data, ContentTypeSignature)
+	if err != nil {
+		return models.Signature{}, "", err
+	}
+	var signature models.Signature
+	if err := signature.UnmarshalCBOR(envelope.Payload); err != nil {
+		return models.Signature{}, "", err
+	}
+	return signature, hex.EncodeToString(envelope.KeyID), nil
+}
+
+// SealPresentation wraps a presentation response. The payload is the CBOR array [proof, revealed indices,
+// revealed attributes], where proof is a byte string holding the CBOR encoding of the signature proof.
+//
+// Parameters:
+//   - response: The presentation response.
+//
+// Returns:
+//   - []byte: The encoded envelope.
+//   - error: An error if the response cannot be encoded.
+func SealPresentation(response models.PresentationResponse) ([]byte, error) {
+	kid, err := hex.DecodeString(response.IssuerKeyID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: key ID is not hex", ErrInvalidEnvelope)
+	}
+	proof, err := response.Proof.MarshalCBOR()
+	if err != nil {
+		return nil, err
+	}
+	indices := response.RevealedIndices
+	if indices == nil {
+		indices = []int{}
+	}
+	attributes := response.RevealedAttributes
+	if attributes == nil {
+		attributes = []string{}
+	}
+	payload, err := cbor.Marshal([]interface{}{proof, indices, attributes})
+	if err != nil {
+		return nil, err
+	}
+	return Seal(ContentTypePresentation, kid, payload)
+}
+
+// OpenPresentation unwraps a presentation response. The proof is not verified.
+//
+// Parameters:
+//   - data: The encoded envelope.
+//
+// Returns:
+//   - models.PresentationResponse: The presentation response.
+//   - error: An error if the envelope or the presentation is malformed.
+func OpenPresentation(data []byte) (models.PresentationResponse, error) {
+	// Step 1: Open the envelope and decode the payload
+	envelope, err := Open(data, ContentTypePresentation)
+	if err != nil {
+		return models.PresentationResponse{}, err
+	}
+	value, err := cbor.Unmarshal(envelope.Payload)
+	if err != nil {
+		return models.PresentationResponse{}, fmt.Errorf("%w: %v", ErrInvalidEnvelope, err)
+	}
+	items, ok := value.([]interface{})
+	if !ok || len(items) != 3 {
+		return models.PresentationResponse{}, fmt.Errorf("%w: expected a presentation of three items", ErrInvalidEnvelope)
+	}
+
+	// Step 2: Decode the proof
+	proofBytes, ok := items[0].([]byte)
+	if !ok {
+		return models.PresentationResponse{}, fmt.Errorf("%w: proof is not a byte string", ErrInvalidEnvelope)
+	}
+	var proof models.SignatureProof
+	if err := proof.UnmarshalCBOR(proofBytes); err != nil {
+		return models.PresentationResponse{}, err
+	}
+
+	// Step 3: Decode the revealed indices and attributes
+	indexItems, ok := items[1].([]interface{})
+	if !ok {
+		return models.PresentationResponse{}, fmt.Errorf("%w: revealed indices are not an array", ErrInvalidEnvelope)
+	}
+	indices := make([]int, len(indexItems))
+	for i, item := range indexItems {
+		index, ok := item.(uint64)
+		if !ok || index > math.MaxInt32 {
+			return models.PresentationResponse{}, fmt.Errorf("%w: invalid revealed index", ErrInvalidEnvelope)
+		}
+		indices[i] = int(index)
+	}
+	attributeItems, ok := items[2].([]interface{})
+	if !ok {
+		return models.PresentationResponse{}, fmt.Errorf("%w: revealed attributes are not an array", ErrInvalidEnvelope)
+	}
+	attributes := make([]string, len(attributeItems))
+	for i, item := range attributeItems {
+		if attributes[i], ok = item.(string); !ok {
+			return models.PresentationResponse{}, fmt.Errorf("%w: invalid revealed attribute", ErrInvalidEnvelope)
+		}
+	}
+
+	return models.PresentationResponse{
+		IssuerKeyID:        hex.EncodeToString(envelope.KeyID),
+		Proof:              proof,
+		RevealedIndices:    indices,
+		RevealedAttributes: attributes,
+	}, nil
+}
+
+// SealPublicKey wraps a public key under its own key ID.
+//
+// Parameters:
+//   - pk: The public key.
+//
+// Returns:
+//   - []byte: The encoded envelope.
+//   - error: An error if the key cannot be encoded.
+func SealPublicKey(pk models.PublicKey) ([]byte, error) {
+	payload, err := pk.MarshalCBOR()
+	if err != nil {
+		return nil, err
+	}
+	kid, _ := hex.DecodeString(pk.KeyID())
+	return Seal(ContentTypePublicKey, kid, payload)
+}
+
+// OpenPublicKey unwraps a public key and checks that the key ID in the header matches the key.
+//
+// Parameters:
+//   - data: The encoded envelope.
+//
+// Returns:
+//   - models.PublicKey: The public key.
+//   - error: An error if the envelope or the key is malformed, or the key ID does not match.
+func OpenPublicKey(data []byte) (models.PublicKey, error) {
+	envelope, err := Open(data, ContentTypePublicKey)
+	if err != nil {
+		return models.PublicKey{}, err
+	}
+	var pk models.PublicKey
+	if err := pk.UnmarshalCBOR(envelope.Payload); err != nil {
+		return models.PublicKey{}, err
+	}
+	if hex.EncodeToString(envelope.KeyID) != pk.KeyID() {
+		return models.PublicKey{}, fmt.Errorf("%w: key ID does not match the public key", ErrInvalidEnvelope)
+	}
+	return pk, nil
+}
+
+// SealParameters wraps public parameters.
+//
+// Parameters:
+//   - pp: The public parameters.
+//
+// Returns:
+//   - []byte: The encoded envelope.
+//   - error: An error if the parameters cannot be encoded.
+func SealParameters(pp models.PublicParameters) ([]byte, error) {
+	payload, err := pp.MarshalCBOR()
+	if err != nil {
+		return nil, err
+	}
+	return Seal(ContentTypeParameters, nil, payload)
+}
+
+// OpenParameters unwraps public parameters.
+//
+// Parameters:
+//   - data: The encoded envelope.
+//
+// Returns:
+//   - models.PublicParameters: The public parameters.
+//   - error: An error if the envelope or the parameters are malformed.
+func OpenParameters(data []byte) (models.PublicParameters, error) {
+	envelope, err := Open(data, ContentTypeParameters)
+	if err != nil {
+		return models.PublicParameters{}, err
+	}
+	var pp models.PublicParameters
+	if err := pp.UnmarshalCBOR(envelope.Payload); err != nil {
+		return models.PublicParameters{}, err
+	}
+	return pp, nil
+}
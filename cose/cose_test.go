@@ -0,0 +1,94 @@
+package cose
+
+import (
+	"testing"
+
+	"github.com/aniagut/msc-bbs-anonymous-credentials/cbor"
+	"github.com/aniagut/msc-bbs-anonymous-credentials/issue"
+	"github.com/aniagut/msc-bbs-anonymous-credentials/models"
+	"github.com/aniagut/msc-bbs-anonymous-credentials/presentation"
+	"github.com/aniagut/msc-bbs-anonymous-credentials/setup"
+	"github.com/aniagut/msc-bbs-anonymous-credentials/verify"
+	"github.com/stretchr/testify/assert"
+)
+
+// Test for wrapping a signature and a presentation and verifying the unwrapped proof
+func TestSealOpen_SignatureAndPresentation(t *testing.T) {
+	setupResult, err := setup.Setup(3)
+	assert.NoError(t, err, "Expected no error during setup")
+	attributes := []string{"Alice", "30", "PL"}
+	keyID := setupResult.PublicKey.KeyID()
+
+	signature, err := issue.Issue(attributes, setupResult.PublicParameters, setupResult.SecretKey)
+	assert.NoError(t, err, "Expected no error issuing the signature")
+	data, err := SealSignature(signature, keyID)
+	assert.NoError(t, err, "Expected no error sealing the signature")
+	opened, openedKeyID, err := OpenSignature(data)
+	assert.NoError(t, err, "Expected no error opening the signature")
+	assert.Equal(t, keyID, openedKeyID, "Expected the key ID to round trip")
+	assert.True(t, opened.A.IsEqual(signature.A), "Expected the signature to round trip")
+
+	nonce := []byte("nonce")
+	revealed := []int{0, 2}
+	proof, err := presentation.Presentation(attributes, opened, revealed, setupResult.PublicParameters, nonce)
+	assert.NoError(t, err, "Expected no error creating the proof")
+	data, err = SealPresentation(models.PresentationResponse{
+		IssuerKeyID:        keyID,
+		Proof:              proof,
+		RevealedIndices:    revealed,
+		RevealedAttributes: []string{"Alice", "PL"},
+	})
+	assert.NoError(t, err, "Expected no error sealing the presentation")
+
+	response, err := OpenPresentation(data)
+	assert.NoError(t, err, "Expected no error opening the presentation")
+	assert.Equal(t, keyID, response.IssuerKeyID, "Expected the key ID to round trip")
+	assert.Equal(t, revealed, response.RevealedIndices, "Expected the revealed indices to round trip")
+	valid, err := verify.Verify(response.Proof, nonce, response.RevealedAttributes, response.RevealedIndices, setupResult.PublicParameters, setupResult.PublicKey)
+	assert.NoError(t, err, "Expected the unwrapped proof to verify")
+	assert.True(t, valid, "Expected the unwrapped proof to verify")
+}
+
+// Test for wrapping keys and parameters
+func TestSealOpen_KeyAndParameters(t *testing.T) {
+	setupResult, err := setup.Setup(2)
+	assert.NoError(t, err, "Expected no error during setup")
+
+	data, err := SealPublicKey(setupResult.PublicKey)
+	assert.NoError(t, err, "Expected no error sealing the public key")
+	pk, err := OpenPublicKey(data)
+	assert.NoError(t, err, "Expected no error opening the public key")
+	assert.True(t, pk.X2.IsEqual(setupResult.PublicKey.X2), "Expected the public key to round trip")
+
+	payload, _ := setupResult.PublicKey.MarshalCBOR()
+	data, _ = Seal(ContentTypePublicKey, []byte{1, 2, 3}, payload)
+	_, err = OpenPublicKey(data)
+	assert.ErrorIs(t, err, ErrInvalidEnvelope, "Expected an error for a key ID that does not match the key")
+
+	data, err = SealParameters(setupResult.PublicParameters)
+	assert.NoError(t, err, "Expected no error sealing the parameters")
+	pp, err := OpenParameters(data)
+	assert.NoError(t, err, "Expected no error opening the parameters")
+	assert.Len(t, pp.H1, len(setupResult.PublicParameters.H1), "Expected the parameters to round trip")
+}
+
+// Test for envelopes with the wrong structure, algorithm or content type
+func TestOpen_Invalid(t *testing.T) {
+	payload := []byte{0x80}
+
+	data, _ := Seal(ContentTypeSignature, nil, payload)
+	_, err := Open(data, ContentTypePresentation)
+	assert.ErrorIs(t, err, ErrInvalidEnvelope, "Expected an error for another content type")
+
+	protected, _ := cbor.Marshal(map[int64]interface{}{HeaderAlgorithm: -7, HeaderContentType: ContentTypeSignature})
+	data, _ = cbor.Marshal([]interface{}{protected, map[int64]interface{}{}, payload})
+	_, err = Open(data, ContentTypeSignature)
+	assert.ErrorIs(t, err, ErrInvalidEnvelope, "Expected an error for another algorithm")
+
+	data, _ = cbor.Marshal([]interface{}{protected, payload})
+	_, err = Open(data, ContentTypeSignature)
+	assert.ErrorIs(t, err, ErrInvalidEnvelope, "Expected an error for a missing item")
+
+	_, err = SealSignature(models.Signature{}, "not hex")
+	assert.ErrorIs(t, err, ErrInvalidEnvelope, "Expected an error for a key ID that is not hex")
+}
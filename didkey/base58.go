@@ -0,0 +1,80 @@
+package didkey
+
+import "fmt"
+
+// base58Alphabet is the Bitcoin base58 alphabet used by the base58btc multibase encoding.
+const base58Alphabet = "123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz"
+
+// encodeBase58 encodes bytes in base58btc. Leading zero bytes are encoded as leading '1' characters.
+func encodeBase58(input []byte) string {
+	zeros := 0
+	for zeros < len(input) && input[zeros] == 0 {
+		zeros++
+	}
+
+	// Repeatedly divide the big-endian number by 58, collecting the remainders as little-endian digits
+	digits := make([]byte, 0, len(input)*138/100+1)
+	for _, b := range input[zeros:] {
+		carry := int(b)
+		for i := range digits {
+			carry += int(digits[i]) << 8
+			digits[i] = byte(carry % 58)
+			carry /= 58
+		}
+		for carry > 0 {
+			digits = append(digits, byte(carry%58))
+			carry /= 58
+		}
+	}
+
+	out := make([]byte, zeros+len(digits))
+	for i := 0; i < zeros; i++ {
+		out[i] = base58Alphabet[0]
+	}
+	for i, d := range digits {
+		out[len(out)-1-i] = base58Alphabet[d]
+	}
+	return string(out)
+}
+
+// decodeBase58 decodes a base58btc string.
+func decodeBase58(input string) ([]byte, error) {
+	zeros := 0
+	for zeros < len(input) && input[zeros] == base58Alphabet[0] {
+		zeros++
+	}
+
+	// Multiply the accumulated little-endian bytes by 58 and add each digit
+	bytes := make([]byte, 0, len(input)*733/1000+1)
+	for i := zeros; i < len(input); i++ {
+		carry := indexBase58(input[i])
+		if carry < 0 {
+			return nil, fmt.Errorf("%w: invalid base58 character %q", ErrInvalidDID, input[i])
+		}
+		for j := range bytes {
+			carry += int(bytes[j]) * 58
+			bytes[j] = byte(carry)
+			carry >>= 8
+		}
+		for carry > 0 {
+			bytes = append(bytes, byte(carry))
+			carry >>= 8
+		}
+	}
+
+	out := make([]byte, zeros+len(bytes))
+	for i, b := range bytes {
+		out[len(out)-1-i] = b
+	}
+	return out, nil
+}
+
+// indexBase58 returns the value of a base58 character, or -1 if it is not in the alphabet.
+func indexBase58(c byte) int {
+	for i := 0; i < len(base58Alphabet); i++ {
+		if base58Alphabet[i] == c {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,222 @@
+// Package didkey encodes issuer public keys as Multikeys and did:key identifiers.
+//
+// A Multikey is the multibase base58btc ('z') encoding of the multicodec bls12_381-g2-pub prefix (0xeb, written as
+// the varint 0xeb 0x01) followed by the compressed X2. The did:key identifier is "did:key:" followed by the Multikey,
+// and its DID document has a single Multikey verification method used for assertions. Public parameters are
+// derived from the key with setup.DeriveParameters, so a verifier can resolve both from the identifier alone.
+package didkey
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/aniagut/msc-bbs-anonymous-credentials/models"
+	"github.com/aniagut/msc-bbs-anonymous-credentials/setup"
+)
+
+// Prefix is the scheme and method of did:key identifiers.
+const Prefix = "did:key:"
+
+// multibaseBase58 is the multibase prefix of base58btc.
+const multibaseBase58 = 'z'
+
+// multicodecPrefix is the varint encoding of the bls12_381-g2-pub multicodec (0xeb).
+var multicodecPrefix = []byte{0xeb, 0x01}
+
+// Contexts of the DID document.
+const (
+	ContextDID      = "https://www.w3.org/ns/did/v1"
+	ContextMultikey = "https://w3id.org/security/multikey/v1"
+)
+
+// ErrInvalidDID is returned when a Multikey, did:key identifier or DID document is malformed or holds an invalid key.
+var ErrInvalidDID = errors.New("invalid did:key")
+
+// VerificationMethod is a Multikey verification method.
+// It contains the following elements:
+// - ID: The identifier of the method, the DID followed by "#" and the Multikey.
+// - Type: Always "Multikey".
+// - Controller: The DID.
+// - PublicKeyMultibase: The Multikey.
+type VerificationMethod struct {
+	ID                 string `json:"id"`
+	Type               string `json:"type"`
+	Controller         string `json:"controller"`
+	PublicKeyMultibase string `json:"publicKeyMultibase"`
+}
+
+// Document is the DID document of a did:key identifier.
+// It contains the following elements:
+// - Context: The JSON-LD contexts.
+// - ID: The DID.
+// - VerificationMethod: The single verification method of the key.
+// - AssertionMethod: The identifiers of the methods used to issue credentials.
+type Document struct {
+	Context            []string             `json:"@context"`
+	ID                 string               `json:"id"`
+	VerificationMethod []VerificationMethod `json:"verificationMethod"`
+	AssertionMethod    []string             `json:"assertionMethod"`
+}
+
+// EncodeMultikey encodes a public key as a Multikey.
+//
+// Parameters:
+//   - publicKey: The public key.
+//
+// Returns:
+//   - string: The Multikey.
+//   - error: An error if the key cannot be encoded.
+func EncodeMultikey(publicKey models.PublicKey) (string, error) {
+	key, err := publicKey.MarshalBinary()
+	if err != nil {
+		return "", err
+	}
+	return string(multibaseBase58) + encodeBase58(append(append([]byte(nil), multicodecPrefix...), key...)), nil
+}
+
+// DecodeMultikey decodes a Multikey, checking the multibase and multicodec prefixes and that X2 is a non-identity
+// element of G2.
+//
+// Parameters:
+//   - multikey: The Multikey.
+//
+// Returns:
+//   - models.PublicKey: The public key.
+//   - error: An error if the Multikey is malformed or holds an invalid key.
+func DecodeMultikey(multikey string) (models.PublicKey, error) {
+	// Step 1: Decode the multibase string
+	if len(multikey) < 2 || multikey[0] != multibaseBase58 {
+		return models.PublicKey{}, fmt.Errorf("%w: expected a base58btc multibase string", ErrInvalidDID)
+	}
+	data, err := decodeBase58(multikey[1:])
+	if err != nil {
+		return models.PublicKey{}, err
+	}
+
+	// Step 2: Check the multicodec prefix
+	if len(data) < len(multicodecPrefix) || data[0] != multicodecPrefix[0] || data[1] != multicodecPrefix[1] {
+		return models.PublicKey{}, fmt.Errorf("%w: expected a bls12_381-g2-pub key", ErrInvalidDID)
+	}
+
+	// Step 3: Decode the key
+	var publicKey models.PublicKey
+	if err := publicKey.UnmarshalBinary(data[len(multicodecPrefix):]); err != nil {
+		return models.PublicKey{}, fmt.Errorf("%w: %v", ErrInvalidDID, err)
+	}
+	return publicKey, nil
+}
+
+// DID returns the did:key identifier of a public key.
+//
+// Parameters:
+//   - publicKey: The public key.
+//
+// Returns:
+//   - string: The DID.
+//   - error: An error if the key cannot be encoded.
+func DID(publicKey models.PublicKey) (string, error) {
+	multikey, err := EncodeMultikey(publicKey)
+	if err != nil {
+		return "", err
+	}
+	return Prefix + multikey, nil
+}
+
+// Parse decodes a did:key identifier, optionally followed by a "#" fragment naming its verification method.
+//
+// Parameters:
+//   - did: The DID or DID URL.
+//
+// Returns:
+//   - models.PublicKey: The public key.
+//   - error: An error if the identifier is malformed or holds an invalid key.
+func Parse(did string) (models.PublicKey, error) {
+	// Step 1: Split off the method-specific identifier and the fragment
+	if !strings.HasPrefix(did, Prefix) {
+		return models.PublicKey{}, fmt.Errorf("%w: expected the %q prefix", ErrInvalidDID, Prefix)
+	}
+	multikey, fragment, hasFragment := strings.Cut(strings.TrimPrefix(did, Prefix), "#")
+	if hasFragment && fragment != multikey {
+		return models.PublicKey{}, fmt.Errorf("%w: fragment does not name the key", ErrInvalidDID)
+	}
+
+	// Step 2: Decode the key
+	return DecodeMultikey(multikey)
+}
+
+// NewDocument builds the DID document of a public key.
+//
+// Parameters:
+//   - publicKey: The public key.
+//
+// Returns:
+//   - Document: The DID document.
+//   - error: An error if the key cannot be encoded.
+func NewDocument(publicKey models.PublicKey) (Document, error) {
+	multikey, err := EncodeMultikey(publicKey)
+	if err != nil {
+		return Document{}, err
+	}
+	did := Prefix + multikey
+	method := VerificationMethod{
+		ID:                 did + "#" + multikey,
+		Type:               "Multikey",
+		Controller:         did,
+		PublicKeyMultibase: multikey,
+	}
+	return Document{
+		Context:            []string{ContextDID, ContextMultikey},
+		ID:                 did,
+		VerificationMethod: []VerificationMethod{method},
+		AssertionMethod:    []string{method.ID},
+	}, nil
+}
+
+// PublicKey returns the key of a DID document, checking that the document is consistent with its identifier.
+//
+// Returns:
+//   - models.PublicKey: The public key.
+//   - error: An error if the document is malformed or does not match its identifier.
+func (d Document) PublicKey() (models.PublicKey, error) {
+	// Step 1: Decode the key from the identifier
+	publicKey, err := Parse(d.ID)
+	if err != nil {
+		return models.PublicKey{}, err
+	}
+
+	// Step 2: Check that the document is the one the identifier resolves to
+	expected, err := NewDocument(publicKey)
+	if err != nil {
+		return models.PublicKey{}, err
+	}
+	if len(d.VerificationMethod) != 1 || d.VerificationMethod[0] != expected.VerificationMethod[0] {
+		return models.PublicKey{}, fmt.Errorf("%w: verification method does not match the DID", ErrInvalidDID)
+	}
+	if len(d.AssertionMethod) != 1 || d.AssertionMethod[0] != expected.AssertionMethod[0] {
+		return models.PublicKey{}, fmt.Errorf("%w: assertion method does not match the DID", ErrInvalidDID)
+	}
+	return publicKey, nil
+}
+
+// Resolve decodes a did:key identifier and derives the public parameters of the key.
+//
+// Parameters:
+//   - did: The DID or DID URL.
+//   - l: The number of generators of the public parameters.
+//
+// Returns:
+//   - models.PublicKey: The public key.
+//   - models.PublicParameters: The public parameters derived with setup.DeriveParameters.
+//   - error: An error if the identifier is malformed or holds an invalid key.
+func Resolve(did string, l int) (models.PublicKey, models.PublicParameters, error) {
+	publicKey, err := Parse(did)
+	if err != nil {
+		return models.PublicKey{}, models.PublicParameters{}, err
+	}
+	publicParams, err := setup.DeriveParameters(publicKey, l)
+	if err != nil {
+		return models.PublicKey{}, models.PublicParameters{}, err
+	}
+	return publicKey, publicParams, nil
+}
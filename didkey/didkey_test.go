@@ -0,0 +1,109 @@
+package didkey
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/aniagut/msc-bbs-anonymous-credentials/issue"
+	"github.com/aniagut/msc-bbs-anonymous-credentials/models"
+	"github.com/aniagut/msc-bbs-anonymous-credentials/setup"
+	e "github.com/cloudflare/circl/ecc/bls12381"
+	"github.com/stretchr/testify/assert"
+)
+
+// Test for the base58btc encoding against known vectors
+func TestBase58(t *testing.T) {
+	vectors := map[string]string{
+		"":                         "",
+		"Hello World!":             "2NEpo7TZRRrLZSi2U",
+		"\x00\x00\x28\x7f\xb4\xcd": "11233QC4",
+		"\x00":                     "1",
+		"The quick brown fox jumps over the lazy dog.": "USm3fpXnKG5EUBx2ndxBDMPVciP5hGey2Jh4NDv6gmeo1LkMeiKrLJUUBk6Z",
+	}
+	for input, expected := range vectors {
+		assert.Equal(t, expected, encodeBase58([]byte(input)), "Expected the base58 encoding of %q", input)
+		decoded, err := decodeBase58(expected)
+		assert.NoError(t, err, "Expected no error decoding %q", expected)
+		assert.Equal(t, input, string(decoded), "Expected the base58 decoding of %q", expected)
+	}
+
+	_, err := decodeBase58("0OIl")
+	assert.ErrorIs(t, err, ErrInvalidDID, "Expected an error for characters outside the alphabet")
+}
+
+// Test for the did:key round trip and the derived parameters
+func TestDID_RoundTrip(t *testing.T) {
+	result, err := setup.SetupDerived(3)
+	assert.NoError(t, err, "Expected no error during setup")
+
+	did, err := DID(result.PublicKey)
+	assert.NoError(t, err, "Expected no error encoding the DID")
+	assert.True(t, strings.HasPrefix(did, "did:key:zUC7"), "Expected the bls12_381-g2-pub prefix")
+
+	publicKey, publicParams, err := Resolve(did, 3)
+	assert.NoError(t, err, "Expected no error resolving the DID")
+	assert.True(t, publicKey.X2.IsEqual(result.PublicKey.X2), "Expected the key to round trip")
+
+	attributes := []string{"a", "b", "c"}
+	signature, err := issue.Issue(attributes, result.PublicParameters, result.SecretKey)
+	assert.NoError(t, err, "Expected no error issuing the credential")
+	valid, err := issue.VerifyCredential(attributes, signature, publicParams, publicKey)
+	assert.NoError(t, err, "Expected no error verifying the credential")
+	assert.True(t, valid, "Expected the credential to verify with the resolved key and parameters")
+
+	fromFragment, err := Parse(did + "#" + strings.TrimPrefix(did, Prefix))
+	assert.NoError(t, err, "Expected no error parsing the verification method ID")
+	assert.True(t, fromFragment.X2.IsEqual(result.PublicKey.X2), "Expected the key from the DID URL")
+}
+
+// Test for the DID document
+func TestDocument(t *testing.T) {
+	result, err := setup.Setup(1)
+	assert.NoError(t, err, "Expected no error during setup")
+
+	document, err := NewDocument(result.PublicKey)
+	assert.NoError(t, err, "Expected no error building the document")
+	encoded, err := json.Marshal(document)
+	assert.NoError(t, err, "Expected no error encoding the document")
+	assert.Contains(t, string(encoded), `"type":"Multikey"`, "Expected a Multikey verification method")
+
+	var decoded Document
+	assert.NoError(t, json.Unmarshal(encoded, &decoded), "Expected no error decoding the document")
+	publicKey, err := decoded.PublicKey()
+	assert.NoError(t, err, "Expected no error reading the key of the document")
+	assert.True(t, publicKey.X2.IsEqual(result.PublicKey.X2), "Expected the key to round trip")
+
+	other, _ := setup.Setup(1)
+	otherMultikey, _ := EncodeMultikey(other.PublicKey)
+	decoded.VerificationMethod[0].PublicKeyMultibase = otherMultikey
+	_, err = decoded.PublicKey()
+	assert.ErrorIs(t, err, ErrInvalidDID, "Expected an error for a method that does not match the DID")
+}
+
+// Test for malformed identifiers and invalid keys
+func TestParse_Invalid(t *testing.T) {
+	result, _ := setup.Setup(1)
+	did, _ := DID(result.PublicKey)
+
+	_, err := Parse("did:web:example.com")
+	assert.ErrorIs(t, err, ErrInvalidDID, "Expected an error for another DID method")
+
+	_, err = Parse(strings.Replace(did, "did:key:z", "did:key:u", 1))
+	assert.ErrorIs(t, err, ErrInvalidDID, "Expected an error for another multibase")
+
+	_, err = Parse(did + "#other")
+	assert.ErrorIs(t, err, ErrInvalidDID, "Expected an error for a fragment naming another key")
+
+	ed25519 := "did:key:z6MkhaXgBZDvotDkL5257faiztiGiC2QtKLGpbnnEGta2doK"
+	_, err = Parse(ed25519)
+	assert.ErrorIs(t, err, ErrInvalidDID, "Expected an error for an Ed25519 key")
+
+	_, err = DecodeMultikey("z" + encodeBase58(append([]byte{0xeb, 0x01}, make([]byte, models.G2Size)...)))
+	assert.ErrorIs(t, err, ErrInvalidDID, "Expected an error for an invalid point")
+
+	identity := new(e.G2)
+	identity.SetIdentity()
+	_, err = DecodeMultikey("z" + encodeBase58(append([]byte{0xeb, 0x01}, identity.BytesCompressed()...)))
+	assert.ErrorIs(t, err, ErrInvalidDID, "Expected an error for the identity")
+}
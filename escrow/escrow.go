@@ -0,0 +1,393 @@
+// Package escrow lets a holder encrypt a hidden attribute of a presentation (e.g. a user ID) for an auditor, and
+// prove in the transcript of the presentation that the ciphertext encrypts the attribute signed in the credential.
+// The auditor can later decrypt the ciphertext to de-anonymize the presentation.
+//
+// The attribute a is encrypted with ElGamal in G1 as (C1, C2) = (g^k, Y^k * h^a), where Y = g^y is the public key of
+// the auditor and h is a generator with an unknown discrete logarithm. Since the presentation proves knowledge of r
+// and r * a, the holder proves the linear relations C1^r = g^s and C2^r = Y^s * h^(r * a) for s = k * r.
+package escrow
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"github.com/aniagut/msc-bbs-anonymous-credentials/presentation"
+	"github.com/aniagut/msc-bbs-anonymous-credentials/utils"
+	"github.com/aniagut/msc-bbs-anonymous-credentials/verify"
+	e "github.com/cloudflare/circl/ecc/bls12381"
+)
+
+// messageBaseDST is the domain separation tag of the hash to G1 deriving the message base h.
+const messageBaseDST = "BBS_ESCROW_BLS12381G1_XMD:SHA-256_SSWU_RO_MESSAGE_BASE_"
+
+var (
+	// ErrInvalidEscrow is returned when an escrow, a ciphertext or an auditor key is malformed or used out of order.
+	ErrInvalidEscrow = errors.New("invalid escrow")
+	// ErrUnknownPlaintext is returned when a decrypted attribute matches none of the candidate values.
+	ErrUnknownPlaintext = errors.New("decrypted attribute matches no candidate")
+)
+
+// SecretKey is the secret key of an auditor.
+// It contains the following elements:
+// - Y: The secret scalar y.
+type SecretKey struct {
+	Y *e.Scalar
+}
+
+// PublicKey is the public key of an auditor.
+// It contains the following elements:
+// - Y: The element g^y of G1.
+type PublicKey struct {
+	Y *e.G1
+}
+
+// Ciphertext is an ElGamal encryption of h^a.
+// It contains the following elements:
+// - C1: The element g^k.
+// - C2: The element Y^k * h^a.
+type Ciphertext struct {
+	C1 *e.G1
+	C2 *e.G1
+}
+
+// Escrow is the encrypted attribute of a presentation with the response of its proof.
+// It contains the following elements:
+// - Index: The index of the encrypted attribute, which must be hidden in the presentation.
+// - Ciphertext: The encryption of the attribute under the public key of the auditor.
+// - Zs: The response for s = k * r.
+type Escrow struct {
+	Index      int
+	Ciphertext Ciphertext
+	Zs         *e.Scalar
+}
+
+// KeyGen generates the key pair of an auditor.
+//
+// Returns:
+//   - SecretKey: The secret key of the auditor.
+//   - PublicKey: The public key of the auditor.
+//   - error: An error if the random number generator fails.
+func KeyGen() (SecretKey, PublicKey, error) {
+	y, err := utils.RandomScalar()
+	if err != nil {
+		return SecretKey{}, PublicKey{}, err
+	}
+	Y := new(e.G1)
+	Y.ScalarMult(&y, e.G1Generator())
+	return SecretKey{Y: &y}, PublicKey{Y: Y}, nil
+}
+
+// MessageBase returns the generator h that attributes are encoded with before their encryption.
+func MessageBase() *e.G1 {
+	h := new(e.G1)
+	h.Hash([]byte("message base"), []byte(messageBaseDST))
+	return h
+}
+
+// Encode returns the element h^a encrypted for the attribute value a.
+func Encode(value string) *e.G1 {
+	a := new(e.Scalar)
+	a.SetBytes(utils.SerializeString(value))
+	m := new(e.G1)
+	m.ScalarMult(a, MessageBase())
+	return m
+}
+
+// Encryption is the holder side of an escrow, passed to presentation.PresentationWithExtensions.
+type Encryption struct {
+	publicKey PublicKey
+	index     int
+	k, s, vS  e.Scalar
+	escrow    Escrow
+	state     int
+}
+
+// States of an Encryption.
+const (
+	stateNew = iota
+	stateCommitted
+	stateDone
+)
+
+// NewEncryption creates the holder side of an escrow of the attribute at index for the auditor.
+func NewEncryption(publicKey PublicKey, index int) *Encryption {
+	return &Encryption{publicKey: publicKey, index: index}
+}
+
+// Commit implements presentation.Extension.
+func (x *Encryption) Commit(witness presentation.Witness) ([]byte, error) {
+	// Step 1: Find the response of the attribute and check the auditor key
+	if x.state != stateNew {
+		return nil, fmt.Errorf("%w: encryption already used", ErrInvalidEscrow)
+	}
+	position, err := witness.Position(x.index)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidEscrow, err)
+	}
+	if err := checkPublicKey(x.publicKey); err != nil {
+		return nil, err
+	}
+	g := e.G1Generator()
+	h := MessageBase()
+
+	// Step 2: Encrypt the attribute as (C1, C2) ← (g^k, Y^k * h^a)
+	if x.k, err = utils.RandomScalar(); err != nil {
+		return nil, err
+	}
+	C1 := new(e.G1)
+	C1.ScalarMult(&x.k, g)
+	C2 := utils.LinearCombination([]*e.G1{x.publicKey.Y, h}, []*e.Scalar{&x.k, &witness.Attributes[position]})
+
+	// Step 3: Compute s ← k * r and the commitments T1 ← C1^vR * g^(-vS) and T2 ← C2^vR * Y^(-vS) * h^(-v[j])
+	x.s.Mul(&x.k, witness.R)
+	if x.vS, err = utils.RandomScalar(); err != nil {
+		return nil, err
+	}
+	negVS, negV := utils.Negate(&x.vS), utils.Negate(&witness.V[position])
+	T1 := utils.LinearCombination([]*e.G1{C1, g}, []*e.Scalar{witness.VR, negVS})
+	T2 := utils.LinearCombination([]*e.G1{C2, x.publicKey.Y, h}, []*e.Scalar{witness.VR, negVS, negV})
+	utils.ZeroizeScalars(negVS, negV)
+
+	x.escrow = Escrow{Index: x.index, Ciphertext: Ciphertext{C1: C1, C2: C2}}
+	x.state = stateCommitted
+	return transcript(x.publicKey, x.escrow, T1, T2), nil
+}
+
+// Respond implements presentation.Extension.
+func (x *Encryption) Respond(ch *e.Scalar) error {
+	if x.state != stateCommitted {
+		return fmt.Errorf("%w: encryption not committed", ErrInvalidEscrow)
+	}
+
+	// Compute zS ← vS + ch * s and zeroize the secrets of the encryption
+	zS := new(e.Scalar)
+	zS.Mul(ch, &x.s)
+	zS.Add(zS, &x.vS)
+	x.escrow.Zs = zS
+	utils.ZeroizeScalars(&x.k, &x.s, &x.vS)
+	x.state = stateDone
+	return nil
+}
+
+// Escrow returns the escrow to send to the verifier with the presentation.
+func (x *Encryption) Escrow() (Escrow, error) {
+	if x.state != stateDone {
+		return Escrow{}, fmt.Errorf("%w: presentation not completed", ErrInvalidEscrow)
+	}
+	return x.escrow, nil
+}
+
+// check is the verifier side of an escrow.
+type check struct {
+	publicKey PublicKey
+	index     int
+	escrow    Escrow
+}
+
+// Check returns the verifier side of an escrow, passed to verify.VerifyWithExtensions to check that the escrow
+// encrypts the hidden attribute at the index required by the verifier under the public key of the auditor.
+func Check(publicKey PublicKey, index int, escrow Escrow) verify.Extension {
+	return check{publicKey: publicKey, index: index, escrow: escrow}
+}
+
+// Commit implements verify.Extension.
+func (c check) Commit(responses verify.Responses) ([]byte, error) {
+	// Step 1: Validate the escrow of the required attribute and find the response of the attribute
+	if err := checkPublicKey(c.publicKey); err != nil {
+		return nil, err
+	}
+	if c.escrow.Index != c.index {
+		return nil, fmt.Errorf("%w: escrow of attribute %d, attribute %d required", ErrInvalidEscrow, c.escrow.Index, c.index)
+	}
+	if err := checkCiphertext(c.escrow.Ciphertext); err != nil {
+		return nil, err
+	}
+	if c.escrow.Zs == nil {
+		return nil, fmt.Errorf("%w: missing response", ErrInvalidEscrow)
+	}
+	position, err := responses.Position(c.escrow.Index)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidEscrow, err)
+	}
+
+	// Step 2: Recompute T1 ← C1^Zr * g^(-Zs) and T2 ← C2^Zr * Y^(-Zs) * h^(-Zi[j])
+	negZS, negZ := utils.Negate(c.escrow.Zs), utils.Negate(&responses.Z[position])
+	T1 := utils.LinearCombination([]*e.G1{c.escrow.Ciphertext.C1, e.G1Generator()}, []*e.Scalar{responses.Zr, negZS})
+	T2 := utils.LinearCombination([]*e.G1{c.escrow.Ciphertext.C2, c.publicKey.Y, MessageBase()}, []*e.Scalar{responses.Zr, negZS, negZ})
+	return transcript(c.publicKey, c.escrow, T1, T2), nil
+}
+
+// Decrypt decrypts a ciphertext with the secret key of the auditor.
+//
+// Parameters:
+//   - secretKey: The secret key of the auditor.
+//   - ciphertext: The ciphertext of an escrow.
+//
+// Returns:
+//   - *e.G1: The encoded attribute h^a, which can be matched with Identify.
+//   - error: An error if the key or the ciphertext is malformed.
+func Decrypt(secretKey SecretKey, ciphertext Ciphertext) (*e.G1, error) {
+	if secretKey.Y == nil {
+		return nil, fmt.Errorf("%w: missing secret key", ErrInvalidEscrow)
+	}
+	if err := checkCiphertext(ciphertext); err != nil {
+		return nil, err
+	}
+
+	// Compute h^a ← C2 * C1^(-y)
+	negY := utils.Negate(secretKey.Y)
+	defer utils.ZeroizeScalars(negY)
+	m := new(e.G1)
+	m.ScalarMult(negY, ciphertext.C1)
+	m.Add(m, ciphertext.C2)
+	return m, nil
+}
+
+// DecryptionProof proves that a plaintext is the decryption of a ciphertext under the auditor key, without revealing
+// the secret key (a Chaum–Pedersen proof of equality of the discrete logarithms of Y to the base g and of C2 / M to
+// the base C1).
+// It contains the following elements:
+// - Ch: The challenge of the proof.
+// - Z: The response for the secret key y.
+type DecryptionProof struct {
+	Ch *e.Scalar
+	Z  *e.Scalar
+}
+
+// ProveDecryption decrypts a ciphertext and proves the correctness of the decryption.
+//
+// Parameters:
+//   - secretKey: The secret key of the auditor.
+//   - publicKey: The public key of the auditor.
+//   - ciphertext: The ciphertext of an escrow.
+//
+// Returns:
+//   - *e.G1: The encoded attribute h^a.
+//   - DecryptionProof: The proof that h^a is the decryption of the ciphertext.
+//   - error: An error if the keys do not match or the ciphertext is malformed.
+func ProveDecryption(secretKey SecretKey, publicKey PublicKey, ciphertext Ciphertext) (*e.G1, DecryptionProof, error) {
+	// Step 1: Decrypt the ciphertext and check the key pair
+	if err := checkPublicKey(publicKey); err != nil {
+		return nil, DecryptionProof{}, err
+	}
+	plaintext, err := Decrypt(secretKey, ciphertext)
+	if err != nil {
+		return nil, DecryptionProof{}, err
+	}
+	Y := new(e.G1)
+	Y.ScalarMult(secretKey.Y, e.G1Generator())
+	if !Y.IsEqual(publicKey.Y) {
+		return nil, DecryptionProof{}, fmt.Errorf("%w: secret key does not match the public key", ErrInvalidEscrow)
+	}
+
+	// Step 2: Compute the commitments T1 ← g^v and T2 ← C1^v for random v
+	v, err := utils.RandomScalar()
+	if err != nil {
+		return nil, DecryptionProof{}, err
+	}
+	defer utils.ZeroizeScalars(&v)
+	T1 := new(e.G1)
+	T1.ScalarMult(&v, e.G1Generator())
+	T2 := new(e.G1)
+	T2.ScalarMult(&v, ciphertext.C1)
+
+	// Step 3: Compute the challenge ch ← H(Y, C1, C2, M, T1, T2) and the response z ← v + ch * y
+	ch, err := decryptionChallenge(publicKey, ciphertext, plaintext, T1, T2)
+	if err != nil {
+		return nil, DecryptionProof{}, err
+	}
+	z := new(e.Scalar)
+	z.Mul(&ch, secretKey.Y)
+	z.Add(z, &v)
+	return plaintext, DecryptionProof{Ch: &ch, Z: z}, nil
+}
+
+// VerifyDecryption checks a proof that a plaintext is the decryption of a ciphertext under the auditor key.
+//
+// Parameters:
+//   - publicKey: The public key of the auditor.
+//   - ciphertext: The ciphertext of an escrow.
+//   - plaintext: The claimed encoded attribute h^a.
+//   - proof: The proof of correct decryption.
+//
+// Returns:
+//   - error: An error if the inputs are malformed or the proof does not verify.
+func VerifyDecryption(publicKey PublicKey, ciphertext Ciphertext, plaintext *e.G1, proof DecryptionProof) error {
+	// Step 1: Validate the inputs
+	if err := checkPublicKey(publicKey); err != nil {
+		return err
+	}
+	if err := checkCiphertext(ciphertext); err != nil {
+		return err
+	}
+	if plaintext == nil || !plaintext.IsOnG1() || proof.Ch == nil || proof.Z == nil {
+		return fmt.Errorf("%w: missing plaintext or proof component", ErrInvalidEscrow)
+	}
+
+	// Step 2: Recompute T1 ← g^z * Y^(-ch) and T2 ← C1^z * (C2 / M)^(-ch)
+	negCh := utils.Negate(proof.Ch)
+	negM := new(e.G1)
+	*negM = *plaintext
+	negM.Neg()
+	shared := new(e.G1)
+	shared.Add(ciphertext.C2, negM)
+	T1 := utils.LinearCombination([]*e.G1{e.G1Generator(), publicKey.Y}, []*e.Scalar{proof.Z, negCh})
+	T2 := utils.LinearCombination([]*e.G1{ciphertext.C1, shared}, []*e.Scalar{proof.Z, negCh})
+
+	// Step 3: Check that the recomputed challenge matches
+	ch, err := decryptionChallenge(publicKey, ciphertext, plaintext, T1, T2)
+	if err != nil {
+		return err
+	}
+	if ch.IsEqual(proof.Ch) != 1 {
+		return fmt.Errorf("%w: proof of decryption does not verify", ErrInvalidEscrow)
+	}
+	return nil
+}
+
+// Identify returns the candidate attribute value whose encoding is the decrypted plaintext. Attribute values are
+// encoded as group elements, so the auditor matches the plaintext against the known values (e.g. registered user IDs).
+func Identify(plaintext *e.G1, candidates []string) (string, error) {
+	for _, candidate := range candidates {
+		if Encode(candidate).IsEqual(plaintext) {
+			return candidate, nil
+		}
+	}
+	return "", ErrUnknownPlaintext
+}
+
+// checkPublicKey checks that the public key of the auditor is a non-identity element of G1.
+func checkPublicKey(publicKey PublicKey) error {
+	if publicKey.Y == nil || publicKey.Y.IsIdentity() || !publicKey.Y.IsOnG1() {
+		return fmt.Errorf("%w: auditor key is not a valid element of G1", ErrInvalidEscrow)
+	}
+	return nil
+}
+
+// checkCiphertext checks that the components of the ciphertext are elements of G1.
+func checkCiphertext(ciphertext Ciphertext) error {
+	if ciphertext.C1 == nil || ciphertext.C2 == nil || !ciphertext.C1.IsOnG1() || !ciphertext.C2.IsOnG1() {
+		return fmt.Errorf("%w: ciphertext is not a pair of elements of G1", ErrInvalidEscrow)
+	}
+	return nil
+}
+
+// decryptionChallenge computes the challenge of a proof of correct decryption.
+func decryptionChallenge(publicKey PublicKey, ciphertext Ciphertext, plaintext, T1, T2 *e.G1) (e.Scalar, error) {
+	inputs := [][]byte{[]byte("escrow-decryption")}
+	for _, element := range []*e.G1{publicKey.Y, ciphertext.C1, ciphertext.C2, plaintext, T1, T2} {
+		inputs = append(inputs, utils.SerializeG1(element))
+	}
+	return utils.HashToScalar(inputs...)
+}
+
+// transcript serializes the statement and the commitments of an escrow proof.
+func transcript(publicKey PublicKey, escrow Escrow, T1, T2 *e.G1) []byte {
+	out := []byte("escrow")
+	out = binary.BigEndian.AppendUint64(out, uint64(escrow.Index))
+	for _, element := range []*e.G1{publicKey.Y, escrow.Ciphertext.C1, escrow.Ciphertext.C2, T1, T2} {
+		out = append(out, utils.SerializeG1(element)...)
+	}
+	return out
+}
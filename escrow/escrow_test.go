@@ -0,0 +1,108 @@
+package escrow
+
+import (
+	"testing"
+
+	"github.com/aniagut/msc-bbs-anonymous-credentials/internal/testutil"
+	"github.com/aniagut/msc-bbs-anonymous-credentials/issue"
+	"github.com/aniagut/msc-bbs-anonymous-credentials/models"
+	"github.com/aniagut/msc-bbs-anonymous-credentials/presentation"
+	"github.com/aniagut/msc-bbs-anonymous-credentials/verify"
+	"github.com/stretchr/testify/assert"
+)
+
+// MockPresentation presents a credential over (user ID, name, role) revealing the role, with the user ID escrowed
+func MockPresentation(t *testing.T, auditor PublicKey) (models.SignatureProof, Escrow, models.SetupResult) {
+	attributes, signature, result := testutil.MockCredential(t, "user-42", "Alice", "admin")
+
+	encryption := NewEncryption(auditor, 0)
+	proof, err := presentation.PresentationWithExtensions(attributes, signature, []int{2}, result.PublicParameters, []byte("nonce"), encryption)
+	assert.NoError(t, err, "Expected no error during the presentation")
+	escrow, err := encryption.Escrow()
+	assert.NoError(t, err, "Expected the escrow once the presentation is complete")
+	return proof, escrow, result
+}
+
+// Test for verifying an escrowed presentation and de-anonymizing it
+func TestEscrow_VerifyAndDecrypt(t *testing.T) {
+	auditorSK, auditorPK, err := KeyGen()
+	assert.NoError(t, err, "Expected no error generating the auditor key")
+	proof, escrow, result := MockPresentation(t, auditorPK)
+
+	valid, err := verify.VerifyWithExtensions(proof, []byte("nonce"), []string{"admin"}, []int{2}, result.PublicParameters, result.PublicKey, Check(auditorPK, 0, escrow))
+	assert.NoError(t, err, "Expected no error verifying the escrowed presentation")
+	assert.True(t, valid, "Expected the escrowed presentation to be valid")
+
+	plaintext, err := Decrypt(auditorSK, escrow.Ciphertext)
+	assert.NoError(t, err, "Expected no error decrypting the escrow")
+	id, err := Identify(plaintext, []string{"user-7", "user-42"})
+	assert.NoError(t, err, "Expected the user ID among the candidates")
+	assert.Equal(t, "user-42", id, "Expected the escrowed user ID")
+	_, err = Identify(plaintext, []string{"user-7"})
+	assert.ErrorIs(t, err, ErrUnknownPlaintext, "Expected no match without the user ID")
+}
+
+// Test for rejecting escrows that do not encrypt the attribute of the credential
+func TestEscrow_Rejections(t *testing.T) {
+	_, auditorPK, err := KeyGen()
+	assert.NoError(t, err, "Expected no error generating the auditor key")
+	_, otherPK, err := KeyGen()
+	assert.NoError(t, err, "Expected no error generating another auditor key")
+	proof, escrow, result := MockPresentation(t, auditorPK)
+	check := func(pk PublicKey, index int, esc Escrow) error {
+		_, err := verify.VerifyWithExtensions(proof, []byte("nonce"), []string{"admin"}, []int{2}, result.PublicParameters, result.PublicKey, Check(pk, index, esc))
+		return err
+	}
+
+	// Replace the ciphertext with an encryption of another user ID
+	forged := escrow
+	forged.Ciphertext = Ciphertext{C1: escrow.Ciphertext.C1, C2: Encode("user-7")}
+	assert.ErrorIs(t, check(auditorPK, 0, forged), models.ErrChallengeMismatch, "Expected a replaced ciphertext to be rejected")
+
+	// Claim another attribute or another auditor
+	moved := escrow
+	moved.Index = 1
+	assert.ErrorIs(t, check(auditorPK, 0, moved), ErrInvalidEscrow, "Expected an escrow of another attribute to be rejected")
+	assert.ErrorIs(t, check(auditorPK, 1, moved), models.ErrChallengeMismatch, "Expected a relabeled escrow to be rejected")
+	assert.ErrorIs(t, check(otherPK, 0, escrow), models.ErrChallengeMismatch, "Expected another auditor key to be rejected")
+	moved.Index = 2
+	assert.ErrorIs(t, check(auditorPK, 2, moved), ErrInvalidEscrow, "Expected a revealed attribute to be rejected")
+
+	// The escrow is bound to the presentation and cannot be dropped
+	_, err = verify.Verify(proof, []byte("nonce"), []string{"admin"}, []int{2}, result.PublicParameters, result.PublicKey)
+	assert.ErrorIs(t, err, models.ErrChallengeMismatch, "Expected the presentation to fail without its escrow")
+
+	// The holder cannot escrow a revealed attribute
+	signature, err := issue.Issue([]string{"user-42", "Alice", "admin"}, result.PublicParameters, result.SecretKey)
+	assert.NoError(t, err, "Expected no error during issuance")
+	_, err = presentation.PresentationWithExtensions([]string{"user-42", "Alice", "admin"}, signature, []int{0}, result.PublicParameters, []byte("nonce"), NewEncryption(auditorPK, 0))
+	assert.ErrorIs(t, err, ErrInvalidEscrow, "Expected an error escrowing a revealed attribute")
+
+	// The holder cannot escrow the name instead of the required user ID
+	encryption := NewEncryption(auditorPK, 1)
+	nameProof, err := presentation.PresentationWithExtensions([]string{"user-42", "Alice", "admin"}, signature, []int{2}, result.PublicParameters, []byte("nonce"), encryption)
+	assert.NoError(t, err, "Expected no error escrowing the name")
+	nameEscrow, err := encryption.Escrow()
+	assert.NoError(t, err, "Expected the escrow once the presentation is complete")
+	_, err = verify.VerifyWithExtensions(nameProof, []byte("nonce"), []string{"admin"}, []int{2}, result.PublicParameters, result.PublicKey, Check(auditorPK, 0, nameEscrow))
+	assert.ErrorIs(t, err, ErrInvalidEscrow, "Expected an escrow of the name to be rejected")
+}
+
+// Test for proving and verifying the correctness of a decryption
+func TestEscrow_DecryptionProof(t *testing.T) {
+	auditorSK, auditorPK, err := KeyGen()
+	assert.NoError(t, err, "Expected no error generating the auditor key")
+	_, escrow, _ := MockPresentation(t, auditorPK)
+
+	plaintext, proof, err := ProveDecryption(auditorSK, auditorPK, escrow.Ciphertext)
+	assert.NoError(t, err, "Expected no error proving the decryption")
+	assert.True(t, plaintext.IsEqual(Encode("user-42")), "Expected the decryption of the user ID")
+	assert.NoError(t, VerifyDecryption(auditorPK, escrow.Ciphertext, plaintext, proof), "Expected the proof of decryption to verify")
+
+	err = VerifyDecryption(auditorPK, escrow.Ciphertext, Encode("user-7"), proof)
+	assert.ErrorIs(t, err, ErrInvalidEscrow, "Expected a wrong plaintext to be rejected")
+	_, otherPK, err := KeyGen()
+	assert.NoError(t, err, "Expected no error generating another auditor key")
+	_, _, err = ProveDecryption(auditorSK, otherPK, escrow.Ciphertext)
+	assert.ErrorIs(t, err, ErrInvalidEscrow, "Expected mismatched auditor keys to be rejected")
+}
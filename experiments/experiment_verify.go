@@ -32,8 +32,8 @@ func MeasureVerifyTime() {
 			return
 		}
 
-		// Define how many attributes to reveal for each test - it needs to be at least 1 and at most l
-		revealedSizes := []int{l/20, l/10, l/5, l/2, l-1, l}
+		// Define how many attributes to reveal for each test - it needs to be at most l (0 proves mere possession)
+		revealedSizes := []int{0, l/20, l/10, l/5, l/2, l-1, l}
 		
 		// Generate a list of attributes
 		attributes := make([]string, l)
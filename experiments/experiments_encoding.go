@@ -0,0 +1,150 @@
+package experiments
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/aniagut/msc-bbs-anonymous-credentials/cose"
+	"github.com/aniagut/msc-bbs-anonymous-credentials/issue"
+	"github.com/aniagut/msc-bbs-anonymous-credentials/models"
+	"github.com/aniagut/msc-bbs-anonymous-credentials/presentation"
+	"github.com/aniagut/msc-bbs-anonymous-credentials/setup"
+)
+
+// MeasureEncodingSizes compares the sizes of the gob, binary, CBOR, COSE envelope and JSON encodings of the public
+// parameters, public key, signature and signature proof for different sizes of the attributes vector and numbers of
+// revealed attributes. Gob proofs use the uncompressed SerializableSignatureProof, as in MeasurePresentationTime.
+func MeasureEncodingSizes() {
+	// Define the sizes of the attributes vector to test
+	lSizes := []int{5, 20, 50, 100}
+
+	// Open the results file for writing sizes
+	file, err := os.Create("experiments/results/encoding_size_results.txt")
+	if err != nil {
+		fmt.Printf("Error creating results file: %v\n", err)
+		return
+	}
+	defer file.Close()
+
+	// Write the header to the file
+	_, err = file.WriteString("Object,L,RevealedAttributesLength,Gob,Binary,CBOR,COSE,JSON\n")
+	if err != nil {
+		fmt.Printf("Error writing to results file: %v\n", err)
+		return
+	}
+
+	write := func(object string, l, revealedSize int, sizes [5]int) bool {
+		fmt.Printf("Encoding sizes of %s for l=%d with revealed size %d: gob=%d binary=%d cbor=%d cose=%d json=%d bytes\n",
+			object, l, revealedSize, sizes[0], sizes[1], sizes[2], sizes[3], sizes[4])
+		_, err := file.WriteString(fmt.Sprintf("%s,%d,%d,%d,%d,%d,%d,%d\n", object, l, revealedSize, sizes[0], sizes[1], sizes[2], sizes[3], sizes[4]))
+		if err != nil {
+			fmt.Printf("Error writing to results file: %v\n", err)
+			return false
+		}
+		return true
+	}
+
+	for _, l := range lSizes {
+		// Generate a list of attributes
+		attributes := make([]string, l)
+		for i := 0; i < l; i++ {
+			attributes[i] = fmt.Sprintf("attribute%d", i+1)
+		}
+		// Generate public parameters, keys and a signature
+		setupResult, err := setup.Setup(l)
+		if err != nil {
+			fmt.Printf("Error during Setup for l=%d: %v\n", l, err)
+			return
+		}
+		signature, err := issue.Issue(attributes, setupResult.PublicParameters, setupResult.SecretKey)
+		if err != nil {
+			fmt.Printf("Error during Issue for l=%d: %v\n", l, err)
+			return
+		}
+		keyID := setupResult.PublicKey.KeyID()
+
+		// Measure the keys and the signature
+		sizes, err := encodingSizes(setupResult.PublicParameters, setupResult.PublicParameters.MarshalBinary,
+			setupResult.PublicParameters.MarshalCBOR, func() ([]byte, error) { return cose.SealParameters(setupResult.PublicParameters) })
+		if err != nil {
+			fmt.Printf("Error encoding the public parameters for l=%d: %v\n", l, err)
+			return
+		}
+		if !write("PublicParameters", l, 0, sizes) {
+			return
+		}
+		sizes, err = encodingSizes(setupResult.PublicKey, setupResult.PublicKey.MarshalBinary,
+			setupResult.PublicKey.MarshalCBOR, func() ([]byte, error) { return cose.SealPublicKey(setupResult.PublicKey) })
+		if err != nil {
+			fmt.Printf("Error encoding the public key for l=%d: %v\n", l, err)
+			return
+		}
+		if !write("PublicKey", l, 0, sizes) {
+			return
+		}
+		sizes, err = encodingSizes(signature, signature.MarshalBinary,
+			signature.MarshalCBOR, func() ([]byte, error) { return cose.SealSignature(signature, keyID) })
+		if err != nil {
+			fmt.Printf("Error encoding the signature for l=%d: %v\n", l, err)
+			return
+		}
+		if !write("Signature", l, 0, sizes) {
+			return
+		}
+
+		// Measure the proofs for different numbers of revealed attributes
+		for _, revealedSize := range []int{0, l / 5, l / 2, l} {
+			revealed := make([]int, revealedSize)
+			for i := 0; i < revealedSize; i++ {
+				revealed[i] = i
+			}
+			proof, err := presentation.Presentation(attributes, signature, revealed, setupResult.PublicParameters, []byte("random_nonce"))
+			if err != nil {
+				fmt.Printf("Error during Presentation for l=%d: %v\n", l, err)
+				return
+			}
+			response := models.PresentationResponse{
+				IssuerKeyID:        keyID,
+				Proof:              proof,
+				RevealedIndices:    revealed,
+				RevealedAttributes: attributes[:revealedSize],
+			}
+			sizes, err = encodingSizes(proof, proof.MarshalBinary,
+				proof.MarshalCBOR, func() ([]byte, error) { return cose.SealPresentation(response) })
+			if err != nil {
+				fmt.Printf("Error encoding the proof for l=%d: %v\n", l, err)
+				return
+			}
+			gobProof, err := SerializeToBytes(&proof)
+			if err != nil {
+				fmt.Printf("Error serializing proof: %v\n", err)
+				return
+			}
+			sizes[0] = len(gobProof)
+			if !write("SignatureProof", l, revealedSize, sizes) {
+				return
+			}
+		}
+	}
+}
+
+// encodingSizes returns the sizes of the gob, binary, CBOR, COSE envelope and JSON encodings of a value.
+func encodingSizes(value interface{}, binary, cbor, envelope func() ([]byte, error)) ([5]int, error) {
+	var sizes [5]int
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(value); err != nil {
+		return sizes, err
+	}
+	sizes[0] = buf.Len()
+	for i, encode := range []func() ([]byte, error){binary, cbor, envelope, func() ([]byte, error) { return json.Marshal(value) }} {
+		data, err := encode()
+		if err != nil {
+			return sizes, err
+		}
+		sizes[i+1] = len(data)
+	}
+	return sizes, nil
+}
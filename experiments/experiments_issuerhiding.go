@@ -0,0 +1,112 @@
+package experiments
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/aniagut/msc-bbs-anonymous-credentials/issue"
+	"github.com/aniagut/msc-bbs-anonymous-credentials/issuerhiding"
+	"github.com/aniagut/msc-bbs-anonymous-credentials/models"
+	"github.com/aniagut/msc-bbs-anonymous-credentials/setup"
+)
+
+// MeasureIssuerHidingTime measures the time taken to run issuerhiding.Present and issuerhiding.Verify for different
+// numbers of accepted issuers, with a credential of the last issuer of the list.
+func MeasureIssuerHidingTime() {
+	// Define the numbers of accepted issuers and the size of the attributes vector to test
+	nSizes := []int{1, 2, 5, 10, 20, 50, 100}
+	l := 20
+
+	// Open the results file for writing time
+	file, err := os.Create("experiments/results/issuer_hiding_time_results.txt")
+	if err != nil {
+		fmt.Printf("Error creating results file: %v\n", err)
+		return
+	}
+	defer file.Close()
+
+	// Write the header to the file
+	_, err = file.WriteString("AcceptedIssuers,AveragePresentationTime,AverageVerifyTime\n")
+	if err != nil {
+		fmt.Printf("Error writing to results file: %v\n", err)
+		return
+	}
+
+	// Generate a list of attributes, revealing the first two
+	attributes := make([]string, l)
+	for i := 0; i < l; i++ {
+		attributes[i] = fmt.Sprintf("attribute%d", i+1)
+	}
+	revealed := attributes[:2]
+	revealedIndices := []int{0, 1}
+	nonce := []byte("random_nonce")
+
+	// Generate the shared public parameters and the largest list of issuers
+	setupResult, err := setup.Setup(l)
+	if err != nil {
+		fmt.Printf("Error during Setup for l=%d: %v\n", l, err)
+		return
+	}
+	publicParams := setupResult.PublicParameters
+	issuers := []models.SetupResult{setupResult}
+	for len(issuers) < nSizes[len(nSizes)-1] {
+		issuer, err := setup.KeyGen(publicParams)
+		if err != nil {
+			fmt.Printf("Error during KeyGen: %v\n", err)
+			return
+		}
+		issuers = append(issuers, issuer)
+	}
+
+	for _, n := range nSizes {
+		// Accept the first n issuers and issue the credential with the last of them
+		accepted := make([]models.PublicKey, n)
+		for i := 0; i < n; i++ {
+			accepted[i] = issuers[i].PublicKey
+		}
+		signature, err := issue.Issue(attributes, publicParams, issuers[n-1].SecretKey)
+		if err != nil {
+			fmt.Printf("Error during Issue for n=%d: %v\n", n, err)
+			return
+		}
+
+		var totalPresentationTime, totalVerifyTime time.Duration
+
+		// Run Present and Verify 10 times and measure the total times
+		for i := 0; i < 10; i++ {
+			start := time.Now()
+			proof, err := issuerhiding.Present(attributes, signature, revealedIndices, publicParams, nonce, issuers[n-1].PublicKey, accepted)
+			if err != nil {
+				fmt.Printf("Error during Present for n=%d: %v\n", n, err)
+				return
+			}
+			totalPresentationTime += time.Since(start)
+
+			start = time.Now()
+			verified, err := issuerhiding.Verify(proof, nonce, revealed, revealedIndices, publicParams, accepted)
+			if err != nil {
+				fmt.Printf("Error during Verify for n=%d: %v\n", n, err)
+				return
+			}
+			totalVerifyTime += time.Since(start)
+
+			// Check if the proof is valid
+			if !verified {
+				fmt.Printf("Verification failed for n=%d\n", n)
+				return
+			}
+		}
+		// Calculate the average times
+		averagePresentationTime := totalPresentationTime / 10
+		averageVerifyTime := totalVerifyTime / 10
+		// Print the results
+		fmt.Printf("Average issuer-hiding times for n=%d: presentation %v, verify %v\n", n, averagePresentationTime, averageVerifyTime)
+		// Write the results to the file
+		_, err = file.WriteString(fmt.Sprintf("%d,%v,%v\n", n, averagePresentationTime, averageVerifyTime))
+		if err != nil {
+			fmt.Printf("Error writing to results file: %v\n", err)
+			return
+		}
+	}
+}
@@ -1,14 +1,17 @@
 module github.com/aniagut/msc-bbs-anonymous-credentials
 
-go 1.18
+go 1.22.0
+
+require (
+	github.com/aniagut/msc-bbs-plus-plus v1.0.3
+	github.com/cloudflare/circl v1.6.1
+	github.com/stretchr/testify v1.10.0
+	golang.org/x/crypto v0.11.1-0.20230711161743-2e82bdd1719d
+)
 
 require (
-	github.com/aniagut/msc-bbs-plus-plus v1.0.3 // indirect
-	github.com/cloudflare/circl v1.6.1 // indirect
 	github.com/davecgh/go-spew v1.1.1 // indirect
 	github.com/pmezard/go-difflib v1.0.0 // indirect
-	github.com/stretchr/testify v1.10.0 // indirect
-	golang.org/x/crypto v0.11.1-0.20230711161743-2e82bdd1719d // indirect
 	golang.org/x/sys v0.10.0 // indirect
 	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
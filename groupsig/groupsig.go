@@ -0,0 +1,286 @@
+// Package groupsig builds a group signature scheme on credentials: members sign messages anonymously with a proof of
+// possession of their credential, and a designated opener can identify the signer with a publicly verifiable proof.
+//
+// The group manager is the issuer, and every member credential holds the member ID at IdentityIndex. A signature is
+// a presentation revealing no attribute, bound to the message through its nonce, with the member ID escrowed for
+// the opener (see package escrow). Opening decrypts the escrow and proves the correctness of the decryption, so
+// that anyone can judge the opening.
+//
+// Members join through blind issuance: the credential holds a member secret at SecretIndex that the group manager
+// never sees, so the manager cannot sign with the credential of a member. The manager is still trusted to issue
+// each member ID only once: it could issue itself another credential under the ID of an existing member and sign
+// with it, which an opening would attribute to that member. Keeping signed join transcripts holds the manager
+// accountable for the credentials it issued.
+package groupsig
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/aniagut/msc-bbs-anonymous-credentials/escrow"
+	"github.com/aniagut/msc-bbs-anonymous-credentials/issue"
+	"github.com/aniagut/msc-bbs-anonymous-credentials/models"
+	"github.com/aniagut/msc-bbs-anonymous-credentials/presentation"
+	"github.com/aniagut/msc-bbs-anonymous-credentials/verify"
+)
+
+var (
+	// ErrInvalidJoin is returned when a join request or the credential issued for it does not match the group.
+	ErrInvalidJoin = errors.New("invalid join")
+	// ErrInvalidSignature is returned when a group signature is malformed.
+	ErrInvalidSignature = errors.New("invalid group signature")
+	// ErrInvalidOpening is returned when an opening does not match the signature or its proof does not verify.
+	ErrInvalidOpening = errors.New("invalid opening")
+)
+
+// GroupPublicKey is the public key of a group.
+// It contains the following elements:
+// - PublicParameters: The public parameters of the issuer.
+// - IssuerKey: The public key of the group manager issuing member credentials.
+// - OpenerKey: The public key of the opener.
+// - IdentityIndex: The index of the member ID in member credentials.
+// - SecretIndex: The index of the member secret in member credentials, hidden from the group manager.
+type GroupPublicKey struct {
+	PublicParameters models.PublicParameters
+	IssuerKey        models.PublicKey
+	OpenerKey        escrow.PublicKey
+	IdentityIndex    int
+	SecretIndex      int
+}
+
+// MemberKey is the signing key of a member.
+// It contains the following elements:
+// - Attributes: The attributes of the member credential, with the member ID at the identity index.
+// - Credential: The signature of the group manager over the attributes.
+type MemberKey struct {
+	Attributes []string
+	Credential models.Signature
+}
+
+// Signature is a group signature.
+// It contains the following elements:
+// - Proof: The proof of possession of a member credential, bound to the message.
+// - Escrow: The encryption of the member ID for the opener.
+type Signature struct {
+	Proof  models.SignatureProof
+	Escrow escrow.Escrow
+}
+
+// Opening identifies the signer of a group signature.
+// It contains the following elements:
+// - Member: The member ID of the signer.
+// - Proof: The proof that the escrow of the signature decrypts to the member ID.
+type Opening struct {
+	Member string
+	Proof  escrow.DecryptionProof
+}
+
+// RequestJoin starts the join of a new member, committing to a fresh member secret hidden from the group manager.
+//
+// Parameters:
+//   - groupKey: The public key of the group.
+//   - nonce: The nonce issued by the group manager for the join.
+//
+// Returns:
+//   - models.BlindCommitment: The commitment to send to the group manager.
+//   - string: The member secret, to be kept by the member for Complete.
+//   - error: An error if the group key is invalid or the commitment fails.
+func RequestJoin(groupKey GroupPublicKey, nonce []byte) (models.BlindCommitment, string, error) {
+	if err := checkGroupKey(groupKey); err != nil {
+		return models.BlindCommitment{}, "", err
+	}
+	return issue.CommitBlind(nil, nil, groupKey.SecretIndex, groupKey.PublicParameters, nonce)
+}
+
+// Join issues the credential of a new member over the commitment to its member secret, as the group manager.
+//
+// Parameters:
+//   - commitment: The commitment of the member from RequestJoin.
+//   - attributes: The other attributes of the member by index, with the member ID at the identity index.
+//   - groupKey: The public key of the group.
+//   - issuerKey: The secret key of the group manager.
+//   - nonce: The nonce issued for the join, which the commitment must be bound to.
+//
+// Returns:
+//   - models.Signature: The member credential, to be sent to the member.
+//   - error: An error if the commitment or the attributes do not match the group or the issuance fails.
+func Join(commitment models.BlindCommitment, attributes map[int]string, groupKey GroupPublicKey, issuerKey models.SecretKey, nonce []byte) (models.Signature, error) {
+	// Step 1: Check that the member commits only to the member secret and the manager assigns the member ID
+	if err := checkGroupKey(groupKey); err != nil {
+		return models.Signature{}, err
+	}
+	if len(commitment.Indices) != 1 || commitment.Indices[0] != groupKey.SecretIndex {
+		return models.Signature{}, fmt.Errorf("%w: the commitment must hold only the member secret at %d", ErrInvalidJoin, groupKey.SecretIndex)
+	}
+	if _, ok := attributes[groupKey.IdentityIndex]; !ok {
+		return models.Signature{}, fmt.Errorf("%w: missing member ID at %d", ErrInvalidJoin, groupKey.IdentityIndex)
+	}
+
+	// Step 2: Issue the credential blindly over the member secret
+	return issue.IssueBlind(commitment, attributes, groupKey.PublicParameters, issuerKey, nonce)
+}
+
+// Complete assembles the signing key of a new member from the credential issued by the group manager.
+//
+// Parameters:
+//   - secret: The member secret returned by RequestJoin.
+//   - attributes: The other attributes of the member by index, as issued by Join.
+//   - credential: The credential issued by Join.
+//   - groupKey: The public key of the group.
+//
+// Returns:
+//   - MemberKey: The signing key of the member.
+//   - error: An error if the credential is not valid over the member secret and the attributes.
+func Complete(secret string, attributes map[int]string, credential models.Signature, groupKey GroupPublicKey) (MemberKey, error) {
+	// Step 1: Place the member secret and the other attributes in signing order
+	if err := checkGroupKey(groupKey); err != nil {
+		return MemberKey{}, err
+	}
+	values := make([]string, len(groupKey.PublicParameters.H1))
+	if len(attributes)+1 != len(values) {
+		return MemberKey{}, fmt.Errorf("%w: %d attributes and the member secret, %d generators", models.ErrLengthMismatch, len(attributes), len(values))
+	}
+	for index, value := range attributes {
+		if index < 0 || index >= len(values) || index == groupKey.SecretIndex {
+			return MemberKey{}, fmt.Errorf("%w: attribute %d", models.ErrIndexOutOfRange, index)
+		}
+		values[index] = value
+	}
+	values[groupKey.SecretIndex] = secret
+
+	// Step 2: Check the credential before using it
+	valid, err := issue.VerifyCredential(values, credential, groupKey.PublicParameters, groupKey.IssuerKey)
+	if err != nil || !valid {
+		return MemberKey{}, fmt.Errorf("%w: the credential does not verify over the member secret", ErrInvalidJoin)
+	}
+	return MemberKey{Attributes: values, Credential: credential}, nil
+}
+
+// Sign signs a message anonymously on behalf of the group.
+//
+// Parameters:
+//   - message: The message to sign.
+//   - memberKey: The signing key of the member.
+//   - groupKey: The public key of the group.
+//
+// Returns:
+//   - Signature: The group signature.
+//   - error: An error if the group key is invalid or the presentation fails.
+func Sign(message []byte, memberKey MemberKey, groupKey GroupPublicKey) (Signature, error) {
+	// Step 1: Prove possession of the credential without revealing any attribute, escrowing the member ID
+	if err := checkGroupKey(groupKey); err != nil {
+		return Signature{}, err
+	}
+	encryption := escrow.NewEncryption(groupKey.OpenerKey, groupKey.IdentityIndex)
+	proof, err := presentation.PresentationWithExtensions(memberKey.Attributes, memberKey.Credential, nil, groupKey.PublicParameters, messageNonce(message), encryption)
+	if err != nil {
+		return Signature{}, err
+	}
+
+	// Step 2: Collect the escrow of the member ID
+	escrowed, err := encryption.Escrow()
+	if err != nil {
+		return Signature{}, err
+	}
+	return Signature{Proof: proof, Escrow: escrowed}, nil
+}
+
+// Verify checks a group signature on a message.
+//
+// Parameters:
+//   - message: The signed message.
+//   - signature: The group signature.
+//   - groupKey: The public key of the group.
+//
+// Returns:
+//   - bool: true if the signature is valid, false otherwise.
+//   - error: An error if the signature is malformed or does not verify.
+func Verify(message []byte, signature Signature, groupKey GroupPublicKey) (bool, error) {
+	// Step 1: Check that the escrow holds the member ID, so that the signature can be opened
+	if err := checkGroupKey(groupKey); err != nil {
+		return false, err
+	}
+	if signature.Escrow.Index != groupKey.IdentityIndex {
+		return false, fmt.Errorf("%w: escrow of attribute %d, identity at %d", ErrInvalidSignature, signature.Escrow.Index, groupKey.IdentityIndex)
+	}
+
+	// Step 2: Verify the proof of possession and the escrow in the transcript bound to the message
+	return verify.VerifyWithExtensions(signature.Proof, messageNonce(message), nil, nil, groupKey.PublicParameters, groupKey.IssuerKey, escrow.Check(groupKey.OpenerKey, groupKey.IdentityIndex, signature.Escrow))
+}
+
+// Open identifies the signer of a valid group signature.
+//
+// Parameters:
+//   - message: The signed message.
+//   - signature: The group signature.
+//   - groupKey: The public key of the group.
+//   - openerKey: The secret key of the opener.
+//   - members: The IDs of the members of the group.
+//
+// Returns:
+//   - Opening: The member ID of the signer with the proof of correct opening.
+//   - error: An error if the signature is invalid or the signer is not among the members.
+func Open(message []byte, signature Signature, groupKey GroupPublicKey, openerKey escrow.SecretKey, members []string) (Opening, error) {
+	// Step 1: Only open valid signatures
+	if _, err := Verify(message, signature, groupKey); err != nil {
+		return Opening{}, err
+	}
+
+	// Step 2: Decrypt the member ID with a proof of correct decryption and identify the member
+	plaintext, proof, err := escrow.ProveDecryption(openerKey, groupKey.OpenerKey, signature.Escrow.Ciphertext)
+	if err != nil {
+		return Opening{}, err
+	}
+	member, err := escrow.Identify(plaintext, members)
+	if err != nil {
+		return Opening{}, err
+	}
+	return Opening{Member: member, Proof: proof}, nil
+}
+
+// Judge checks that an opening correctly identifies the signer of a group signature.
+//
+// Parameters:
+//   - message: The signed message.
+//   - signature: The group signature.
+//   - groupKey: The public key of the group.
+//   - opening: The opening of the signature.
+//
+// Returns:
+//   - bool: true if the signature is valid and was produced by the member of the opening, false otherwise.
+//   - error: An error if the signature or the opening does not verify.
+//
+// Judge proves that the signature was made with a credential holding the member ID of the opening. It proves that
+// the member signed only as far as the group manager issued that member ID once (see the package documentation).
+func Judge(message []byte, signature Signature, groupKey GroupPublicKey, opening Opening) (bool, error) {
+	// Step 1: Check the signature
+	if _, err := Verify(message, signature, groupKey); err != nil {
+		return false, err
+	}
+
+	// Step 2: Check that the escrow decrypts to the member ID of the opening
+	if err := escrow.VerifyDecryption(groupKey.OpenerKey, signature.Escrow.Ciphertext, escrow.Encode(opening.Member), opening.Proof); err != nil {
+		return false, fmt.Errorf("%w: %w", ErrInvalidOpening, err)
+	}
+	return true, nil
+}
+
+// messageNonce binds a presentation to the signed message.
+func messageNonce(message []byte) []byte {
+	return append([]byte("group-signature:"), message...)
+}
+
+// checkGroupKey checks that the identity and secret indices refer to distinct attributes of the group.
+func checkGroupKey(groupKey GroupPublicKey) error {
+	l := len(groupKey.PublicParameters.H1)
+	if groupKey.IdentityIndex < 0 || groupKey.IdentityIndex >= l {
+		return fmt.Errorf("%w: identity index %d, %d attributes", models.ErrIndexOutOfRange, groupKey.IdentityIndex, l)
+	}
+	if groupKey.SecretIndex < 0 || groupKey.SecretIndex >= l {
+		return fmt.Errorf("%w: secret index %d, %d attributes", models.ErrIndexOutOfRange, groupKey.SecretIndex, l)
+	}
+	if groupKey.SecretIndex == groupKey.IdentityIndex {
+		return fmt.Errorf("%w: the member ID and the member secret share index %d", ErrInvalidJoin, groupKey.IdentityIndex)
+	}
+	return nil
+}
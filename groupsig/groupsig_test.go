@@ -0,0 +1,125 @@
+package groupsig
+
+import (
+	"testing"
+
+	"github.com/aniagut/msc-bbs-anonymous-credentials/escrow"
+	"github.com/aniagut/msc-bbs-anonymous-credentials/issue"
+	"github.com/aniagut/msc-bbs-anonymous-credentials/models"
+	"github.com/aniagut/msc-bbs-anonymous-credentials/setup"
+	"github.com/stretchr/testify/assert"
+)
+
+// MockGroup sets up a group with the members alice and bob, with credentials (member ID, member secret, role)
+func MockGroup(t *testing.T) (GroupPublicKey, escrow.SecretKey, map[string]MemberKey) {
+	groupKey, openerSK, issuerKey := MockGroupKey(t)
+	members := make(map[string]MemberKey)
+	for _, id := range []string{"alice", "bob"} {
+		nonce := []byte("join:" + id)
+		commitment, secret, err := RequestJoin(groupKey, nonce)
+		assert.NoError(t, err, "Expected no error requesting to join")
+		attributes := map[int]string{0: id, 2: "staff"}
+		credential, err := Join(commitment, attributes, groupKey, issuerKey, nonce)
+		assert.NoError(t, err, "Expected no error joining the group")
+		members[id], err = Complete(secret, attributes, credential, groupKey)
+		assert.NoError(t, err, "Expected no error completing the join")
+	}
+	return groupKey, openerSK, members
+}
+
+// MockGroupKey sets up the keys of a group
+func MockGroupKey(t *testing.T) (GroupPublicKey, escrow.SecretKey, models.SecretKey) {
+	result, err := setup.Setup(3)
+	assert.NoError(t, err, "Expected no error during setup")
+	openerSK, openerPK, err := escrow.KeyGen()
+	assert.NoError(t, err, "Expected no error generating the opener key")
+	return GroupPublicKey{
+		PublicParameters: result.PublicParameters,
+		IssuerKey:        result.PublicKey,
+		OpenerKey:        openerPK,
+		IdentityIndex:    0,
+		SecretIndex:      1,
+	}, openerSK, result.SecretKey
+}
+
+// Test for signing, verifying, opening and judging
+func TestGroupSignature_Flow(t *testing.T) {
+	groupKey, openerSK, members := MockGroup(t)
+	message := []byte("approve transfer 17")
+
+	signature, err := Sign(message, members["alice"], groupKey)
+	assert.NoError(t, err, "Expected no error signing")
+	valid, err := Verify(message, signature, groupKey)
+	assert.NoError(t, err, "Expected no error verifying")
+	assert.True(t, valid, "Expected the group signature to be valid")
+
+	opening, err := Open(message, signature, groupKey, openerSK, []string{"alice", "bob"})
+	assert.NoError(t, err, "Expected no error opening the signature")
+	assert.Equal(t, "alice", opening.Member, "Expected the signer to be identified")
+	valid, err = Judge(message, signature, groupKey, opening)
+	assert.NoError(t, err, "Expected no error judging the opening")
+	assert.True(t, valid, "Expected the opening to be correct")
+
+	// An opening blaming another member is rejected
+	blamed := opening
+	blamed.Member = "bob"
+	valid, err = Judge(message, signature, groupKey, blamed)
+	assert.ErrorIs(t, err, ErrInvalidOpening, "Expected an opening blaming bob to be rejected")
+	assert.False(t, valid, "Expected the wrong opening to be invalid")
+}
+
+// Test for rejecting signatures on other messages or with a modified escrow
+func TestGroupSignature_Rejections(t *testing.T) {
+	groupKey, openerSK, members := MockGroup(t)
+	message := []byte("approve transfer 17")
+	signature, err := Sign(message, members["bob"], groupKey)
+	assert.NoError(t, err, "Expected no error signing")
+
+	_, err = Verify([]byte("approve transfer 18"), signature, groupKey)
+	assert.ErrorIs(t, err, models.ErrChallengeMismatch, "Expected a signature on another message to be rejected")
+
+	moved := signature
+	moved.Escrow.Index = 1
+	_, err = Verify(message, moved, groupKey)
+	assert.ErrorIs(t, err, ErrInvalidSignature, "Expected an escrow of another attribute to be rejected")
+
+	_, err = Open([]byte("approve transfer 18"), signature, groupKey, openerSK, []string{"alice", "bob"})
+	assert.ErrorIs(t, err, models.ErrChallengeMismatch, "Expected invalid signatures not to be opened")
+	_, err = Open(message, signature, groupKey, openerSK, []string{"alice"})
+	assert.ErrorIs(t, err, escrow.ErrUnknownPlaintext, "Expected an error for an unknown signer")
+
+	// Two signatures of the same member are not linkable through their escrows
+	other, err := Sign(message, members["bob"], groupKey)
+	assert.NoError(t, err, "Expected no error signing")
+	assert.False(t, other.Escrow.Ciphertext.C1.IsEqual(signature.Escrow.Ciphertext.C1), "Expected fresh ciphertexts")
+	assert.False(t, other.Proof.APrim.IsEqual(signature.Proof.APrim), "Expected fresh proofs")
+}
+
+// Test for joining with a member secret hidden from the group manager
+func TestJoin_MemberSecret(t *testing.T) {
+	groupKey, _, issuerKey := MockGroupKey(t)
+	nonce := []byte("join:carol")
+	commitment, secret, err := RequestJoin(groupKey, nonce)
+	assert.NoError(t, err, "Expected no error requesting to join")
+	assert.Equal(t, []int{groupKey.SecretIndex}, commitment.Indices, "Expected only the member secret to be committed")
+
+	// The manager assigns the member ID and only signs commitments to the member secret for its nonce
+	_, err = Join(commitment, map[int]string{2: "staff"}, groupKey, issuerKey, nonce)
+	assert.ErrorIs(t, err, ErrInvalidJoin, "Expected a join without a member ID to be refused")
+	hiddenID, _, err := issue.CommitBlind([]string{"carol"}, []int{0}, groupKey.SecretIndex, groupKey.PublicParameters, nonce)
+	assert.NoError(t, err, "Expected no error committing to the member ID")
+	_, err = Join(hiddenID, map[int]string{2: "staff"}, groupKey, issuerKey, nonce)
+	assert.ErrorIs(t, err, ErrInvalidJoin, "Expected a hidden member ID to be refused")
+	_, err = Join(commitment, map[int]string{0: "carol", 2: "staff"}, groupKey, issuerKey, []byte("another join"))
+	assert.ErrorIs(t, err, models.ErrInvalidCommitment, "Expected a commitment for another nonce to be refused")
+
+	// The member only accepts a credential over its member secret
+	attributes := map[int]string{0: "carol", 2: "staff"}
+	credential, err := Join(commitment, attributes, groupKey, issuerKey, nonce)
+	assert.NoError(t, err, "Expected no error joining the group")
+	_, err = Complete("guessed secret", attributes, credential, groupKey)
+	assert.ErrorIs(t, err, ErrInvalidJoin, "Expected the credential not to verify without the member secret")
+	member, err := Complete(secret, attributes, credential, groupKey)
+	assert.NoError(t, err, "Expected no error completing the join")
+	assert.Equal(t, []string{"carol", secret, "staff"}, member.Attributes, "Expected the attributes in signing order")
+}
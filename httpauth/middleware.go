@@ -0,0 +1,324 @@
+// Package httpauth authenticates HTTP requests with anonymous credential presentations instead of bearer tokens.
+//
+// A client first obtains a single-use challenge from the server, from the challenge handler or, with a stateless
+// nonce manager, from the WWW-Authenticate header of a rejected request. It then presents its credential with a proof bound to a nonce
+// derived from the challenge and the request (by default its method and path), and sends the presentation in the
+// request header. The middleware consumes the challenge, verifies the proof and exposes the revealed attributes
+// to the downstream handler through the request context.
+package httpauth
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/aniagut/msc-bbs-anonymous-credentials/models"
+	"github.com/aniagut/msc-bbs-anonymous-credentials/nonce"
+	"github.com/aniagut/msc-bbs-anonymous-credentials/presentation"
+	"github.com/aniagut/msc-bbs-anonymous-credentials/request"
+	"github.com/aniagut/msc-bbs-anonymous-credentials/verify"
+)
+
+// DefaultHeader is the request header carrying the presentation when none is configured.
+const DefaultHeader = "Credential-Presentation"
+
+// scheme is the authentication scheme announced in the WWW-Authenticate header.
+const scheme = "BBS"
+
+// ErrUnauthorized is returned when a request carries no valid presentation.
+var ErrUnauthorized = errors.New("unauthorized")
+
+// NonceFunc derives the nonce of a presentation proof from the server challenge and the request,
+// so that a proof cannot be moved to another request.
+type NonceFunc func(r *http.Request, challenge []byte) []byte
+
+// Token is the content of the presentation header, encoded as unpadded base64url JSON.
+// It contains the following elements:
+// - Challenge: The server challenge the proof is bound to.
+// - PresentationResponse: The issuer key ID, the proof and the revealed attributes.
+type Token struct {
+	Challenge []byte `json:"challenge"`
+	models.PresentationResponse
+}
+
+// Config holds the configuration of the middleware.
+// It contains the following elements:
+// - Issuer: The trusted issuer, with the schema naming the revealed attributes.
+// - Nonces: The manager issuing and consuming the server challenges.
+// - NonceFunc: The derivation of the proof nonce. BindRequest is used when nil.
+// - Header: The request header carrying the presentation. DefaultHeader is used when empty.
+// - Required: The names of the attributes every presentation must reveal.
+// - ChallengeURL: The URL of the challenge handler, announced to rejected requests when challenges are not offered.
+// - ChallengeLimit: The hook limiting the challenges issued by the challenge handler, e.g. per client. It is required
+// with a stateful nonce manager, which stores every challenge; returning an error refuses the challenge.
+type Config struct {
+	Issuer         request.Issuer
+	Nonces         *nonce.Manager
+	NonceFunc      NonceFunc
+	Header         string
+	Required       []string
+	ChallengeURL   string
+	ChallengeLimit func(r *http.Request) error
+}
+
+// Middleware authenticates requests with credential presentations.
+type Middleware struct {
+	config Config
+	keyID  string
+}
+
+// contextKey is the type of the context key of the revealed attributes.
+type contextKey struct{}
+
+// New creates an authentication middleware.
+//
+// Parameters:
+//   - config: The configuration of the middleware.
+//
+// Returns:
+//   - *Middleware: The middleware.
+//   - error: An error if the configuration is incomplete.
+func New(config Config) (*Middleware, error) {
+	if config.Nonces == nil {
+		return nil, errors.New("a nonce manager is required")
+	}
+	if !config.Nonces.Stateless() && config.ChallengeLimit == nil {
+		return nil, errors.New("a challenge limit is required with a stateful nonce manager")
+	}
+	if config.Issuer.PublicKey.X2 == nil {
+		return nil, fmt.Errorf("%w: missing issuer public key", models.ErrInvalidPublicKey)
+	}
+	if len(config.Issuer.Schema) != len(config.Issuer.PublicParameters.H1) {
+		return nil, fmt.Errorf("%w: %d attribute names, %d generators", models.ErrLengthMismatch, len(config.Issuer.Schema), len(config.Issuer.PublicParameters.H1))
+	}
+	if _, err := request.RevealedIndices(models.PresentationRequest{RequestedAttributes: config.Required}, config.Issuer.Schema); err != nil {
+		return nil, err
+	}
+	if config.NonceFunc == nil {
+		config.NonceFunc = BindRequest
+	}
+	if config.Header == "" {
+		config.Header = DefaultHeader
+	}
+	return &Middleware{config: config, keyID: config.Issuer.PublicKey.KeyID()}, nil
+}
+
+// Wrap returns a handler that calls next only for requests carrying a valid presentation.
+// Rejected requests receive 401 Unauthorized with a WWW-Authenticate header (see unauthorized).
+func (m *Middleware) Wrap(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attributes, err := m.Authenticate(r)
+		if err != nil {
+			m.unauthorized(w, err)
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), contextKey{}, attributes)))
+	})
+}
+
+// ChallengeHandler returns a handler issuing fresh challenges as {"challenge": ...}.
+// The handler is reachable without authentication, so requests refused by the challenge limit receive
+// 429 Too Many Requests before anything is stored.
+func (m *Middleware) ChallengeHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if m.config.ChallengeLimit != nil {
+			if err := m.config.ChallengeLimit(r); err != nil {
+				http.Error(w, "too many challenges", http.StatusTooManyRequests)
+				return
+			}
+		}
+		challenge, err := m.config.Nonces.Issue()
+		if errors.Is(err, nonce.ErrStoreFull) {
+			http.Error(w, "challenges temporarily unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		if err != nil {
+			http.Error(w, "failed to issue a challenge", http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		json.NewEncoder(w).Encode(map[string][]byte{"challenge": challenge})
+	})
+}
+
+// Authenticate verifies the presentation carried by the request.
+//
+// Parameters:
+//   - r: The HTTP request.
+//
+// Returns:
+//   - map[string]string: The revealed attributes by name.
+//   - error: An error wrapping ErrUnauthorized if the presentation is missing or invalid.
+func (m *Middleware) Authenticate(r *http.Request) (map[string]string, error) {
+	// Step 1: Decode the presentation from the header
+	header := r.Header.Get(m.config.Header)
+	if header == "" {
+		return nil, fmt.Errorf("%w: missing %s header", ErrUnauthorized, m.config.Header)
+	}
+	token, err := DecodeToken(header)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrUnauthorized, err)
+	}
+	if token.IssuerKeyID != m.keyID {
+		return nil, fmt.Errorf("%w: issuer %q is not trusted", ErrUnauthorized, token.IssuerKeyID)
+	}
+
+	// Step 2: Consume the challenge, so that a presentation is accepted at most once
+	if err := m.config.Nonces.Consume(token.Challenge); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrUnauthorized, err)
+	}
+
+	// Step 3: Verify the proof against the nonce derived from the challenge and the request
+	valid, err := verify.Verify(token.Proof, m.config.NonceFunc(r, token.Challenge), token.RevealedAttributes, token.RevealedIndices, m.config.Issuer.PublicParameters, m.config.Issuer.PublicKey)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrUnauthorized, err)
+	}
+	if !valid {
+		return nil, fmt.Errorf("%w: invalid presentation proof", ErrUnauthorized)
+	}
+
+	// Step 4: Name the revealed attributes and check that the required ones are present
+	attributes := make(map[string]string, len(token.RevealedIndices))
+	for i, index := range token.RevealedIndices {
+		attributes[m.config.Issuer.Schema[index]] = token.RevealedAttributes[i]
+	}
+	for _, name := range m.config.Required {
+		if _, ok := attributes[name]; !ok {
+			return nil, fmt.Errorf("%w: attribute %q is not revealed", ErrUnauthorized, name)
+		}
+	}
+	return attributes, nil
+}
+
+// Attributes returns the revealed attributes of the authenticated request, by name.
+func Attributes(ctx context.Context) (map[string]string, bool) {
+	attributes, ok := ctx.Value(contextKey{}).(map[string]string)
+	return attributes, ok
+}
+
+// BindRequest is the default NonceFunc. It derives the nonce as
+// SHA-256(len(challenge) || challenge || len(method) || method || len(path) || path).
+// The path is the one seen by the middleware, so it must wrap the handler before any http.StripPrefix.
+func BindRequest(r *http.Request, challenge []byte) []byte {
+	h := sha256.New()
+	for _, field := range [][]byte{challenge, []byte(r.Method), []byte(r.URL.Path)} {
+		var length [8]byte
+		binary.BigEndian.PutUint64(length[:], uint64(len(field)))
+		h.Write(length[:])
+		h.Write(field)
+	}
+	return h.Sum(nil)
+}
+
+// Authorize adds a presentation of the credential bound to the challenge and the request to the request header.
+//
+// Parameters:
+//   - r: The outgoing HTTP request, with its final method and URL.
+//   - challenge: The server challenge.
+//   - credential: The credential of the holder.
+//   - publicParams: The public parameters of the issuer of the credential.
+//   - reveal: The names of the attributes to reveal.
+//   - nonceFunc: The nonce derivation used by the server. BindRequest is used when nil.
+//   - header: The request header carrying the presentation. DefaultHeader is used when empty.
+//
+// Returns:
+//   - error: An error if an attribute is not part of the credential or the proof generation fails.
+func Authorize(r *http.Request, challenge []byte, credential models.Credential, publicParams models.PublicParameters, reveal []string, nonceFunc NonceFunc, header string) error {
+	if nonceFunc == nil {
+		nonceFunc = BindRequest
+	}
+	if header == "" {
+		header = DefaultHeader
+	}
+
+	// Step 1: Compute the indices of the revealed attributes
+	indices, err := request.RevealedIndices(models.PresentationRequest{RequestedAttributes: reveal}, credential.Schema)
+	if err != nil {
+		return err
+	}
+	revealed := make([]string, len(indices))
+	for i, index := range indices {
+		revealed[i] = credential.Attributes[index]
+	}
+
+	// Step 2: Generate the proof bound to the request
+	proof, err := presentation.Presentation(credential.Attributes, credential.Signature, indices, publicParams, nonceFunc(r, challenge))
+	if err != nil {
+		return err
+	}
+
+	// Step 3: Encode the token into the header
+	encoded, err := EncodeToken(Token{
+		Challenge: challenge,
+		PresentationResponse: models.PresentationResponse{
+			IssuerKeyID:        credential.IssuerKeyID,
+			Proof:              proof,
+			RevealedIndices:    indices,
+			RevealedAttributes: revealed,
+		},
+	})
+	if err != nil {
+		return err
+	}
+	r.Header.Set(header, encoded)
+	return nil
+}
+
+// ParseChallenge extracts the challenge offered in the WWW-Authenticate header of a response rejected by a
+// middleware with a stateless nonce manager.
+func ParseChallenge(resp *http.Response) ([]byte, error) {
+	var encoded string
+	if _, err := fmt.Sscanf(resp.Header.Get("WWW-Authenticate"), scheme+` challenge=%q`, &encoded); err != nil {
+		return nil, fmt.Errorf("%w: WWW-Authenticate header: %v", models.ErrInvalidEncoding, err)
+	}
+	challenge, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("%w: WWW-Authenticate header: %v", models.ErrInvalidEncoding, err)
+	}
+	return challenge, nil
+}
+
+// EncodeToken encodes a token as unpadded base64url JSON.
+func EncodeToken(token Token) (string, error) {
+	data, err := json.Marshal(token)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+// DecodeToken decodes a token encoded with EncodeToken.
+func DecodeToken(s string) (Token, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return Token{}, fmt.Errorf("%w: presentation header: %v", models.ErrInvalidEncoding, err)
+	}
+	var token Token
+	if err := json.Unmarshal(data, &token); err != nil {
+		return Token{}, fmt.Errorf("%w: presentation header: %v", models.ErrInvalidEncoding, err)
+	}
+	return token, nil
+}
+
+// unauthorized rejects the request. A stateless nonce manager stores nothing at issuance, so a fresh challenge is
+// offered in the WWW-Authenticate header. A stateful one would record a nonce for every anonymous request, so the
+// header only announces the challenge handler instead.
+func (m *Middleware) unauthorized(w http.ResponseWriter, err error) {
+	switch {
+	case m.config.Nonces.Stateless():
+		if challenge, issueErr := m.config.Nonces.Issue(); issueErr == nil {
+			w.Header().Set("WWW-Authenticate", fmt.Sprintf(`%s challenge="%s"`, scheme, base64.RawURLEncoding.EncodeToString(challenge)))
+		}
+	case m.config.ChallengeURL != "":
+		w.Header().Set("WWW-Authenticate", fmt.Sprintf(`%s challenge_uri=%q`, scheme, m.config.ChallengeURL))
+	default:
+		w.Header().Set("WWW-Authenticate", scheme)
+	}
+	http.Error(w, err.Error(), http.StatusUnauthorized)
+}
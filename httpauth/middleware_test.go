@@ -0,0 +1,200 @@
+package httpauth
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/aniagut/msc-bbs-anonymous-credentials/issue"
+	"github.com/aniagut/msc-bbs-anonymous-credentials/models"
+	"github.com/aniagut/msc-bbs-anonymous-credentials/nonce"
+	"github.com/aniagut/msc-bbs-anonymous-credentials/request"
+	"github.com/aniagut/msc-bbs-anonymous-credentials/setup"
+	"github.com/stretchr/testify/assert"
+)
+
+// MockServer starts a server with a protected endpoint echoing the revealed role, and returns a credential for it
+func MockServer(t *testing.T, nonces *nonce.Manager) (*httptest.Server, models.Credential, models.PublicParameters) {
+	schema := []string{"name", "role", "team"}
+	attributes := []string{"Alice", "admin", "payments"}
+	setupResult, err := setup.Setup(len(schema))
+	assert.NoError(t, err, "Expected no error during setup")
+	signature, err := issue.Issue(attributes, setupResult.PublicParameters, setupResult.SecretKey)
+	assert.NoError(t, err, "Expected no error during issuance")
+
+	middleware, err := New(Config{
+		Issuer: request.Issuer{
+			PublicParameters: setupResult.PublicParameters,
+			PublicKey:        setupResult.PublicKey,
+			Schema:           schema,
+		},
+		Nonces:       nonces,
+		Required:     []string{"role"},
+		ChallengeURL: "/challenge",
+		// The tests issue few challenges
+		ChallengeLimit: func(*http.Request) error { return nil },
+	})
+	assert.NoError(t, err, "Expected no error when creating the middleware")
+
+	mux := http.NewServeMux()
+	mux.Handle("GET /challenge", middleware.ChallengeHandler())
+	mux.Handle("/api/", middleware.Wrap(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attributes, _ := Attributes(r.Context())
+		io.WriteString(w, attributes["role"])
+	})))
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	credential := models.Credential{
+		Schema:      schema,
+		Attributes:  attributes,
+		Signature:   signature,
+		IssuerKeyID: setupResult.PublicKey.KeyID(),
+	}
+	return server, credential, setupResult.PublicParameters
+}
+
+// getChallenge fetches a fresh challenge from the server
+func getChallenge(t *testing.T, server *httptest.Server) []byte {
+	resp, err := http.Get(server.URL + "/challenge")
+	assert.NoError(t, err, "Expected no error fetching the challenge")
+	defer resp.Body.Close()
+	var body map[string][]byte
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&body), "Expected no error decoding the challenge")
+	return body["challenge"]
+}
+
+// Test for an authenticated request
+func TestMiddleware_Authenticated(t *testing.T) {
+	server, credential, publicParams := MockServer(t, nonce.NewManager(nonce.NewMemoryStore(), time.Minute))
+
+	r, _ := http.NewRequest(http.MethodGet, server.URL+"/api/reports", nil)
+	err := Authorize(r, getChallenge(t, server), credential, publicParams, []string{"role"}, nil, "")
+	assert.NoError(t, err, "Expected no error presenting the credential")
+
+	resp, err := http.DefaultClient.Do(r)
+	assert.NoError(t, err, "Expected no error sending the request")
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	assert.Equal(t, http.StatusOK, resp.StatusCode, string(body))
+	assert.Equal(t, "admin", string(body), "Expected the revealed role to reach the handler")
+}
+
+// Test for the challenge offered by a request rejected with a stateless nonce manager
+func TestMiddleware_ChallengeFromRejection(t *testing.T) {
+	nonces, err := nonce.NewStatelessManager(bytes.Repeat([]byte{0x42}, 32), nonce.NewMemoryStore(), time.Minute)
+	assert.NoError(t, err, "Expected no error creating the nonce manager")
+	server, credential, publicParams := MockServer(t, nonces)
+
+	resp, err := http.Get(server.URL + "/api/reports")
+	assert.NoError(t, err, "Expected no error sending the request")
+	resp.Body.Close()
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode, "Expected a request without presentation to be rejected")
+	challenge, err := ParseChallenge(resp)
+	assert.NoError(t, err, "Expected a challenge in the WWW-Authenticate header")
+
+	r, _ := http.NewRequest(http.MethodGet, server.URL+"/api/reports", nil)
+	assert.NoError(t, Authorize(r, challenge, credential, publicParams, []string{"role"}, nil, ""))
+	resp, err = http.DefaultClient.Do(r)
+	assert.NoError(t, err, "Expected no error sending the request")
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode, "Expected the offered challenge to be accepted")
+}
+
+// Test for rejected requests not recording nonces in a stateful nonce manager
+func TestMiddleware_RejectionWithoutChallenge(t *testing.T) {
+	store := nonce.NewMemoryStore()
+	server, _, _ := MockServer(t, nonce.NewManager(store, time.Minute))
+
+	for i := 0; i < 10; i++ {
+		resp, err := http.Get(server.URL + "/api/reports")
+		assert.NoError(t, err, "Expected no error sending the request")
+		resp.Body.Close()
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode, "Expected a request without presentation to be rejected")
+		assert.Equal(t, `BBS challenge_uri="/challenge"`, resp.Header.Get("WWW-Authenticate"), "Expected the challenge handler to be announced")
+		_, err = ParseChallenge(resp)
+		assert.Error(t, err, "Expected no challenge to be offered")
+	}
+	assert.Equal(t, 0, store.Len(), "Expected no nonce to be recorded for rejected requests")
+}
+
+// Test for presentations the middleware must reject
+func TestMiddleware_Rejections(t *testing.T) {
+	server, credential, publicParams := MockServer(t, nonce.NewManager(nonce.NewMemoryStore(), time.Minute))
+
+	// A presentation replayed with the same challenge
+	r, _ := http.NewRequest(http.MethodGet, server.URL+"/api/reports", nil)
+	assert.NoError(t, Authorize(r, getChallenge(t, server), credential, publicParams, []string{"role"}, nil, ""))
+	for i, expected := range []int{http.StatusOK, http.StatusUnauthorized} {
+		resp, err := http.DefaultClient.Do(r)
+		assert.NoError(t, err, "Expected no error sending the request")
+		resp.Body.Close()
+		assert.Equal(t, expected, resp.StatusCode, "Unexpected status for attempt %d", i)
+	}
+
+	// A presentation bound to another path
+	r, _ = http.NewRequest(http.MethodGet, server.URL+"/api/reports", nil)
+	assert.NoError(t, Authorize(r, getChallenge(t, server), credential, publicParams, []string{"role"}, nil, ""))
+	moved, _ := http.NewRequest(http.MethodDelete, server.URL+"/api/users", nil)
+	moved.Header = r.Header
+	resp, err := http.DefaultClient.Do(moved)
+	assert.NoError(t, err, "Expected no error sending the request")
+	resp.Body.Close()
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode, "Expected a presentation moved to another request to be rejected")
+
+	// A presentation hiding a required attribute
+	r, _ = http.NewRequest(http.MethodGet, server.URL+"/api/reports", nil)
+	assert.NoError(t, Authorize(r, getChallenge(t, server), credential, publicParams, []string{"team"}, nil, ""))
+	resp, err = http.DefaultClient.Do(r)
+	assert.NoError(t, err, "Expected no error sending the request")
+	resp.Body.Close()
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode, "Expected a presentation hiding the role to be rejected")
+
+	// A malformed header
+	r, _ = http.NewRequest(http.MethodGet, server.URL+"/api/reports", nil)
+	r.Header.Set(DefaultHeader, "not a token")
+	resp, err = http.DefaultClient.Do(r)
+	assert.NoError(t, err, "Expected no error sending the request")
+	resp.Body.Close()
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode, "Expected a malformed presentation to be rejected")
+}
+
+// Test for limiting the challenges stored by a stateful nonce manager
+func TestChallengeHandler_Limit(t *testing.T) {
+	setupResult, err := setup.Setup(1)
+	assert.NoError(t, err, "Expected no error during setup")
+	config := Config{
+		Issuer: request.Issuer{
+			PublicParameters: setupResult.PublicParameters,
+			PublicKey:        setupResult.PublicKey,
+			Schema:           []string{"role"},
+		},
+		Nonces: nonce.NewManager(nonce.NewMemoryStoreWithLimit(2), time.Minute),
+	}
+	_, err = New(config)
+	assert.Error(t, err, "Expected a stateful nonce manager without a challenge limit to be refused")
+
+	issued := 0
+	config.ChallengeLimit = func(*http.Request) error {
+		if issued++; issued > 3 {
+			return errors.New("limit reached")
+		}
+		return nil
+	}
+	middleware, err := New(config)
+	assert.NoError(t, err, "Expected no error when creating the middleware")
+	handler := middleware.ChallengeHandler()
+	for i, expected := range []int{http.StatusOK, http.StatusOK, http.StatusServiceUnavailable, http.StatusTooManyRequests} {
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/challenge", nil))
+		assert.Equal(t, expected, recorder.Code, "Unexpected status for challenge %d", i)
+		if expected != http.StatusOK {
+			assert.NotContains(t, recorder.Body.String(), nonce.ErrStoreFull.Error(), "Expected a generic error message")
+		}
+	}
+}
@@ -0,0 +1,32 @@
+// Package httpjson writes the JSON responses shared by the HTTP handlers of the issuer and the verifier.
+package httpjson
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/aniagut/msc-bbs-anonymous-credentials/utils"
+)
+
+// ErrorResponse is the body of every failed request.
+type ErrorResponse struct {
+	Error string `json:"error"`
+}
+
+// WriteJSON writes the value as a JSON response with the given status.
+func WriteJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// WriteError writes the error as a JSON response with the given status.
+// Server errors are logged and answered with a fixed message, so that internal details do not reach clients.
+func WriteError(w http.ResponseWriter, status int, err error) {
+	message := err.Error()
+	if status >= http.StatusInternalServerError {
+		utils.Logger().Error("request failed", "status", status, "error", err)
+		message = http.StatusText(status)
+	}
+	WriteJSON(w, status, ErrorResponse{Error: message})
+}
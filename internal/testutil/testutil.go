@@ -0,0 +1,30 @@
+// Package testutil holds the fixtures shared by the tests of the extension packages.
+package testutil
+
+import (
+	"testing"
+
+	"github.com/aniagut/msc-bbs-anonymous-credentials/issue"
+	"github.com/aniagut/msc-bbs-anonymous-credentials/models"
+	"github.com/aniagut/msc-bbs-anonymous-credentials/setup"
+	"github.com/stretchr/testify/assert"
+)
+
+// MockCredential sets up an issuer for the attributes and issues a credential over them.
+//
+// Parameters:
+//   - t: The test using the credential.
+//   - attributes: The attributes of the credential, in signing order.
+//
+// Returns:
+//   - []string: The attributes of the credential.
+//   - models.Signature: The issued credential.
+//   - models.SetupResult: The parameters and keys of the issuer.
+func MockCredential(t *testing.T, attributes ...string) ([]string, models.Signature, models.SetupResult) {
+	t.Helper()
+	result, err := setup.Setup(len(attributes))
+	assert.NoError(t, err, "Expected no error during setup")
+	signature, err := issue.Issue(attributes, result.PublicParameters, result.SecretKey)
+	assert.NoError(t, err, "Expected no error during issuance")
+	return attributes, signature, result
+}
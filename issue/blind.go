@@ -0,0 +1,264 @@
+package issue
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"sort"
+
+	"github.com/aniagut/msc-bbs-anonymous-credentials/models"
+	"github.com/aniagut/msc-bbs-anonymous-credentials/utils"
+	m "github.com/aniagut/msc-bbs-plus-plus/models"
+	bbsverify "github.com/aniagut/msc-bbs-plus-plus/verify"
+	e "github.com/cloudflare/circl/ecc/bls12381"
+)
+
+// holderSecretSize is the number of random bytes of the holder secret added to every blind commitment.
+const holderSecretSize = 32
+
+// CommitBlind commits the holder to attributes that are hidden from the issuer during issuance,
+// and proves knowledge of the committed attributes.
+// A fresh random holder secret is committed at secretIndex together with the given attributes, so that the commitment
+// is hiding and unlinkable even if the other committed attributes have low entropy. The proof is bound to a nonce
+// issued by the issuer, so that it cannot be replayed in another issuance session.
+//
+// Parameters:
+//   - values: The values of the committed attributes, which may be empty.
+//   - indices: The sorted indices of the committed attributes, aligned with values.
+//   - secretIndex: The index of the holder secret, which must not be among indices.
+//   - publicParams: The public parameters of the system.
+//   - nonce: The nonce issued by the issuer for the issuance session.
+//
+// Returns:
+//   - models.BlindCommitment: The commitment with the proof of knowledge of the committed attributes.
+//   - string: The holder secret, which the holder keeps as the attribute at secretIndex of the credential.
+//   - error: An error if the indices are invalid or the proof generation fails.
+func CommitBlind(values []string, indices []int, secretIndex int, publicParams models.PublicParameters, nonce []byte) (models.BlindCommitment, string, error) {
+	// Step 1: Validate the indices and add a fresh holder secret at secretIndex to the committed attributes
+	if len(values) != len(indices) {
+		return models.BlindCommitment{}, "", fmt.Errorf("%w: %d values, %d indices", models.ErrLengthMismatch, len(values), len(indices))
+	}
+	if err := checkIndices(indices, len(publicParams.H1)); err != nil {
+		return models.BlindCommitment{}, "", err
+	}
+	secret, err := holderSecret()
+	if err != nil {
+		return models.BlindCommitment{}, "", err
+	}
+	values, indices, err = withSecret(values, indices, secretIndex, secret)
+	if err != nil {
+		return models.BlindCommitment{}, "", err
+	}
+	if err := checkIndices(indices, len(publicParams.H1)); err != nil {
+		return models.BlindCommitment{}, "", err
+	}
+	committedH := make([]e.G1, len(indices))
+	for i, index := range indices {
+		committedH[i] = publicParams.H1[index]
+	}
+
+	// Step 2: Compute the commitment ∏_i h₁[i]^m[i]
+	mScalars := make([]e.Scalar, len(values))
+	for i, value := range values {
+		mScalars[i].SetBytes(utils.SerializeString(value))
+	}
+	commitment, err := utils.ComputeH1Exp(committedH, mScalars)
+	if err != nil {
+		return models.BlindCommitment{}, "", err
+	}
+
+	// Step 3: Compute T ← ∏_i h₁[i]^v[i] for random v[i]
+	v := make([]e.Scalar, len(values))
+	defer utils.ZeroizeScalarSlice(v)
+	defer utils.ZeroizeScalarSlice(mScalars)
+	for i := range v {
+		if v[i], err = utils.RandomScalar(); err != nil {
+			return models.BlindCommitment{}, "", err
+		}
+	}
+	T, err := utils.ComputeH1Exp(committedH, v)
+	if err != nil {
+		return models.BlindCommitment{}, "", err
+	}
+
+	// Step 4: Compute the challenge ch ← H(commitment, T, indices, nonce)
+	ch, err := blindChallenge(commitment, T, indices, nonce)
+	if err != nil {
+		return models.BlindCommitment{}, "", err
+	}
+
+	// Step 5: Compute z[i] ← v[i] + ch * m[i]
+	z := make([]e.Scalar, len(values))
+	for i := range z {
+		z[i].Mul(&ch, &mScalars[i])
+		z[i].Add(&z[i], &v[i])
+	}
+
+	return models.BlindCommitment{
+		Commitment: commitment,
+		Indices:    indices,
+		Ch:         &ch,
+		Z:          z,
+	}, secret, nil
+}
+
+// IssueBlind generates a credential over attributes known to the issuer and attributes committed by the holder.
+//
+// Parameters:
+//   - commitment: The holder's commitment to the hidden attributes.
+//   - known: The attributes known to the issuer, by index. Together with the committed indices they must cover every attribute exactly once.
+//   - publicParams: The public parameters of the system.
+//   - secretKey: The secret key of the system.
+//   - nonce: The nonce issued for the issuance session, which the proof of knowledge must be bound to.
+//
+// Returns:
+//   - Signature: The generated signature.
+//   - error: An error if the commitment is invalid or the signing process fails.
+func IssueBlind(commitment models.BlindCommitment, known map[int]string, publicParams models.PublicParameters, secretKey models.SecretKey, nonce []byte) (models.Signature, error) {
+	return IssueBlindWithSigner(commitment, known, publicParams, NewKeySigner(secretKey), nonce)
+}
+
+// IssueBlindWithSigner generates a credential over known and committed attributes with a Signer.
+//
+// Parameters:
+//   - commitment: The holder's commitment to the hidden attributes.
+//   - known: The attributes known to the issuer, by index. Together with the committed indices they must cover every attribute exactly once.
+//   - publicParams: The public parameters of the system.
+//   - signer: The signer holding the secret key.
+//   - nonce: The nonce issued for the issuance session, which the proof of knowledge must be bound to.
+//
+// Returns:
+//   - Signature: The generated signature.
+//   - error: An error if the commitment is invalid or the signing process fails.
+func IssueBlindWithSigner(commitment models.BlindCommitment, known map[int]string, publicParams models.PublicParameters, signer Signer, nonce []byte) (models.Signature, error) {
+	// Step 1: Verify the proof of knowledge of the committed attributes for the nonce of the session
+	if err := VerifyBlindCommitment(commitment, publicParams, nonce); err != nil {
+		return models.Signature{}, err
+	}
+
+	// Step 2: Check that the known and committed attributes cover every attribute exactly once
+	if len(known)+len(commitment.Indices) != len(publicParams.H1) {
+		return models.Signature{}, fmt.Errorf("%w: %d known and %d committed attributes, %d generators", models.ErrLengthMismatch, len(known), len(commitment.Indices), len(publicParams.H1))
+	}
+	for _, index := range commitment.Indices {
+		if _, ok := known[index]; ok {
+			return models.Signature{}, fmt.Errorf("%w: attribute %d is both known and committed", models.ErrInvalidCommitment, index)
+		}
+	}
+
+	// Step 3: Compute C ← g1 * commitment * ∏_i h₁[i]^a[i] for the known attributes
+	C := new(e.G1)
+	C.Add(publicParams.G1, commitment.Commitment)
+	for index, value := range known {
+		if index < 0 || index >= len(publicParams.H1) {
+			return models.Signature{}, fmt.Errorf("%w: index %d, %d attributes", models.ErrIndexOutOfRange, index, len(publicParams.H1))
+		}
+		aScalar := new(e.Scalar)
+		aScalar.SetBytes(utils.SerializeString(value))
+		hExp := new(e.G1)
+		hExp.ScalarMult(aScalar, &publicParams.H1[index])
+		C.Add(C, hExp)
+	}
+
+	// Step 4: Select random e and compute A ← C^{1 / (x + e)} with the signer
+	return signCommitment(C, signer)
+}
+
+// VerifyBlindCommitment checks the proof of knowledge of the attributes committed in a blind commitment,
+// bound to the nonce of the issuance session.
+func VerifyBlindCommitment(commitment models.BlindCommitment, publicParams models.PublicParameters, nonce []byte) error {
+	// Step 1: Validate the structure of the commitment
+	if commitment.Commitment == nil || commitment.Ch == nil || len(commitment.Z) != len(commitment.Indices) {
+		return fmt.Errorf("%w: missing or mismatched components", models.ErrInvalidCommitment)
+	}
+	if !commitment.Commitment.IsOnG1() {
+		return fmt.Errorf("%w: commitment is not an element of G1", models.ErrInvalidCommitment)
+	}
+	if err := checkIndices(commitment.Indices, len(publicParams.H1)); err != nil {
+		return err
+	}
+
+	// Step 2: Recompute T ← ∏_i h₁[i]^z[i] * commitment^(-ch)
+	committedH := make([]e.G1, len(commitment.Indices))
+	for i, index := range commitment.Indices {
+		committedH[i] = publicParams.H1[index]
+	}
+	T, err := utils.ComputeH1Exp(committedH, commitment.Z)
+	if err != nil {
+		return err
+	}
+	negCh := new(e.Scalar)
+	*negCh = *commitment.Ch
+	negCh.Neg()
+	commitmentExp := new(e.G1)
+	commitmentExp.ScalarMult(negCh, commitment.Commitment)
+	T.Add(T, commitmentExp)
+
+	// Step 3: Check that the recomputed challenge matches
+	ch, err := blindChallenge(commitment.Commitment, T, commitment.Indices, nonce)
+	if err != nil {
+		return err
+	}
+	if ch.IsEqual(commitment.Ch) != 1 {
+		return fmt.Errorf("%w: proof of knowledge does not verify", models.ErrInvalidCommitment)
+	}
+	return nil
+}
+
+// VerifyCredential checks that the signature is a valid BBS++ signature over the attributes.
+// Holders use it to check a credential obtained through blind issuance.
+func VerifyCredential(attributes []string, signature models.Signature, publicParams models.PublicParameters, publicKey models.PublicKey) (bool, error) {
+	return bbsverify.Verify(
+		m.PublicParameters{G1: publicParams.G1, G2: publicParams.G2, H1: publicParams.H1},
+		m.VerificationKey{X2: publicKey.X2},
+		attributes,
+		m.Signature{A: signature.A, E: signature.E},
+	)
+}
+
+// blindChallenge computes the challenge ch ← H(commitment, T, indices, nonce) of the proof of knowledge of committed attributes.
+func blindChallenge(commitment *e.G1, T *e.G1, indices []int, nonce []byte) (e.Scalar, error) {
+	indexBytes := make([]byte, 0, 8*len(indices))
+	for _, index := range indices {
+		indexBytes = binary.BigEndian.AppendUint64(indexBytes, uint64(index))
+	}
+	nonceBytes := binary.BigEndian.AppendUint64(nil, uint64(len(nonce)))
+	nonceBytes = append(nonceBytes, nonce...)
+	return utils.HashToScalar([]byte("blind-commitment"), utils.SerializeG1(commitment), utils.SerializeG1(T), indexBytes, nonceBytes)
+}
+
+// holderSecret generates a random holder secret, hex-encoded so that it can be kept as a string attribute.
+func holderSecret() (string, error) {
+	secret := make([]byte, holderSecretSize)
+	if _, err := rand.Read(secret); err != nil {
+		return "", fmt.Errorf("%w: holder secret: %v", models.ErrRandomness, err)
+	}
+	return hex.EncodeToString(secret), nil
+}
+
+// withSecret inserts the holder secret at secretIndex into the sorted committed attributes.
+func withSecret(values []string, indices []int, secretIndex int, secret string) ([]string, []int, error) {
+	position := sort.SearchInts(indices, secretIndex)
+	if position < len(indices) && indices[position] == secretIndex {
+		return nil, nil, fmt.Errorf("%w: attribute %d is both given and the holder secret", models.ErrInvalidCommitment, secretIndex)
+	}
+	outValues := make([]string, 0, len(values)+1)
+	outValues = append(append(append(outValues, values[:position]...), secret), values[position:]...)
+	outIndices := make([]int, 0, len(indices)+1)
+	outIndices = append(append(append(outIndices, indices[:position]...), secretIndex), indices[position:]...)
+	return outValues, outIndices, nil
+}
+
+// checkIndices checks that the indices are strictly increasing and in range.
+func checkIndices(indices []int, l int) error {
+	for i, index := range indices {
+		if index < 0 || index >= l {
+			return fmt.Errorf("%w: index %d, %d attributes", models.ErrIndexOutOfRange, index, l)
+		}
+		if i > 0 && index <= indices[i-1] {
+			return fmt.Errorf("%w: index %d follows %d", models.ErrUnsortedIndices, index, indices[i-1])
+		}
+	}
+	return nil
+}
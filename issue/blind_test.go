@@ -0,0 +1,88 @@
+package issue
+
+import (
+	"testing"
+
+	"github.com/aniagut/msc-bbs-anonymous-credentials/models"
+	"github.com/aniagut/msc-bbs-anonymous-credentials/presentation"
+	"github.com/aniagut/msc-bbs-anonymous-credentials/setup"
+	"github.com/aniagut/msc-bbs-anonymous-credentials/verify"
+	e "github.com/cloudflare/circl/ecc/bls12381"
+	"github.com/stretchr/testify/assert"
+)
+
+// Test for blind issuance of a credential that can be presented and verified
+func TestIssueBlind_Success(t *testing.T) {
+	setupResult, err := setup.Setup(3)
+	assert.NoError(t, err, "Expected no error during setup")
+	nonce := []byte("issuance_nonce")
+
+	commitment, secret, err := CommitBlind([]string{"30"}, []int{2}, 0, setupResult.PublicParameters, nonce)
+	assert.NoError(t, err, "Expected no error when committing to hidden attributes")
+	assert.Equal(t, []int{0, 2}, commitment.Indices, "Expected the holder secret to be committed with the attributes")
+	assert.Len(t, secret, 2*holderSecretSize, "Expected a hex-encoded holder secret")
+
+	signature, err := IssueBlind(commitment, map[int]string{1: "Alice"}, setupResult.PublicParameters, setupResult.SecretKey, nonce)
+	assert.NoError(t, err, "Expected no error during blind issuance")
+
+	attributes := []string{secret, "Alice", "30"}
+	valid, err := VerifyCredential(attributes, signature, setupResult.PublicParameters, setupResult.PublicKey)
+	assert.NoError(t, err, "Expected no error when verifying the credential")
+	assert.True(t, valid, "Expected the blindly issued credential to be valid")
+
+	presentationNonce := []byte("random_nonce")
+	proof, err := presentation.Presentation(attributes, signature, []int{1}, setupResult.PublicParameters, presentationNonce)
+	assert.NoError(t, err, "Expected no error during presentation")
+	valid, err = verify.Verify(proof, presentationNonce, attributes[1:2], []int{1}, setupResult.PublicParameters, setupResult.PublicKey)
+	assert.NoError(t, err, "Expected no error during verification")
+	assert.True(t, valid, "Expected the presentation to be valid")
+}
+
+// Test for commitments to the same attributes being unlinkable
+func TestCommitBlind_Hiding(t *testing.T) {
+	setupResult, err := setup.Setup(2)
+	assert.NoError(t, err, "Expected no error during setup")
+
+	first, firstSecret, err := CommitBlind([]string{"30"}, []int{1}, 0, setupResult.PublicParameters, []byte("nonce"))
+	assert.NoError(t, err, "Expected no error when committing to hidden attributes")
+	second, secondSecret, err := CommitBlind([]string{"30"}, []int{1}, 0, setupResult.PublicParameters, []byte("nonce"))
+	assert.NoError(t, err, "Expected no error when committing to hidden attributes")
+	assert.NotEqual(t, firstSecret, secondSecret, "Expected fresh holder secrets")
+	assert.False(t, first.Commitment.IsEqual(second.Commitment), "Expected commitments to the same attributes to differ")
+
+	_, _, err = CommitBlind([]string{"30"}, []int{1}, 1, setupResult.PublicParameters, []byte("nonce"))
+	assert.ErrorIs(t, err, models.ErrInvalidCommitment, "Expected the holder secret to be refused on a given attribute")
+	_, _, err = CommitBlind(nil, nil, 2, setupResult.PublicParameters, []byte("nonce"))
+	assert.ErrorIs(t, err, models.ErrIndexOutOfRange, "Expected the holder secret to be refused out of range")
+}
+
+// Test for a commitment whose proof of knowledge does not verify
+func TestIssueBlind_InvalidProof(t *testing.T) {
+	setupResult, err := setup.Setup(2)
+	assert.NoError(t, err, "Expected no error during setup")
+	nonce := []byte("issuance_nonce")
+	commitment, _, err := CommitBlind(nil, nil, 0, setupResult.PublicParameters, nonce)
+	assert.NoError(t, err, "Expected no error when committing to hidden attributes")
+
+	// Replace the commitment with another group element, keeping the proof
+	forged := commitment
+	forged.Commitment = new(e.G1)
+	forged.Commitment.Add(commitment.Commitment, setupResult.PublicParameters.G1)
+	_, err = IssueBlind(forged, map[int]string{1: "Alice"}, setupResult.PublicParameters, setupResult.SecretKey, nonce)
+	assert.ErrorIs(t, err, models.ErrInvalidCommitment, "Expected a forged commitment to be rejected")
+
+	// The proof is bound to the nonce of the issuance session
+	_, err = IssueBlind(commitment, map[int]string{1: "Alice"}, setupResult.PublicParameters, setupResult.SecretKey, []byte("another_nonce"))
+	assert.ErrorIs(t, err, models.ErrInvalidCommitment, "Expected a commitment for another nonce to be rejected")
+}
+
+// Test for known attributes overlapping the committed ones
+func TestIssueBlind_Overlap(t *testing.T) {
+	setupResult, err := setup.Setup(2)
+	assert.NoError(t, err, "Expected no error during setup")
+	commitment, _, err := CommitBlind(nil, nil, 0, setupResult.PublicParameters, []byte("nonce"))
+	assert.NoError(t, err, "Expected no error when committing to hidden attributes")
+
+	_, err = IssueBlind(commitment, map[int]string{0: "Alice"}, setupResult.PublicParameters, setupResult.SecretKey, []byte("nonce"))
+	assert.Error(t, err, "Expected an error when known and committed attributes overlap")
+}
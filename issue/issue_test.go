@@ -49,5 +49,5 @@ func TestIssue_InvalidAttributes(t *testing.T) {
 
     _, err := Issue(attributes, publicParams, secretKey)
 
-    assert.Error(t, err, "Expected an error for empty attributes")
+    assert.ErrorIs(t, err, models.ErrLengthMismatch, "Expected an error for empty attributes")
 }
\ No newline at end of file
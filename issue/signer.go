@@ -0,0 +1,86 @@
+package issue
+
+import (
+	"fmt"
+
+	"github.com/aniagut/msc-bbs-anonymous-credentials/models"
+	"github.com/aniagut/msc-bbs-anonymous-credentials/utils"
+	"github.com/aniagut/msc-bbs-plus-plus/sign"
+	e "github.com/cloudflare/circl/ecc/bls12381"
+)
+
+// Signer computes the secret-key operation of BBS++ issuance, so that the issuer key can be kept outside of the
+// process memory (e.g. in an HSM, a KMS or a separate keystore process).
+type Signer interface {
+	// Sign computes the signature component A ← C^{1 / (x + e)} for the commitment C and the exponent e.
+	// It returns an error if x + e = 0.
+	Sign(commitment *e.G1, exponent *e.Scalar) (*e.G1, error)
+}
+
+// KeySigner is the in-memory Signer holding the secret key.
+type KeySigner struct {
+	secretKey models.SecretKey
+}
+
+// NewKeySigner creates a Signer for a secret key held in memory.
+func NewKeySigner(secretKey models.SecretKey) *KeySigner {
+	return &KeySigner{secretKey: secretKey}
+}
+
+// Sign implements Signer.
+func (s *KeySigner) Sign(commitment *e.G1, exponent *e.Scalar) (*e.G1, error) {
+	if s.secretKey.X == nil || commitment == nil || exponent == nil {
+		return nil, fmt.Errorf("%w: missing secret key, commitment or exponent", models.ErrSigningFailed)
+	}
+	xPlusE := new(e.Scalar)
+	xPlusE.Add(s.secretKey.X, exponent)
+	defer utils.ZeroizeScalars(xPlusE)
+	if xPlusE.IsZero() == 1 {
+		return nil, fmt.Errorf("%w: x + e = 0", models.ErrSigningFailed)
+	}
+	return sign.ComputeA(s.secretKey.X, exponent, commitment), nil
+}
+
+// Zeroize zeroizes the secret key of the signer, which is shared with the key the signer was created with.
+// It must not be called concurrently with Sign.
+func (s *KeySigner) Zeroize() {
+	s.secretKey.Zeroize()
+}
+
+// IssueWithSigner generates a credential for a given list of attributes with a Signer.
+//
+// Parameters:
+//   - a: The list of attributes to be signed.
+//   - publicParams: The public parameters of the system.
+//   - signer: The signer holding the secret key.
+//
+// Returns:
+//   - Signature: The generated signature.
+//   - error: An error if the signing process fails.
+func IssueWithSigner(a []string, publicParams models.PublicParameters, signer Signer) (models.Signature, error) {
+	// Step 1: Compute the commitment C ← g1 * ∏_i h₁[i]^a[i]
+	C, err := utils.ComputeCommitment(a, publicParams.H1, publicParams.G1)
+	if err != nil {
+		return models.Signature{}, err
+	}
+
+	// Step 2: Sign the commitment
+	return signCommitment(C, signer)
+}
+
+// signCommitment selects a random e and asks the signer for A ← C^{1 / (x + e)}.
+func signCommitment(C *e.G1, signer Signer) (models.Signature, error) {
+	// Step 1: Select random e ∈ Z_p*
+	elem, err := utils.RandomScalar()
+	if err != nil {
+		return models.Signature{}, err
+	}
+
+	// Step 2: Compute A with the signer
+	A, err := signer.Sign(C, &elem)
+	if err != nil {
+		utils.Logger().Debug("error signing the commitment", "error", err)
+		return models.Signature{}, fmt.Errorf("%w: %w", models.ErrSigningFailed, err)
+	}
+	return models.Signature{A: A, E: &elem}, nil
+}
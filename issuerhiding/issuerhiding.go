@@ -0,0 +1,254 @@
+// Package issuerhiding adds issuer-hiding presentations: the holder proves possession of a credential signed by one
+// of a list of issuers accepted by the verifier, all sharing the same public parameters, without revealing which.
+//
+// The holder blinds the key X_k of its issuer as X' = X_k * g2^β and presents the credential (A, e - β), which is a
+// valid signature under X' since A^(x_k + β + e - β) = C. The presentation is thus verified against X' as usual, and
+// an OR-proof in the same transcript shows that X' / X_i = g2^β for one accepted key X_i. As X' is uniformly
+// random, the presentation does not reveal the issuer; proofs and verification grow linearly with the list.
+package issuerhiding
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"github.com/aniagut/msc-bbs-anonymous-credentials/models"
+	"github.com/aniagut/msc-bbs-anonymous-credentials/presentation"
+	"github.com/aniagut/msc-bbs-anonymous-credentials/utils"
+	"github.com/aniagut/msc-bbs-anonymous-credentials/verify"
+	e "github.com/cloudflare/circl/ecc/bls12381"
+)
+
+var (
+	// ErrIssuerNotAccepted is returned when the issuer of the credential is not in the list of accepted issuers.
+	ErrIssuerNotAccepted = errors.New("issuer not accepted")
+	// ErrInvalidProof is returned when an issuer-hiding proof is malformed.
+	ErrInvalidProof = errors.New("invalid issuer-hiding proof")
+)
+
+// Proof is an issuer-hiding presentation.
+// It contains the following elements:
+// - Proof: The proof of possession of the credential, verified against the blinded key.
+// - Key: The blinded key X' = X_k * g2^β of the issuer.
+// - Ch: The challenges of the branches of the OR-proof, one per accepted issuer, summing to the challenge of Proof.
+// - Z: The responses of the branches of the OR-proof.
+type Proof struct {
+	Proof models.SignatureProof
+	Key   *e.G2
+	Ch    []e.Scalar
+	Z     []e.Scalar
+}
+
+// Present presents attributes like presentation.PresentationWithExtensions without revealing the issuer among the
+// accepted ones.
+//
+// Parameters:
+//   - attributes: The list of attributes to be presented.
+//   - credential: The BBS+ signature representing the credential.
+//   - revealed: The list of indexes for revealed attributes.
+//   - publicParams: The public parameters shared by the accepted issuers.
+//   - nonce: A random nonce used for the proof.
+//   - issuer: The public key of the issuer of the credential.
+//   - accepted: The public keys of the issuers accepted by the verifier, in the verifier's order.
+//   - extensions: The extensions proving additional statements in the same transcript.
+//
+// Returns:
+//   - Proof: The issuer-hiding presentation.
+//   - error: ErrIssuerNotAccepted if the issuer is not in the list, or an error if the presentation fails.
+func Present(attributes []string, credential models.Signature, revealed []int, publicParams models.PublicParameters, nonce []byte, issuer models.PublicKey, accepted []models.PublicKey, extensions ...presentation.Extension) (Proof, error) {
+	// Step 1: Validate the accepted keys and find the issuer among them
+	if err := checkAccepted(publicParams, accepted); err != nil {
+		return Proof{}, err
+	}
+	if credential.E == nil || issuer.X2 == nil {
+		return Proof{}, fmt.Errorf("%w: missing credential or issuer key", models.ErrInvalidPublicKey)
+	}
+	index := -1
+	for i := range accepted {
+		if accepted[i].X2.IsEqual(issuer.X2) {
+			index = i
+			break
+		}
+	}
+	if index < 0 {
+		return Proof{}, fmt.Errorf("%w: key %s", ErrIssuerNotAccepted, issuer.KeyID())
+	}
+
+	// Step 2: Blind the key X' ← X_k * g2^β and shift the credential to (A, e - β)
+	beta, err := utils.RandomScalar()
+	if err != nil {
+		return Proof{}, err
+	}
+	blinded := new(e.G2)
+	blinded.ScalarMult(&beta, publicParams.G2)
+	blinded.Add(blinded, issuer.X2)
+	shifted := new(e.Scalar)
+	shifted.Sub(credential.E, &beta)
+	defer utils.ZeroizeScalars(&beta, shifted)
+
+	// Step 3: Present the shifted credential, proving the blinding in the same transcript before the extensions
+	prover := &keyProver{publicParams: publicParams, accepted: accepted, index: index, key: blinded, beta: beta}
+	proof, err := presentation.PresentationWithExtensions(attributes, models.Signature{A: credential.A, E: shifted}, revealed, publicParams, nonce, append([]presentation.Extension{prover}, extensions...)...)
+	utils.ZeroizeScalars(&prover.beta, &prover.a)
+	if err != nil {
+		return Proof{}, err
+	}
+	return Proof{Proof: proof, Key: blinded, Ch: prover.ch, Z: prover.z}, nil
+}
+
+// Verify checks an issuer-hiding presentation.
+//
+// Parameters:
+//   - proof: The issuer-hiding presentation.
+//   - nonce: A random nonce used for the proof.
+//   - revealedAttributes: The list of revealed attributes.
+//   - revealedIndices: The list of indices for revealed attributes.
+//   - publicParams: The public parameters shared by the accepted issuers.
+//   - accepted: The public keys of the accepted issuers, in the order given to the holder.
+//   - extensions: The verifier sides of the extensions, in the order used by the holder.
+//
+// Returns:
+//   - bool: true if the credential was signed by one of the accepted issuers and the proof is valid, false otherwise.
+//   - error: An error if the verification process fails.
+func Verify(proof Proof, nonce []byte, revealedAttributes []string, revealedIndices []int, publicParams models.PublicParameters, accepted []models.PublicKey, extensions ...verify.Extension) (bool, error) {
+	// Step 1: Validate the accepted keys and the shape of the OR-proof
+	if err := checkAccepted(publicParams, accepted); err != nil {
+		return false, err
+	}
+	if proof.Key == nil {
+		return false, fmt.Errorf("%w: missing blinded key", ErrInvalidProof)
+	}
+	if len(proof.Ch) != len(accepted) || len(proof.Z) != len(accepted) {
+		return false, fmt.Errorf("%w: %d branches, %d accepted issuers", ErrInvalidProof, len(proof.Ch), len(accepted))
+	}
+
+	// Step 2: Verify the presentation against the blinded key, with the OR-proof in the same transcript
+	check := keyCheck{publicParams: publicParams, accepted: accepted, proof: proof}
+	return verify.VerifyWithExtensions(proof.Proof, nonce, revealedAttributes, revealedIndices, publicParams, models.PublicKey{X2: proof.Key}, append([]verify.Extension{check}, extensions...)...)
+}
+
+// keyProver proves that the blinded key blinds one of the accepted keys.
+type keyProver struct {
+	publicParams models.PublicParameters
+	accepted     []models.PublicKey
+	index        int
+	key          *e.G2
+	beta, a      e.Scalar
+	ch, z        []e.Scalar
+}
+
+// Commit implements presentation.Extension.
+// Every branch but the one of the issuer is simulated: A[i] ← g2^a for i = k, and A[i] ← g2^z[i] * (X' / X_i)^(-ch[i])
+// for random ch[i], z[i] otherwise.
+func (p *keyProver) Commit(presentation.Witness) ([]byte, error) {
+	var err error
+	p.ch = make([]e.Scalar, len(p.accepted))
+	p.z = make([]e.Scalar, len(p.accepted))
+	A := make([]*e.G2, len(p.accepted))
+	if p.a, err = utils.RandomScalar(); err != nil {
+		return nil, err
+	}
+	for i := range A {
+		if i == p.index {
+			A[i] = new(e.G2)
+			A[i].ScalarMult(&p.a, p.publicParams.G2)
+			continue
+		}
+		if p.ch[i], err = utils.RandomScalar(); err != nil {
+			return nil, err
+		}
+		if p.z[i], err = utils.RandomScalar(); err != nil {
+			return nil, err
+		}
+		A[i] = branchCommitment(p.publicParams.G2, p.key, p.accepted[i].X2, &p.ch[i], &p.z[i])
+	}
+	return transcript(p.accepted, p.key, A), nil
+}
+
+// Respond implements presentation.Extension.
+// It completes the branch of the issuer with ch[k] ← ch - Σ_{i≠k} ch[i] and z[k] ← a + ch[k] * β.
+func (p *keyProver) Respond(ch *e.Scalar) error {
+	if len(p.ch) != len(p.accepted) {
+		return fmt.Errorf("%w: prover not committed", ErrInvalidProof)
+	}
+	chK := &p.ch[p.index]
+	chK.Set(ch)
+	for i := range p.ch {
+		if i != p.index {
+			chK.Sub(chK, &p.ch[i])
+		}
+	}
+	zK := &p.z[p.index]
+	zK.Mul(chK, &p.beta)
+	zK.Add(zK, &p.a)
+	return nil
+}
+
+// keyCheck is the verifier side of the OR-proof on the blinded key.
+type keyCheck struct {
+	publicParams models.PublicParameters
+	accepted     []models.PublicKey
+	proof        Proof
+}
+
+// Commit implements verify.Extension.
+// It checks that the branch challenges sum to the challenge and recomputes A[i] ← g2^z[i] * (X' / X_i)^(-ch[i]).
+func (c keyCheck) Commit(responses verify.Responses) ([]byte, error) {
+	sum := new(e.Scalar)
+	A := make([]*e.G2, len(c.accepted))
+	for i := range A {
+		sum.Add(sum, &c.proof.Ch[i])
+		A[i] = branchCommitment(c.publicParams.G2, c.proof.Key, c.accepted[i].X2, &c.proof.Ch[i], &c.proof.Z[i])
+	}
+	if sum.IsEqual(responses.Ch) != 1 {
+		return nil, fmt.Errorf("%w: branch challenges do not sum to the challenge", models.ErrChallengeMismatch)
+	}
+	return transcript(c.accepted, c.proof.Key, A), nil
+}
+
+// branchCommitment computes g2^z * (X' / X_i)^(-ch).
+func branchCommitment(g2, key, issuerKey *e.G2, ch, z *e.Scalar) *e.G2 {
+	// Step 1: Compute (X' / X_i)^(-ch) = (X_i / X')^ch
+	quotient := new(e.G2)
+	*quotient = *key
+	quotient.Neg()
+	quotient.Add(quotient, issuerKey)
+	term := new(e.G2)
+	term.ScalarMult(ch, quotient)
+
+	// Step 2: Multiply by g2^z
+	result := new(e.G2)
+	result.ScalarMult(z, g2)
+	result.Add(result, term)
+	return result
+}
+
+// checkAccepted checks that the list of accepted keys is not empty and holds valid elements of G2.
+func checkAccepted(publicParams models.PublicParameters, accepted []models.PublicKey) error {
+	if publicParams.G2 == nil {
+		return fmt.Errorf("%w: missing G2", models.ErrInvalidPublicKey)
+	}
+	if len(accepted) == 0 {
+		return fmt.Errorf("%w: no accepted issuer", ErrIssuerNotAccepted)
+	}
+	for i, key := range accepted {
+		if key.X2 == nil || key.X2.IsIdentity() || !key.X2.IsOnG2() {
+			return fmt.Errorf("%w: accepted key %d is not a valid element of G2", models.ErrInvalidPublicKey, i)
+		}
+	}
+	return nil
+}
+
+// transcript serializes the accepted keys, the blinded key and the commitments of the OR-proof.
+func transcript(accepted []models.PublicKey, key *e.G2, A []*e.G2) []byte {
+	out := []byte("issuer-hiding")
+	out = binary.BigEndian.AppendUint64(out, uint64(len(accepted)))
+	for _, issuerKey := range accepted {
+		out = append(out, issuerKey.X2.BytesCompressed()...)
+	}
+	out = append(out, key.BytesCompressed()...)
+	for _, commitment := range A {
+		out = append(out, commitment.BytesCompressed()...)
+	}
+	return out
+}
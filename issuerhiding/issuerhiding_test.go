@@ -0,0 +1,97 @@
+package issuerhiding
+
+import (
+	"testing"
+
+	"github.com/aniagut/msc-bbs-anonymous-credentials/issue"
+	"github.com/aniagut/msc-bbs-anonymous-credentials/models"
+	"github.com/aniagut/msc-bbs-anonymous-credentials/setup"
+	e "github.com/cloudflare/circl/ecc/bls12381"
+	"github.com/stretchr/testify/assert"
+)
+
+// MockIssuers sets up n issuers sharing the same public parameters
+func MockIssuers(t *testing.T, n int) []models.SetupResult {
+	result, err := setup.Setup(3)
+	assert.NoError(t, err, "Expected no error during setup")
+	issuers := []models.SetupResult{result}
+	for len(issuers) < n {
+		other, err := setup.KeyGen(result.PublicParameters)
+		assert.NoError(t, err, "Expected no error generating an issuer key")
+		issuers = append(issuers, other)
+	}
+	return issuers
+}
+
+// publicKeys returns the public keys of the issuers
+func publicKeys(issuers []models.SetupResult) []models.PublicKey {
+	keys := make([]models.PublicKey, len(issuers))
+	for i, issuer := range issuers {
+		keys[i] = issuer.PublicKey
+	}
+	return keys
+}
+
+// Test for presenting credentials of every accepted issuer without revealing it
+func TestIssuerHiding_Flow(t *testing.T) {
+	issuers := MockIssuers(t, 4)
+	accepted := publicKeys(issuers)
+	publicParams := issuers[0].PublicParameters
+	attributes := []string{"alice", "MSc", "2026"}
+	nonce := []byte("nonce")
+
+	for i, issuer := range issuers {
+		signature, err := issue.Issue(attributes, publicParams, issuer.SecretKey)
+		assert.NoError(t, err, "Expected no error during issuance")
+		proof, err := Present(attributes, signature, []int{1}, publicParams, nonce, issuer.PublicKey, accepted)
+		assert.NoError(t, err, "Expected no error presenting the credential of issuer %d", i)
+		for _, key := range accepted {
+			assert.False(t, proof.Key.IsEqual(key.X2), "Expected the key to be blinded")
+		}
+
+		valid, err := Verify(proof, nonce, []string{"MSc"}, []int{1}, publicParams, accepted)
+		assert.NoError(t, err, "Expected no error verifying the presentation of issuer %d", i)
+		assert.True(t, valid, "Expected the presentation of issuer %d to be valid", i)
+	}
+}
+
+// Test for rejecting credentials of issuers outside the accepted list
+func TestIssuerHiding_Rejections(t *testing.T) {
+	issuers := MockIssuers(t, 3)
+	accepted := publicKeys(issuers[:2])
+	publicParams := issuers[0].PublicParameters
+	attributes := []string{"bob", "BSc", "2025"}
+	nonce := []byte("nonce")
+	verifyProof := func(proof Proof, nonce []byte, accepted []models.PublicKey) error {
+		_, err := Verify(proof, nonce, []string{"BSc"}, []int{1}, publicParams, accepted)
+		return err
+	}
+
+	// The holder cannot present the credential of an issuer outside the list
+	outsider, err := issue.Issue(attributes, publicParams, issuers[2].SecretKey)
+	assert.NoError(t, err, "Expected no error during issuance")
+	_, err = Present(attributes, outsider, []int{1}, publicParams, nonce, issuers[2].PublicKey, accepted)
+	assert.ErrorIs(t, err, ErrIssuerNotAccepted, "Expected an error for an issuer outside the list")
+
+	// Claiming an accepted issuer for the credential of the outsider fails the pairing check
+	proof, err := Present(attributes, outsider, []int{1}, publicParams, nonce, issuers[0].PublicKey, accepted)
+	assert.NoError(t, err, "Expected the presentation to be computed")
+	assert.ErrorIs(t, verifyProof(proof, nonce, accepted), models.ErrPairingFailed, "Expected a credential of the outsider to be rejected")
+
+	// A valid proof is bound to the accepted list and its branch challenges to the challenge
+	signature, err := issue.Issue(attributes, publicParams, issuers[1].SecretKey)
+	assert.NoError(t, err, "Expected no error during issuance")
+	proof, err = Present(attributes, signature, []int{1}, publicParams, nonce, issuers[1].PublicKey, accepted)
+	assert.NoError(t, err, "Expected no error presenting the credential")
+	reordered := []models.PublicKey{accepted[1], accepted[0]}
+	assert.ErrorIs(t, verifyProof(proof, nonce, reordered), models.ErrChallengeMismatch, "Expected another list to be rejected")
+	assert.ErrorIs(t, verifyProof(proof, nonce, publicKeys(issuers)), ErrInvalidProof, "Expected a longer list to be rejected")
+	assert.ErrorIs(t, verifyProof(proof, []byte("other"), accepted), models.ErrChallengeMismatch, "Expected another nonce to be rejected")
+	shifted := proof
+	shifted.Ch = append([]e.Scalar(nil), proof.Ch...)
+	shifted.Ch[0].SetUint64(7)
+	assert.ErrorIs(t, verifyProof(shifted, nonce, accepted), models.ErrChallengeMismatch, "Expected modified branch challenges to be rejected")
+	_, err = Verify(proof, nonce, []string{"BSc"}, []int{1}, publicParams, nil)
+	assert.ErrorIs(t, err, ErrIssuerNotAccepted, "Expected an empty list to be rejected")
+	assert.NoError(t, verifyProof(proof, nonce, accepted), "Expected the untouched proof to be accepted")
+}
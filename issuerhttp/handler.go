@@ -0,0 +1,300 @@
+// Package issuerhttp exposes credential issuance over HTTP.
+//
+// The handler serves the following endpoints:
+//
+//	GET  /health      - liveness of the issuer.
+//	GET  /parameters  - public parameters, public key, key ID and attribute schema of the issuer.
+//	GET  /public-key  - public key and key ID of the issuer.
+//	GET  /nonce       - issues a single-use nonce for blind issuance, if enabled.
+//	POST /credentials - issues a credential over the requested attributes.
+package issuerhttp
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/aniagut/msc-bbs-anonymous-credentials/internal/httpjson"
+	"github.com/aniagut/msc-bbs-anonymous-credentials/issue"
+	"github.com/aniagut/msc-bbs-anonymous-credentials/models"
+	"github.com/aniagut/msc-bbs-anonymous-credentials/nonce"
+)
+
+// maxRequestSize bounds the size of credential request bodies.
+const maxRequestSize = 1 << 20
+
+// ErrForbidden can be returned (or wrapped) by an Authorizer to deny a request.
+var ErrForbidden = errors.New("forbidden")
+
+// CredentialRequest is the body of POST /credentials.
+// It contains the following elements:
+// - Attributes: The values of the attributes known to the issuer, by name.
+// - Blind: The optional commitment to the remaining attributes, hidden from the issuer.
+// - Nonce: The nonce obtained from GET /nonce that the commitment is bound to, required with Blind.
+type CredentialRequest struct {
+	Attributes map[string]string       `json:"attributes"`
+	Blind      *models.BlindCommitment `json:"blind,omitempty"`
+	Nonce      []byte                  `json:"nonce,omitempty"`
+}
+
+// CredentialResponse is the body of a successful POST /credentials.
+// It contains the following elements:
+// - Signature: The issued signature.
+// - IssuerKeyID: The identifier of the public key of the issuer.
+// - Schema: The names of the attributes in signing order.
+type CredentialResponse struct {
+	Signature   models.Signature `json:"signature"`
+	IssuerKeyID string           `json:"issuerKeyId"`
+	Schema      []string         `json:"schema"`
+}
+
+// ParametersResponse is the body of GET /parameters.
+type ParametersResponse struct {
+	PublicParameters models.PublicParameters `json:"publicParameters"`
+	PublicKey        models.PublicKey        `json:"publicKey"`
+	KeyID            string                  `json:"keyId"`
+	Schema           []string                `json:"schema"`
+}
+
+// NonceResponse is the body of GET /nonce.
+// It contains the following elements:
+// - Nonce: The single-use nonce to bind the blind commitment to.
+// - Expiry: The time after which the nonce is refused, if the nonces expire.
+type NonceResponse struct {
+	Nonce  []byte    `json:"nonce"`
+	Expiry time.Time `json:"expiry,omitempty"`
+}
+
+// PublicKeyResponse is the body of GET /public-key.
+type PublicKeyResponse struct {
+	PublicKey models.PublicKey `json:"publicKey"`
+	KeyID     string           `json:"keyId"`
+}
+
+// Authorizer decides whether the subject of the HTTP request may receive a credential over the requested attributes.
+// Blinded attributes are not visible to the authorizer. Returning an error denies the request with 403 Forbidden.
+type Authorizer func(r *http.Request, req CredentialRequest) error
+
+// Config holds the configuration of the issuer handler.
+// It contains the following elements:
+// - PublicParameters: The public parameters of the issuer.
+// - PublicKey: The public key of the issuer.
+// - SecretKey: The secret key of the issuer, used when Signer is nil.
+// - Signer: The signer holding the secret key outside of the process memory, e.g. a remotesigner.Client.
+// - Schema: The names of the attributes in signing order.
+// - Authorize: The authorization hook. It is required; use AllowAll to issue to anyone.
+// - Nonces: The manager issuing and consuming the nonces of blind issuance. Blind requests are refused without it.
+type Config struct {
+	PublicParameters models.PublicParameters
+	PublicKey        models.PublicKey
+	SecretKey        models.SecretKey
+	Signer           issue.Signer
+	Schema           []string
+	Authorize        Authorizer
+	Nonces           *nonce.Manager
+}
+
+// AllowAll is an Authorizer accepting every request.
+func AllowAll(*http.Request, CredentialRequest) error {
+	return nil
+}
+
+// Handler serves the issuer endpoints.
+type Handler struct {
+	config Config
+	signer issue.Signer
+	keyID  string
+	mux    *http.ServeMux
+}
+
+// NewHandler creates an issuer handler.
+//
+// Parameters:
+//   - config: The configuration of the issuer.
+//
+// Returns:
+//   - *Handler: The issuer handler, to be mounted on a server or under a prefix with http.StripPrefix.
+//   - error: An error if the configuration is incomplete.
+func NewHandler(config Config) (*Handler, error) {
+	if config.Authorize == nil {
+		return nil, errors.New("an authorizer is required")
+	}
+	if config.PublicKey.X2 == nil || (config.SecretKey.X == nil && config.Signer == nil) {
+		return nil, errors.New("the issuer keys are required")
+	}
+	if len(config.Schema) != len(config.PublicParameters.H1) {
+		return nil, fmt.Errorf("%w: %d attribute names, %d generators", models.ErrLengthMismatch, len(config.Schema), len(config.PublicParameters.H1))
+	}
+
+	h := &Handler{
+		config: config,
+		signer: config.Signer,
+		keyID:  config.PublicKey.KeyID(),
+		mux:    http.NewServeMux(),
+	}
+	if h.signer == nil {
+		h.signer = issue.NewKeySigner(config.SecretKey)
+	}
+	h.mux.HandleFunc("GET /health", h.health)
+	h.mux.HandleFunc("GET /parameters", h.parameters)
+	h.mux.HandleFunc("GET /public-key", h.publicKey)
+	if config.Nonces != nil {
+		h.mux.HandleFunc("GET /nonce", h.nonce)
+	}
+	h.mux.HandleFunc("POST /credentials", h.credentials)
+	return h, nil
+}
+
+// ServeHTTP implements http.Handler.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.mux.ServeHTTP(w, r)
+}
+
+func (h *Handler) health(w http.ResponseWriter, r *http.Request) {
+	httpjson.WriteJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+func (h *Handler) parameters(w http.ResponseWriter, r *http.Request) {
+	httpjson.WriteJSON(w, http.StatusOK, ParametersResponse{
+		PublicParameters: h.config.PublicParameters,
+		PublicKey:        h.config.PublicKey,
+		KeyID:            h.keyID,
+		Schema:           h.config.Schema,
+	})
+}
+
+func (h *Handler) publicKey(w http.ResponseWriter, r *http.Request) {
+	httpjson.WriteJSON(w, http.StatusOK, PublicKeyResponse{PublicKey: h.config.PublicKey, KeyID: h.keyID})
+}
+
+func (h *Handler) nonce(w http.ResponseWriter, r *http.Request) {
+	n, err := h.config.Nonces.Issue()
+	if err != nil {
+		httpjson.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+	res := NonceResponse{Nonce: n}
+	if ttl := h.config.Nonces.TTL(); ttl > 0 {
+		res.Expiry = time.Now().Add(ttl).UTC()
+	}
+	httpjson.WriteJSON(w, http.StatusOK, res)
+}
+
+func (h *Handler) credentials(w http.ResponseWriter, r *http.Request) {
+	// Step 1: Decode the request
+	var req CredentialRequest
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRequestSize))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&req); err != nil {
+		httpjson.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid credential request: %w", err))
+		return
+	}
+
+	// Step 2: Map the attribute names to indices
+	known, err := h.knownAttributes(req)
+	if err != nil {
+		httpjson.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	// Step 3: Ask the authorization hook
+	if err := h.config.Authorize(r, req); err != nil {
+		httpjson.WriteError(w, http.StatusForbidden, err)
+		return
+	}
+
+	// Step 4: Consume the nonce of a blind request, so that a commitment is accepted only once
+	if req.Blind != nil {
+		if status, err := h.consumeNonce(req.Nonce); err != nil {
+			httpjson.WriteError(w, status, err)
+			return
+		}
+	}
+
+	// Step 5: Issue the credential, blindly if the holder committed to some attributes
+	var signature models.Signature
+	if req.Blind != nil {
+		signature, err = issue.IssueBlindWithSigner(*req.Blind, known, h.config.PublicParameters, h.signer, req.Nonce)
+	} else {
+		attributes := make([]string, len(h.config.Schema))
+		for index, value := range known {
+			attributes[index] = value
+		}
+		signature, err = issue.IssueWithSigner(attributes, h.config.PublicParameters, h.signer)
+	}
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, models.ErrInvalidCommitment) || errors.Is(err, models.ErrIndexOutOfRange) ||
+			errors.Is(err, models.ErrUnsortedIndices) || errors.Is(err, models.ErrLengthMismatch) {
+			status = http.StatusBadRequest
+		}
+		httpjson.WriteError(w, status, err)
+		return
+	}
+
+	httpjson.WriteJSON(w, http.StatusOK, CredentialResponse{
+		Signature:   signature,
+		IssuerKeyID: h.keyID,
+		Schema:      h.config.Schema,
+	})
+}
+
+// consumeNonce consumes the nonce of a blind request and returns the status to answer with if it is refused.
+func (h *Handler) consumeNonce(n []byte) (int, error) {
+	if h.config.Nonces == nil {
+		return http.StatusBadRequest, errors.New("blind issuance is not enabled")
+	}
+	if len(n) == 0 {
+		return http.StatusBadRequest, errors.New("invalid credential request: missing nonce")
+	}
+	if err := h.config.Nonces.Consume(n); err != nil {
+		if errors.Is(err, nonce.ErrStoreFull) {
+			return http.StatusServiceUnavailable, err
+		}
+		return http.StatusBadRequest, err
+	}
+	return http.StatusOK, nil
+}
+
+// knownAttributes maps the named attributes of the request to their indices and checks that, together with
+// the committed attributes, they cover the schema exactly once.
+func (h *Handler) knownAttributes(req CredentialRequest) (map[int]string, error) {
+	positions := make(map[string]int, len(h.config.Schema))
+	for i, name := range h.config.Schema {
+		positions[name] = i
+	}
+
+	known := make(map[int]string, len(req.Attributes))
+	for name, value := range req.Attributes {
+		index, ok := positions[name]
+		if !ok {
+			return nil, fmt.Errorf("attribute %q is not part of the schema", name)
+		}
+		known[index] = value
+	}
+
+	covered := make(map[int]bool, len(h.config.Schema))
+	for index := range known {
+		covered[index] = true
+	}
+	if req.Blind != nil {
+		for _, index := range req.Blind.Indices {
+			if index < 0 || index >= len(h.config.Schema) || covered[index] {
+				return nil, fmt.Errorf("committed attribute %d is out of range or also provided", index)
+			}
+			covered[index] = true
+		}
+	}
+	missing := make([]string, 0)
+	for i, name := range h.config.Schema {
+		if !covered[i] {
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("attributes %v are neither provided nor committed", missing)
+	}
+	return known, nil
+}
@@ -0,0 +1,245 @@
+package issuerhttp
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/aniagut/msc-bbs-anonymous-credentials/internal/httpjson"
+	"github.com/aniagut/msc-bbs-anonymous-credentials/issue"
+	"github.com/aniagut/msc-bbs-anonymous-credentials/models"
+	"github.com/aniagut/msc-bbs-anonymous-credentials/nonce"
+	"github.com/aniagut/msc-bbs-anonymous-credentials/setup"
+	"github.com/aniagut/msc-bbs-anonymous-credentials/utils"
+	e "github.com/cloudflare/circl/ecc/bls12381"
+	"github.com/stretchr/testify/assert"
+)
+
+// MockServer starts an issuer server for testing
+func MockServer(t *testing.T, authorize Authorizer) (*httptest.Server, models.SetupResult) {
+	setupResult, err := setup.Setup(3)
+	assert.NoError(t, err, "Expected no error during setup")
+	handler, err := NewHandler(Config{
+		PublicParameters: setupResult.PublicParameters,
+		PublicKey:        setupResult.PublicKey,
+		SecretKey:        setupResult.SecretKey,
+		Schema:           []string{"secret", "name", "role"},
+		Authorize:        authorize,
+		Nonces:           nonce.NewManager(nonce.NewMemoryStore(), time.Minute),
+	})
+	assert.NoError(t, err, "Expected no error when creating the handler")
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	return server, setupResult
+}
+
+// postCredential sends a credential request and decodes the response
+func postCredential(t *testing.T, server *httptest.Server, req CredentialRequest, header string) (*http.Response, CredentialResponse) {
+	body, err := json.Marshal(req)
+	assert.NoError(t, err, "Expected no error encoding the request")
+	httpReq, err := http.NewRequest(http.MethodPost, server.URL+"/credentials", bytes.NewReader(body))
+	assert.NoError(t, err, "Expected no error creating the request")
+	httpReq.Header.Set("X-Subject", header)
+	resp, err := http.DefaultClient.Do(httpReq)
+	assert.NoError(t, err, "Expected no error sending the request")
+	defer resp.Body.Close()
+
+	var out CredentialResponse
+	if resp.StatusCode == http.StatusOK {
+		assert.NoError(t, json.NewDecoder(resp.Body).Decode(&out), "Expected no error decoding the response")
+	}
+	return resp, out
+}
+
+// getNonce fetches a nonce for blind issuance
+func getNonce(t *testing.T, server *httptest.Server) []byte {
+	resp, err := http.Get(server.URL + "/nonce")
+	assert.NoError(t, err, "Expected no error fetching a nonce")
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode, "Expected a nonce to be issued")
+	var out NonceResponse
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&out), "Expected no error decoding the nonce")
+	assert.False(t, out.Expiry.IsZero(), "Expected the nonce to expire")
+	return out.Nonce
+}
+
+// Test for the public endpoints
+func TestHandler_PublicEndpoints(t *testing.T) {
+	server, setupResult := MockServer(t, AllowAll)
+
+	resp, err := http.Get(server.URL + "/health")
+	assert.NoError(t, err, "Expected no error calling health")
+	assert.Equal(t, http.StatusOK, resp.StatusCode, "Expected the issuer to be healthy")
+	resp.Body.Close()
+
+	resp, err = http.Get(server.URL + "/parameters")
+	assert.NoError(t, err, "Expected no error fetching the parameters")
+	var params ParametersResponse
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&params), "Expected no error decoding the parameters")
+	resp.Body.Close()
+	assert.Equal(t, setupResult.PublicKey.KeyID(), params.KeyID, "Expected the key ID of the issuer")
+	assert.Len(t, params.PublicParameters.H1, 3, "Expected the generators of the issuer")
+
+	resp, err = http.Post(server.URL+"/health", "application/json", nil)
+	assert.NoError(t, err, "Expected no error calling health")
+	assert.Equal(t, http.StatusMethodNotAllowed, resp.StatusCode, "Expected POST /health to be rejected")
+	resp.Body.Close()
+}
+
+// Test for issuing a credential over plain attributes
+func TestHandler_Issue(t *testing.T) {
+	server, setupResult := MockServer(t, AllowAll)
+	req := CredentialRequest{Attributes: map[string]string{"secret": "s3cr3t", "name": "Alice", "role": "admin"}}
+
+	resp, out := postCredential(t, server, req, "alice")
+	assert.Equal(t, http.StatusOK, resp.StatusCode, "Expected the credential to be issued")
+
+	valid, err := issue.VerifyCredential([]string{"s3cr3t", "Alice", "admin"}, out.Signature, setupResult.PublicParameters, setupResult.PublicKey)
+	assert.NoError(t, err, "Expected no error verifying the credential")
+	assert.True(t, valid, "Expected the issued credential to be valid")
+}
+
+// Test for issuing a credential over a blind commitment
+func TestHandler_IssueBlind(t *testing.T) {
+	server, setupResult := MockServer(t, AllowAll)
+	n := getNonce(t, server)
+	commitment, secret, err := issue.CommitBlind(nil, nil, 0, setupResult.PublicParameters, n)
+	assert.NoError(t, err, "Expected no error committing to the secret")
+	req := CredentialRequest{Attributes: map[string]string{"name": "Alice", "role": "admin"}, Blind: &commitment, Nonce: n}
+
+	resp, out := postCredential(t, server, req, "alice")
+	assert.Equal(t, http.StatusOK, resp.StatusCode, "Expected the credential to be issued")
+
+	valid, err := issue.VerifyCredential([]string{secret, "Alice", "admin"}, out.Signature, setupResult.PublicParameters, setupResult.PublicKey)
+	assert.NoError(t, err, "Expected no error verifying the credential")
+	assert.True(t, valid, "Expected the blindly issued credential to be valid")
+
+	// The nonce is single-use
+	resp, _ = postCredential(t, server, req, "alice")
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode, "Expected a replayed commitment to be rejected")
+}
+
+// Test for blind requests without a valid nonce
+func TestHandler_IssueBlindNonce(t *testing.T) {
+	server, setupResult := MockServer(t, AllowAll)
+	n := getNonce(t, server)
+	commitment, _, err := issue.CommitBlind(nil, nil, 0, setupResult.PublicParameters, []byte("chosen by the holder"))
+	assert.NoError(t, err, "Expected no error committing to the secret")
+	attributes := map[string]string{"name": "Alice", "role": "admin"}
+
+	resp, _ := postCredential(t, server, CredentialRequest{Attributes: attributes, Blind: &commitment}, "alice")
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode, "Expected a commitment without a nonce to be rejected")
+	resp, _ = postCredential(t, server, CredentialRequest{Attributes: attributes, Blind: &commitment, Nonce: []byte("chosen by the holder")}, "alice")
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode, "Expected a nonce not issued by the issuer to be rejected")
+	resp, _ = postCredential(t, server, CredentialRequest{Attributes: attributes, Blind: &commitment, Nonce: n}, "alice")
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode, "Expected a commitment bound to another nonce to be rejected")
+}
+
+// countingSigner records how many commitments it signed
+type countingSigner struct {
+	issue.Signer
+	calls int
+}
+
+// Sign implements issue.Signer.
+func (s *countingSigner) Sign(commitment *e.G1, exponent *e.Scalar) (*e.G1, error) {
+	s.calls++
+	return s.Signer.Sign(commitment, exponent)
+}
+
+// Test for issuing with a signer instead of a secret key
+func TestHandler_IssueWithSigner(t *testing.T) {
+	setupResult, err := setup.Setup(3)
+	assert.NoError(t, err, "Expected no error during setup")
+	signer := &countingSigner{Signer: issue.NewKeySigner(setupResult.SecretKey)}
+	handler, err := NewHandler(Config{
+		PublicParameters: setupResult.PublicParameters,
+		PublicKey:        setupResult.PublicKey,
+		Signer:           signer,
+		Schema:           []string{"secret", "name", "role"},
+		Authorize:        AllowAll,
+	})
+	assert.NoError(t, err, "Expected no error creating the handler without a secret key")
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	req := CredentialRequest{Attributes: map[string]string{"secret": "s3cr3t", "name": "Alice", "role": "admin"}}
+	resp, out := postCredential(t, server, req, "alice")
+	assert.Equal(t, http.StatusOK, resp.StatusCode, "Expected the credential to be issued")
+	assert.Equal(t, 1, signer.calls, "Expected the signer to compute the signature")
+	valid, _ := issue.VerifyCredential([]string{"s3cr3t", "Alice", "admin"}, out.Signature, setupResult.PublicParameters, setupResult.PublicKey)
+	assert.True(t, valid, "Expected the issued credential to be valid")
+}
+
+// failingSigner fails like an unreachable keystore process
+type failingSigner struct{}
+
+// Sign implements issue.Signer.
+func (failingSigner) Sign(*e.G1, *e.Scalar) (*e.G1, error) {
+	return nil, errors.New("dial unix /run/keystore/signer.sock: connection refused")
+}
+
+// Test for server errors being logged and not sent to the client
+func TestHandler_SignerFailure(t *testing.T) {
+	setupResult, err := setup.Setup(3)
+	assert.NoError(t, err, "Expected no error during setup")
+	handler, err := NewHandler(Config{
+		PublicParameters: setupResult.PublicParameters,
+		PublicKey:        setupResult.PublicKey,
+		Signer:           failingSigner{},
+		Schema:           []string{"secret", "name", "role"},
+		Authorize:        AllowAll,
+	})
+	assert.NoError(t, err, "Expected no error creating the handler")
+	server := httptest.NewServer(handler)
+	defer server.Close()
+	var logs bytes.Buffer
+	utils.SetLogger(slog.New(slog.NewTextHandler(&logs, nil)))
+	defer utils.SetLogger(nil)
+
+	body, _ := json.Marshal(CredentialRequest{Attributes: map[string]string{"secret": "s3cr3t", "name": "Alice", "role": "admin"}})
+	resp, err := http.Post(server.URL+"/credentials", "application/json", bytes.NewReader(body))
+	assert.NoError(t, err, "Expected no error sending the request")
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusInternalServerError, resp.StatusCode, "Expected the signer failure to be a server error")
+	var out httpjson.ErrorResponse
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&out), "Expected no error decoding the error")
+	assert.Equal(t, http.StatusText(http.StatusInternalServerError), out.Error, "Expected a fixed message")
+	assert.Contains(t, logs.String(), "signer.sock", "Expected the error to be logged")
+}
+
+// Test for the authorization hook and invalid requests
+func TestHandler_Rejections(t *testing.T) {
+	onlyAlice := func(r *http.Request, req CredentialRequest) error {
+		if r.Header.Get("X-Subject") != "alice" {
+			return fmt.Errorf("%w: subject may not receive these attributes", ErrForbidden)
+		}
+		return nil
+	}
+	server, _ := MockServer(t, onlyAlice)
+	req := CredentialRequest{Attributes: map[string]string{"secret": "s3cr3t", "name": "Bob", "role": "admin"}}
+
+	resp, _ := postCredential(t, server, req, "bob")
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode, "Expected the authorizer to deny the request")
+
+	incomplete := CredentialRequest{Attributes: map[string]string{"name": "Alice"}}
+	resp, _ = postCredential(t, server, incomplete, "alice")
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode, "Expected missing attributes to be rejected")
+
+	unknown := CredentialRequest{Attributes: map[string]string{"secret": "s", "name": "Alice", "role": "user", "age": "30"}}
+	resp, _ = postCredential(t, server, unknown, "alice")
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode, "Expected unknown attributes to be rejected")
+}
+
+// Test for an incomplete configuration
+func TestNewHandler_InvalidConfig(t *testing.T) {
+	_, err := NewHandler(Config{})
+
+	assert.Error(t, err, "Expected an error without an authorizer")
+}
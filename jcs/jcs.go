@@ -0,0 +1,265 @@
+// Package jcs implements the JSON Canonicalization Scheme (RFC 8785).
+package jcs
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// Transform canonicalizes a JSON text.
+//
+// Parameters:
+//   - data: The JSON text.
+//
+// Returns:
+//   - []byte: The canonical form of the JSON text.
+//   - error: An error if the text is not valid JSON or contains numbers that are not representable as IEEE 754 doubles.
+func Transform(data []byte) ([]byte, error) {
+	value, err := Parse(data)
+	if err != nil {
+		return nil, err
+	}
+	return Marshal(value)
+}
+
+// Parse decodes a JSON text into generic values, keeping numbers as json.Number.
+// As RFC 8785 requires I-JSON input, objects with duplicate member names are rejected, so that signers and verifiers
+// cannot disagree on which of the values is canonicalized.
+func Parse(data []byte) (interface{}, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	value, err := parseValue(decoder)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := decoder.Token(); err != io.EOF {
+		return nil, errors.New("jcs: trailing data after JSON value")
+	}
+	return value, nil
+}
+
+// parseValue decodes the next JSON value from the token stream of the decoder.
+func parseValue(decoder *json.Decoder) (interface{}, error) {
+	token, err := decoder.Token()
+	if err != nil {
+		if err == io.EOF {
+			return nil, io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+	switch token {
+	case json.Delim('{'):
+		object := make(map[string]interface{})
+		for decoder.More() {
+			key, err := decoder.Token()
+			if err != nil {
+				return nil, err
+			}
+			name, ok := key.(string)
+			if !ok {
+				return nil, fmt.Errorf("jcs: invalid member name %v", key)
+			}
+			if _, ok := object[name]; ok {
+				return nil, fmt.Errorf("jcs: duplicate member name %q", name)
+			}
+			if object[name], err = parseValue(decoder); err != nil {
+				return nil, err
+			}
+		}
+		if _, err := decoder.Token(); err != nil {
+			return nil, err
+		}
+		return object, nil
+	case json.Delim('['):
+		array := make([]interface{}, 0)
+		for decoder.More() {
+			element, err := parseValue(decoder)
+			if err != nil {
+				return nil, err
+			}
+			array = append(array, element)
+		}
+		if _, err := decoder.Token(); err != nil {
+			return nil, err
+		}
+		return array, nil
+	default:
+		return token, nil
+	}
+}
+
+// Marshal serializes a generic JSON value (as produced by Parse or encoding/json) in canonical form.
+func Marshal(value interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := write(&buf, value); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// write appends the canonical form of the value to the buffer.
+func write(buf *bytes.Buffer, value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		buf.WriteString("null")
+	case bool:
+		buf.WriteString(strconv.FormatBool(v))
+	case string:
+		writeString(buf, v)
+	case json.Number:
+		f, err := strconv.ParseFloat(string(v), 64)
+		if err != nil {
+			return fmt.Errorf("jcs: invalid number %s", v)
+		}
+		return writeNumber(buf, f)
+	case float64:
+		return writeNumber(buf, v)
+	case []interface{}:
+		buf.WriteByte('[')
+		for i, element := range v {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			if err := write(buf, element); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte(']')
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		// Properties are sorted by their UTF-16 code units
+		sort.Slice(keys, func(i, j int) bool { return lessUTF16(keys[i], keys[j]) })
+		buf.WriteByte('{')
+		for i, key := range keys {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			writeString(buf, key)
+			buf.WriteByte(':')
+			if err := write(buf, v[key]); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte('}')
+	default:
+		return fmt.Errorf("jcs: unsupported type %T", value)
+	}
+	return nil
+}
+
+// writeString appends a string escaped as by ECMAScript JSON.stringify.
+func writeString(buf *bytes.Buffer, s string) {
+	buf.WriteByte('"')
+	for _, r := range s {
+		switch r {
+		case '"':
+			buf.WriteString(`\"`)
+		case '\\':
+			buf.WriteString(`\\`)
+		case '\b':
+			buf.WriteString(`\b`)
+		case '\f':
+			buf.WriteString(`\f`)
+		case '\n':
+			buf.WriteString(`\n`)
+		case '\r':
+			buf.WriteString(`\r`)
+		case '\t':
+			buf.WriteString(`\t`)
+		default:
+			if r < 0x20 {
+				fmt.Fprintf(buf, `\u%04x`, r)
+			} else {
+				buf.WriteRune(r)
+			}
+		}
+	}
+	buf.WriteByte('"')
+}
+
+// writeNumber appends a number serialized as by ECMAScript Number.prototype.toString.
+func writeNumber(buf *bytes.Buffer, f float64) error {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return errors.New("jcs: NaN and Infinity are not valid JSON numbers")
+	}
+	if f == 0 {
+		buf.WriteByte('0')
+		return nil
+	}
+	if f < 0 {
+		buf.WriteByte('-')
+		f = -f
+	}
+
+	// Shortest round-trip digits d1...dk with value 0.d1...dk × 10^n
+	formatted := strconv.FormatFloat(f, 'e', -1, 64)
+	mantissa, exponent, _ := strings.Cut(formatted, "e")
+	digits := strings.Replace(mantissa, ".", "", 1)
+	exp, _ := strconv.Atoi(exponent)
+	k, n := len(digits), exp+1
+
+	switch {
+	case k <= n && n <= 21:
+		buf.WriteString(digits)
+		buf.WriteString(strings.Repeat("0", n-k))
+	case 0 < n && n <= 21:
+		buf.WriteString(digits[:n])
+		buf.WriteByte('.')
+		buf.WriteString(digits[n:])
+	case -6 < n && n <= 0:
+		buf.WriteString("0.")
+		buf.WriteString(strings.Repeat("0", -n))
+		buf.WriteString(digits)
+	default:
+		buf.WriteString(digits[:1])
+		if k > 1 {
+			buf.WriteByte('.')
+			buf.WriteString(digits[1:])
+		}
+		buf.WriteByte('e')
+		if n-1 >= 0 {
+			buf.WriteByte('+')
+		}
+		buf.WriteString(strconv.Itoa(n - 1))
+	}
+	return nil
+}
+
+// lessUTF16 compares two strings by their UTF-16 code units.
+func lessUTF16(a, b string) bool {
+	for a != "" && b != "" {
+		ra, sa := utf8.DecodeRuneInString(a)
+		rb, sb := utf8.DecodeRuneInString(b)
+		if ra != rb {
+			ua, ub := utf16Units(ra), utf16Units(rb)
+			for i := 0; i < len(ua) && i < len(ub); i++ {
+				if ua[i] != ub[i] {
+					return ua[i] < ub[i]
+				}
+			}
+			return len(ua) < len(ub)
+		}
+		a, b = a[sa:], b[sb:]
+	}
+	return len(a) < len(b)
+}
+
+// utf16Units returns the UTF-16 code units of a rune.
+func utf16Units(r rune) []uint16 {
+	if r1, r2 := utf16.EncodeRune(r); r1 != utf8.RuneError {
+		return []uint16{uint16(r1), uint16(r2)}
+	}
+	return []uint16{uint16(r)}
+}
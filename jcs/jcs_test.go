@@ -0,0 +1,80 @@
+package jcs
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Test for the canonical serialization of objects, strings and literals
+func TestTransform(t *testing.T) {
+	input := `{
+		"numbers": [333333333.33333329, 1E30, 4.50, 2e-3, 0.000000000000000000000000001],
+		"string": "€$\u000F\u000aA'B\u0022\u005c\\\u0022\/",
+		"literals": [null, true, false]
+	}`
+	expected := `{"literals":[null,true,false],"numbers":[333333333.3333333,1e+30,4.5,0.002,1e-27],"string":"€$\u000f\nA'B\"\\\\\"/"}`
+
+	output, err := Transform([]byte(input))
+
+	assert.NoError(t, err, "Expected no error during canonicalization")
+	assert.Equal(t, expected, string(output), "Expected the RFC 8785 canonical form")
+}
+
+// Test for the ordering of properties by UTF-16 code units
+func TestTransform_PropertyOrder(t *testing.T) {
+	input := `{"€":"Euro Sign","\r":"Carriage Return","\ufb33":"Hebrew Letter Dalet With Dagesh","1":"One","😀":"Emoji: Grinning Face","\u0080":"Control","ö":"Latin Small Letter O With Diaeresis"}`
+	expected := `{"\r":"Carriage Return","1":"One","` + "\u0080" + `":"Control","ö":"Latin Small Letter O With Diaeresis","€":"Euro Sign","😀":"Emoji: Grinning Face","` + "\ufb33" + `":"Hebrew Letter Dalet With Dagesh"}`
+
+	output, err := Transform([]byte(input))
+
+	assert.NoError(t, err, "Expected no error during canonicalization")
+	assert.Equal(t, expected, string(output), "Expected properties sorted by UTF-16 code units")
+}
+
+// Test for the serialization of numbers
+func TestMarshal_Numbers(t *testing.T) {
+	cases := map[float64]string{
+		0:                      "0",
+		1:                      "1",
+		-1.5:                   "-1.5",
+		1e21:                   "1e+21",
+		1e20:                   "100000000000000000000",
+		1e-7:                   "1e-7",
+		1e-6:                   "0.000001",
+		9007199254740992:       "9007199254740992",
+		295147905179352830000:  "295147905179352830000",
+		5e-324:                 "5e-324",
+		1.7976931348623157e308: "1.7976931348623157e+308",
+	}
+	for value, expected := range cases {
+		output, err := Marshal(value)
+		assert.NoError(t, err, "Expected no error serializing %v", value)
+		assert.Equal(t, expected, string(output), "Unexpected serialization of %v", value)
+	}
+}
+
+// Test for invalid input
+func TestTransform_Invalid(t *testing.T) {
+	_, err := Transform([]byte(`{"a":1} {"b":2}`))
+	assert.Error(t, err, "Expected an error for trailing data")
+
+	_, err = Transform([]byte(`{"a":`))
+	assert.Error(t, err, "Expected an error for truncated JSON")
+
+	// Trailing data that is not a valid token
+	for _, input := range []string{`{"a":1} xyz`, `{"a":1}]`, `{"a":1}}`, `[1] ,`} {
+		_, err = Transform([]byte(input))
+		assert.Error(t, err, "Expected an error for trailing data in %s", input)
+	}
+}
+
+// Test for rejecting duplicate member names
+func TestTransform_DuplicateNames(t *testing.T) {
+	_, err := Transform([]byte(`{"a":1,"a":2}`))
+	assert.Error(t, err, "Expected an error for a duplicate member name")
+	_, err = Transform([]byte(`{"b":{"a":1,"\u0061":2}}`))
+	assert.Error(t, err, "Expected an error for a duplicate escaped member name in a nested object")
+	_, err = Transform([]byte(`[{"a":1},{"a":2}]`))
+	assert.NoError(t, err, "Expected the same name in distinct objects to be accepted")
+}
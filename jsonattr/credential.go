@@ -0,0 +1,176 @@
+package jsonattr
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/aniagut/msc-bbs-anonymous-credentials/issue"
+	"github.com/aniagut/msc-bbs-anonymous-credentials/jcs"
+	"github.com/aniagut/msc-bbs-anonymous-credentials/models"
+	"github.com/aniagut/msc-bbs-anonymous-credentials/presentation"
+	"github.com/aniagut/msc-bbs-anonymous-credentials/verify"
+)
+
+// Credential is a signed JSON document.
+// It contains the following elements:
+// - Document: The canonical JSON of the document.
+// - Signature: The signature over the attributes of the document.
+type Credential struct {
+	Document  json.RawMessage  `json:"document"`
+	Signature models.Signature `json:"signature"`
+}
+
+// Presentation is a partially disclosed JSON document with a proof of possession of the credential.
+// It contains the following elements:
+// - Document: The canonical JSON of the disclosed part of the document.
+// - Indices: The positions of the disclosed attributes among all attributes of the document.
+// - Proof: The zero-knowledge proof.
+type Presentation struct {
+	Document json.RawMessage       `json:"document"`
+	Indices  []int                 `json:"indices"`
+	Proof    models.SignatureProof `json:"proof"`
+}
+
+// Issue signs a JSON document.
+// The attributes of the document are padded with empty strings to the number of generators, so that presentations
+// do not leak how many attributes are hidden.
+//
+// Parameters:
+//   - document: The JSON object to sign.
+//   - publicParams: The public parameters of the issuer.
+//   - secretKey: The secret key of the issuer.
+//
+// Returns:
+//   - Credential: The signed document.
+//   - error: An error if the document has more attributes than generators or the signing process fails.
+func Issue(document []byte, publicParams models.PublicParameters, secretKey models.SecretKey) (Credential, error) {
+	// Step 1: Flatten the document into attributes
+	doc, err := Parse(document)
+	if err != nil {
+		return Credential{}, err
+	}
+	attributes, err := Flatten(doc)
+	if err != nil {
+		return Credential{}, err
+	}
+	messages, err := Messages(attributes, len(publicParams.H1))
+	if err != nil {
+		return Credential{}, err
+	}
+
+	// Step 2: Sign the messages of the attributes
+	signature, err := issue.Issue(messages, publicParams, secretKey)
+	if err != nil {
+		return Credential{}, err
+	}
+	canonical, err := jcs.Marshal(doc)
+	if err != nil {
+		return Credential{}, err
+	}
+	return Credential{Document: canonical, Signature: signature}, nil
+}
+
+// Present discloses the attributes selected by JSON pointers and proves possession of the credential.
+//
+// Parameters:
+//   - credential: The credential of the holder.
+//   - pointers: The JSON pointers of the attributes to disclose.
+//   - publicParams: The public parameters of the issuer.
+//   - nonce: The verifier's nonce.
+//
+// Returns:
+//   - Presentation: The partially disclosed document with its proof.
+//   - error: An error if a pointer selects nothing or the proof generation fails.
+func Present(credential Credential, pointers []string, publicParams models.PublicParameters, nonce []byte) (Presentation, error) {
+	// Step 1: Select the disclosed attributes
+	doc, err := Parse(credential.Document)
+	if err != nil {
+		return Presentation{}, err
+	}
+	attributes, err := Flatten(doc)
+	if err != nil {
+		return Presentation{}, err
+	}
+	disclosed, err := Select(attributes, pointers)
+	if err != nil {
+		return Presentation{}, err
+	}
+	messages, err := Messages(attributes, len(publicParams.H1))
+	if err != nil {
+		return Presentation{}, err
+	}
+
+	// Step 2: Generate the proof and rebuild the disclosed document
+	proof, err := presentation.Presentation(messages, credential.Signature, disclosed, publicParams, nonce)
+	if err != nil {
+		return Presentation{}, err
+	}
+	partial, err := Reconstruct(Pick(attributes, disclosed))
+	if err != nil {
+		return Presentation{}, err
+	}
+	canonical, err := jcs.Marshal(partial)
+	if err != nil {
+		return Presentation{}, err
+	}
+	return Presentation{Document: canonical, Indices: disclosed, Proof: proof}, nil
+}
+
+// Verify checks a presentation and returns the disclosed document.
+//
+// Parameters:
+//   - p: The presentation received from the holder.
+//   - nonce: The verifier's nonce.
+//   - publicParams: The public parameters of the issuer.
+//   - publicKey: The public key of the issuer.
+//
+// Returns:
+//   - map[string]interface{}: The verified partial document.
+//   - error: An error if the disclosed document does not match the indices or the proof does not verify.
+func Verify(p Presentation, nonce []byte, publicParams models.PublicParameters, publicKey models.PublicKey) (map[string]interface{}, error) {
+	// Step 1: Flatten the disclosed document; its attributes keep the relative order of the full document
+	doc, err := Parse(p.Document)
+	if err != nil {
+		return nil, err
+	}
+	attributes, err := Flatten(doc)
+	if err != nil {
+		return nil, err
+	}
+	if len(attributes) != len(p.Indices) {
+		return nil, fmt.Errorf("%w: %d disclosed attributes, %d indices", models.ErrLengthMismatch, len(attributes), len(p.Indices))
+	}
+	messages := make([]string, len(attributes))
+	for i, a := range attributes {
+		messages[i] = a.Message()
+	}
+
+	// Step 2: Verify the proof over the disclosed attributes at their positions
+	valid, err := verify.Verify(p.Proof, nonce, messages, p.Indices, publicParams, publicKey)
+	if err != nil {
+		return nil, err
+	}
+	if !valid {
+		return nil, models.ErrPairingFailed
+	}
+	return doc, nil
+}
+
+// Messages returns the messages of the attributes, padded with empty strings to l messages.
+func Messages(attributes []Attribute, l int) ([]string, error) {
+	return PaddedMessages(nil, attributes, l)
+}
+
+// PaddedMessages returns the given leading messages followed by the messages of the attributes,
+// padded with empty strings to l messages.
+func PaddedMessages(leading []string, attributes []Attribute, l int) ([]string, error) {
+	if len(leading)+len(attributes) > l {
+		return nil, fmt.Errorf("%w: %d attributes, %d generators", models.ErrLengthMismatch, len(leading)+len(attributes), l)
+	}
+	messages := make([]string, l)
+	copy(messages, leading)
+	for i, a := range attributes {
+		messages[len(leading)+i] = a.Message()
+	}
+	return messages, nil
+}
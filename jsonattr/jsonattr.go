@@ -0,0 +1,178 @@
+// Package jsonattr maps JSON documents to credential attributes and back.
+//
+// A document is flattened into attributes, one per JSON pointer (RFC 6901) to a scalar, an array or an empty object,
+// sorted by their canonical form JCS([pointer, value]). Objects are split into their members while arrays are kept
+// as single values, so an array is always disclosed as a whole. A partially disclosed document is rebuilt from any
+// subset of the attributes, and flattening it again yields exactly that subset in the same relative order.
+package jsonattr
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/aniagut/msc-bbs-anonymous-credentials/jcs"
+)
+
+// Attribute is a single disclosable claim of a document: a JSON pointer and the value it references.
+type Attribute struct {
+	Pointer   string
+	Value     interface{}
+	canonical []byte
+}
+
+// Canonical returns the canonical form JCS([pointer, value]) of the attribute.
+func (a Attribute) Canonical() []byte {
+	return a.canonical
+}
+
+// Message returns the signed message of the attribute, the SHA-256 digest of its canonical form.
+// Hashing keeps the messages within the scalar field, so that distinct attributes cannot map to the same scalar.
+func (a Attribute) Message() string {
+	digest := sha256.Sum256(a.canonical)
+	return string(digest[:])
+}
+
+// Parse decodes a JSON object, keeping numbers exact.
+func Parse(data []byte) (map[string]interface{}, error) {
+	value, err := jcs.Parse(data)
+	if err != nil {
+		return nil, err
+	}
+	document, ok := value.(map[string]interface{})
+	if !ok {
+		return nil, errors.New("the document is not a JSON object")
+	}
+	return document, nil
+}
+
+// Flatten splits a document into attributes sorted by their canonical form.
+//
+// Parameters:
+//   - document: The JSON object, as returned by Parse.
+//
+// Returns:
+//   - []Attribute: The attributes of the document.
+//   - error: An error if a value cannot be canonicalized.
+func Flatten(document map[string]interface{}) ([]Attribute, error) {
+	attributes := make([]Attribute, 0)
+	var walk func(pointer string, value interface{}) error
+	walk = func(pointer string, value interface{}) error {
+		if object, ok := value.(map[string]interface{}); ok && len(object) > 0 {
+			for key, member := range object {
+				if err := walk(pointer+"/"+escape(key), member); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+		canonical, err := jcs.Marshal([]interface{}{pointer, value})
+		if err != nil {
+			return err
+		}
+		attributes = append(attributes, Attribute{Pointer: pointer, Value: value, canonical: canonical})
+		return nil
+	}
+
+	for key, member := range document {
+		if err := walk("/"+escape(key), member); err != nil {
+			return nil, err
+		}
+	}
+	sort.Slice(attributes, func(i, j int) bool { return string(attributes[i].canonical) < string(attributes[j].canonical) })
+	return attributes, nil
+}
+
+// Select returns the sorted positions of the attributes selected by the JSON pointers.
+// A pointer selects the attribute it references and every attribute below it.
+//
+// Parameters:
+//   - attributes: The attributes of a document, as returned by Flatten.
+//   - pointers: The JSON pointers to select.
+//
+// Returns:
+//   - []int: The positions of the selected attributes.
+//   - error: An error if a pointer is invalid or selects nothing.
+func Select(attributes []Attribute, pointers []string) ([]int, error) {
+	selected := make(map[int]bool)
+	for _, pointer := range pointers {
+		if !strings.HasPrefix(pointer, "/") {
+			return nil, fmt.Errorf("invalid JSON pointer %q", pointer)
+		}
+		found := false
+		for i, a := range attributes {
+			if a.Pointer == pointer || strings.HasPrefix(a.Pointer, pointer+"/") {
+				selected[i] = true
+				found = true
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("JSON pointer %q does not select any attribute", pointer)
+		}
+	}
+
+	positions := make([]int, 0, len(selected))
+	for i := range selected {
+		positions = append(positions, i)
+	}
+	sort.Ints(positions)
+	return positions, nil
+}
+
+// Pick returns the attributes at the given positions.
+func Pick(attributes []Attribute, positions []int) []Attribute {
+	picked := make([]Attribute, len(positions))
+	for i, position := range positions {
+		picked[i] = attributes[position]
+	}
+	return picked
+}
+
+// Reconstruct rebuilds the document made of the given attributes.
+//
+// Parameters:
+//   - attributes: A subset of the attributes of a document.
+//
+// Returns:
+//   - map[string]interface{}: The partial document.
+//   - error: An error if two attributes conflict.
+func Reconstruct(attributes []Attribute) (map[string]interface{}, error) {
+	document := make(map[string]interface{})
+	for _, a := range attributes {
+		tokens := strings.Split(a.Pointer, "/")[1:]
+		object := document
+		for i, token := range tokens {
+			key := unescape(token)
+			if i == len(tokens)-1 {
+				if _, exists := object[key]; exists {
+					return nil, fmt.Errorf("attribute %q conflicts with another attribute", a.Pointer)
+				}
+				object[key] = a.Value
+				break
+			}
+			child, exists := object[key]
+			if !exists {
+				child = make(map[string]interface{})
+				object[key] = child
+			}
+			next, ok := child.(map[string]interface{})
+			if !ok {
+				return nil, fmt.Errorf("attribute %q conflicts with another attribute", a.Pointer)
+			}
+			object = next
+		}
+	}
+	return document, nil
+}
+
+// escape escapes a key as a JSON pointer reference token.
+func escape(key string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(key, "~", "~0"), "/", "~1")
+}
+
+// unescape reverses escape.
+func unescape(token string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+}
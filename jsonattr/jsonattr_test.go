@@ -0,0 +1,111 @@
+package jsonattr
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/aniagut/msc-bbs-anonymous-credentials/models"
+	"github.com/aniagut/msc-bbs-anonymous-credentials/setup"
+	"github.com/stretchr/testify/assert"
+)
+
+// document is the JSON object used in the tests
+const document = `{
+	"name": "Alice",
+	"birth": {"year": 1990, "place": {"city": "Warsaw", "country": "PL"}},
+	"emails": ["alice@example.com"],
+	"verified": true
+}`
+
+// Test for flattening a document and rebuilding it
+func TestFlattenAndReconstruct(t *testing.T) {
+	doc, err := Parse([]byte(`{"a/b":{"c~d":1,"e":{}},"f":[1,{"g":2}],"h":null}`))
+	assert.NoError(t, err, "Expected no error parsing the document")
+
+	attributes, err := Flatten(doc)
+	assert.NoError(t, err, "Expected no error flattening the document")
+	pointers := make([]string, len(attributes))
+	for i, a := range attributes {
+		pointers[i] = a.Pointer
+	}
+	assert.ElementsMatch(t, []string{"/a~1b/c~0d", "/a~1b/e", "/f", "/h"}, pointers, "Expected one attribute per leaf")
+
+	rebuilt, err := Reconstruct(attributes)
+	assert.NoError(t, err, "Expected no error rebuilding the document")
+	assert.Equal(t, doc, rebuilt, "Expected the rebuilt document to equal the original")
+}
+
+// Test for the deterministic order of attributes
+func TestFlatten_Deterministic(t *testing.T) {
+	first, _ := Parse([]byte(`{"b":{"y":2,"x":1},"a":"z"}`))
+	second, _ := Parse([]byte(`{"a":"z","b":{"x":1.0,"y":2}}`))
+
+	a, err := Flatten(first)
+	assert.NoError(t, err, "Expected no error flattening the first document")
+	b, err := Flatten(second)
+	assert.NoError(t, err, "Expected no error flattening the second document")
+
+	for i := range a {
+		assert.Equal(t, string(a[i].Canonical()), string(b[i].Canonical()), "Expected the same attributes in the same order")
+	}
+	assert.Equal(t, `["/a","z"]`, string(a[0].Canonical()), "Expected attributes sorted by their canonical form")
+}
+
+// Test for selecting attributes by JSON pointer
+func TestSelect(t *testing.T) {
+	doc, _ := Parse([]byte(document))
+	attributes, err := Flatten(doc)
+	assert.NoError(t, err, "Expected no error flattening the document")
+
+	positions, err := Select(attributes, []string{"/birth/place", "/name"})
+	assert.NoError(t, err, "Expected no error selecting the attributes")
+	partial, err := Reconstruct(Pick(attributes, positions))
+	assert.NoError(t, err, "Expected no error rebuilding the partial document")
+	encoded, _ := json.Marshal(partial)
+	assert.JSONEq(t, `{"name":"Alice","birth":{"place":{"city":"Warsaw","country":"PL"}}}`, string(encoded), "Expected the selected subtree")
+
+	_, err = Select(attributes, []string{"/emails/0"})
+	assert.Error(t, err, "Expected an error for a pointer into an array")
+	_, err = Select(attributes, []string{"name"})
+	assert.Error(t, err, "Expected an error for an invalid pointer")
+}
+
+// Test for issuing, presenting and verifying a JSON document
+func TestIssuePresentVerify(t *testing.T) {
+	setupResult, err := setup.Setup(8)
+	assert.NoError(t, err, "Expected no error during setup")
+	nonce := []byte("nonce")
+
+	credential, err := Issue([]byte(document), setupResult.PublicParameters, setupResult.SecretKey)
+	assert.NoError(t, err, "Expected no error issuing the credential")
+
+	p, err := Present(credential, []string{"/birth/place/country", "/verified"}, setupResult.PublicParameters, nonce)
+	assert.NoError(t, err, "Expected no error presenting the credential")
+	assert.JSONEq(t, `{"birth":{"place":{"country":"PL"}},"verified":true}`, string(p.Document), "Expected only the selected attributes")
+
+	disclosed, err := Verify(p, nonce, setupResult.PublicParameters, setupResult.PublicKey)
+	assert.NoError(t, err, "Expected the presentation to verify")
+	assert.Equal(t, true, disclosed["verified"], "Expected the verified document to be returned")
+
+	// Round trip through JSON, as sent over the wire
+	encoded, err := json.Marshal(p)
+	assert.NoError(t, err, "Expected no error encoding the presentation")
+	var decoded Presentation
+	assert.NoError(t, json.Unmarshal(encoded, &decoded), "Expected no error decoding the presentation")
+	_, err = Verify(decoded, nonce, setupResult.PublicParameters, setupResult.PublicKey)
+	assert.NoError(t, err, "Expected the decoded presentation to verify")
+
+	decoded.Document = json.RawMessage(`{"birth":{"place":{"country":"DE"}},"verified":true}`)
+	_, err = Verify(decoded, nonce, setupResult.PublicParameters, setupResult.PublicKey)
+	assert.Error(t, err, "Expected a modified disclosed value to fail verification")
+}
+
+// Test for a document with more attributes than generators
+func TestIssue_TooManyAttributes(t *testing.T) {
+	setupResult, err := setup.Setup(2)
+	assert.NoError(t, err, "Expected no error during setup")
+
+	_, err = Issue([]byte(document), setupResult.PublicParameters, setupResult.SecretKey)
+
+	assert.ErrorIs(t, err, models.ErrLengthMismatch, "Expected an error for more attributes than generators")
+}
@@ -0,0 +1,232 @@
+// Package jwk represents issuer keys as JSON Web Keys with the BLS12381G2 curve of the BBS JOSE draft.
+//
+// Keys are exported with kty "EC", where x and y are the two 96-byte coordinates of the uncompressed X2, and d is
+// the 32-byte big-endian secret scalar. Keys with kty "OKP", where x is the compressed X2, are accepted on import.
+// Imported keys are checked to be non-identity elements of the prime-order subgroup G2.
+package jwk
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/aniagut/msc-bbs-anonymous-credentials/models"
+	e "github.com/cloudflare/circl/ecc/bls12381"
+)
+
+// Key types and curve of BBS issuer keys.
+const (
+	KeyTypeEC  = "EC"
+	KeyTypeOKP = "OKP"
+	Curve      = "BLS12381G2"
+)
+
+// coordinateSize is the size of a coordinate of an element of G2.
+const coordinateSize = e.G2Size / 2
+
+// ErrInvalidKey is returned when a JWK is malformed, uses another key type or curve, or holds an invalid key.
+var ErrInvalidKey = errors.New("invalid JWK")
+
+// Key is a JSON Web Key.
+// It contains the following elements:
+// - Kty: The key type, "EC" or "OKP".
+// - Crv: The curve, always "BLS12381G2".
+// - X: The x coordinate of X2 ("EC"), or the compressed X2 ("OKP"), base64url encoded.
+// - Y: The y coordinate of X2 ("EC" only), base64url encoded.
+// - D: The secret scalar, base64url encoded, present only in private keys.
+// - Kid: The key ID, the RFC 7638 thumbprint by default.
+type Key struct {
+	Kty string `json:"kty"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y,omitempty"`
+	D   string `json:"d,omitempty"`
+	Kid string `json:"kid,omitempty"`
+}
+
+// FromPublicKey exports a public key as an "EC" JWK whose key ID is its thumbprint.
+//
+// Parameters:
+//   - publicKey: The public key.
+//
+// Returns:
+//   - Key: The JWK.
+//   - error: An error if the key is missing or is the identity.
+func FromPublicKey(publicKey models.PublicKey) (Key, error) {
+	if publicKey.X2 == nil || publicKey.X2.IsIdentity() {
+		return Key{}, fmt.Errorf("%w: X2 is missing or the identity", ErrInvalidKey)
+	}
+	uncompressed := publicKey.X2.Bytes()
+	key := Key{
+		Kty: KeyTypeEC,
+		Crv: Curve,
+		X:   base64.RawURLEncoding.EncodeToString(uncompressed[:coordinateSize]),
+		Y:   base64.RawURLEncoding.EncodeToString(uncompressed[coordinateSize:]),
+	}
+	thumbprint, err := key.Thumbprint()
+	if err != nil {
+		return Key{}, err
+	}
+	key.Kid = thumbprint
+	return key, nil
+}
+
+// FromSecretKey exports a secret key, together with its public key, as a private "EC" JWK.
+//
+// Parameters:
+//   - secretKey: The secret key.
+//
+// Returns:
+//   - Key: The private JWK.
+//   - error: An error if the key is missing or zero.
+func FromSecretKey(secretKey models.SecretKey) (Key, error) {
+	// Step 1: Derive the public key X2 = g2^x
+	if secretKey.X == nil || secretKey.X.IsZero() == 1 {
+		return Key{}, fmt.Errorf("%w: secret key is missing or zero", ErrInvalidKey)
+	}
+	x2 := new(e.G2)
+	x2.ScalarMult(secretKey.X, e.G2Generator())
+
+	// Step 2: Export the public key and add the secret scalar
+	key, err := FromPublicKey(models.PublicKey{X2: x2})
+	if err != nil {
+		return Key{}, err
+	}
+	d, err := secretKey.MarshalBinary()
+	if err != nil {
+		return Key{}, err
+	}
+	key.D = base64.RawURLEncoding.EncodeToString(d)
+	return key, nil
+}
+
+// Public returns the key without its secret scalar.
+func (k Key) Public() Key {
+	k.D = ""
+	return k
+}
+
+// PublicKey imports the public key of a JWK.
+//
+// Returns:
+//   - models.PublicKey: The public key.
+//   - error: An error if the JWK is malformed or X2 is not a non-identity element of G2.
+func (k Key) PublicKey() (models.PublicKey, error) {
+	// Step 1: Check the curve and assemble the encoding of X2 for the key type
+	if k.Crv != Curve {
+		return models.PublicKey{}, fmt.Errorf("%w: unsupported curve %q", ErrInvalidKey, k.Crv)
+	}
+	x, err := decodeMember("x", k.X)
+	if err != nil {
+		return models.PublicKey{}, err
+	}
+	var encoded []byte
+	switch k.Kty {
+	case KeyTypeEC:
+		y, err := decodeMember("y", k.Y)
+		if err != nil {
+			return models.PublicKey{}, err
+		}
+		// The flag bits of the uncompressed encoding must be clear, as they are not part of the coordinate
+		if len(x) != coordinateSize || len(y) != coordinateSize || x[0]&0xE0 != 0 {
+			return models.PublicKey{}, fmt.Errorf("%w: invalid coordinates", ErrInvalidKey)
+		}
+		encoded = append(x, y...)
+	case KeyTypeOKP:
+		if k.Y != "" || len(x) != models.G2Size {
+			return models.PublicKey{}, fmt.Errorf("%w: invalid compressed key", ErrInvalidKey)
+		}
+		encoded = x
+	default:
+		return models.PublicKey{}, fmt.Errorf("%w: unsupported key type %q", ErrInvalidKey, k.Kty)
+	}
+
+	// Step 2: Decode X2, which checks that it is on the curve and in the subgroup
+	x2 := new(e.G2)
+	if err := x2.SetBytes(encoded); err != nil {
+		return models.PublicKey{}, fmt.Errorf("%w: %v", ErrInvalidKey, err)
+	}
+	if x2.IsIdentity() {
+		return models.PublicKey{}, fmt.Errorf("%w: X2 is the identity", ErrInvalidKey)
+	}
+	return models.PublicKey{X2: x2}, nil
+}
+
+// SecretKey imports the secret key of a private JWK, checking that it matches the public key.
+//
+// Returns:
+//   - models.SecretKey: The secret key.
+//   - error: An error if the JWK is malformed, is not private, or d does not match the public key.
+func (k Key) SecretKey() (models.SecretKey, error) {
+	// Step 1: Import the public key
+	publicKey, err := k.PublicKey()
+	if err != nil {
+		return models.SecretKey{}, err
+	}
+
+	// Step 2: Decode the secret scalar
+	if k.D == "" {
+		return models.SecretKey{}, fmt.Errorf("%w: not a private key", ErrInvalidKey)
+	}
+	d, err := decodeMember("d", k.D)
+	if err != nil {
+		return models.SecretKey{}, err
+	}
+	var secretKey models.SecretKey
+	if err := secretKey.UnmarshalBinary(d); err != nil {
+		return models.SecretKey{}, fmt.Errorf("%w: %v", ErrInvalidKey, err)
+	}
+
+	// Step 3: Check that g2^d is the public key
+	x2 := new(e.G2)
+	x2.ScalarMult(secretKey.X, e.G2Generator())
+	if !x2.IsEqual(publicKey.X2) {
+		return models.SecretKey{}, fmt.Errorf("%w: d does not match the public key", ErrInvalidKey)
+	}
+	return secretKey, nil
+}
+
+// Thumbprint computes the RFC 7638 thumbprint of the key: the base64url SHA-256 digest of the JSON object of its
+// required members (crv, kty, x and, for "EC", y) in lexicographic order without whitespace.
+//
+// Returns:
+//   - string: The thumbprint.
+//   - error: An error if the key type is not supported.
+func (k Key) Thumbprint() (string, error) {
+	var members interface{}
+	switch k.Kty {
+	case KeyTypeEC:
+		// Struct fields are declared in lexicographic order, which encoding/json preserves
+		members = struct {
+			Crv string `json:"crv"`
+			Kty string `json:"kty"`
+			X   string `json:"x"`
+			Y   string `json:"y"`
+		}{k.Crv, k.Kty, k.X, k.Y}
+	case KeyTypeOKP:
+		members = struct {
+			Crv string `json:"crv"`
+			Kty string `json:"kty"`
+			X   string `json:"x"`
+		}{k.Crv, k.Kty, k.X}
+	default:
+		return "", fmt.Errorf("%w: unsupported key type %q", ErrInvalidKey, k.Kty)
+	}
+	encoded, err := json.Marshal(members)
+	if err != nil {
+		return "", err
+	}
+	digest := sha256.Sum256(encoded)
+	return base64.RawURLEncoding.EncodeToString(digest[:]), nil
+}
+
+// decodeMember decodes a base64url member of the JWK.
+func decodeMember(name, value string) ([]byte, error) {
+	b, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, fmt.Errorf("%w: member %q is not base64url: %v", ErrInvalidKey, name, err)
+	}
+	return b, nil
+}
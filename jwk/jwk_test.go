@@ -0,0 +1,109 @@
+package jwk
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"testing"
+
+	"github.com/aniagut/msc-bbs-anonymous-credentials/setup"
+	"github.com/stretchr/testify/assert"
+)
+
+// Test for exporting and importing public and secret keys
+func TestKey_RoundTrip(t *testing.T) {
+	result, err := setup.Setup(1)
+	assert.NoError(t, err, "Expected no error during setup")
+
+	private, err := FromSecretKey(result.SecretKey)
+	assert.NoError(t, err, "Expected no error exporting the secret key")
+	public, err := FromPublicKey(result.PublicKey)
+	assert.NoError(t, err, "Expected no error exporting the public key")
+	assert.Equal(t, public, private.Public(), "Expected the private JWK to hold the public JWK")
+
+	encoded, err := json.Marshal(private)
+	assert.NoError(t, err, "Expected no error encoding the JWK")
+	var decoded Key
+	assert.NoError(t, json.Unmarshal(encoded, &decoded), "Expected no error decoding the JWK")
+
+	publicKey, err := decoded.PublicKey()
+	assert.NoError(t, err, "Expected no error importing the public key")
+	assert.True(t, publicKey.X2.IsEqual(result.PublicKey.X2), "Expected the public key to round trip")
+	secretKey, err := decoded.SecretKey()
+	assert.NoError(t, err, "Expected no error importing the secret key")
+	assert.Equal(t, 1, secretKey.X.IsEqual(result.SecretKey.X), "Expected the secret key to round trip")
+
+	_, err = public.SecretKey()
+	assert.ErrorIs(t, err, ErrInvalidKey, "Expected an error importing a secret key from a public JWK")
+}
+
+// Test for the RFC 7638 thumbprint
+func TestKey_Thumbprint(t *testing.T) {
+	result, _ := setup.Setup(1)
+	key, err := FromPublicKey(result.PublicKey)
+	assert.NoError(t, err, "Expected no error exporting the public key")
+
+	canonical := `{"crv":"BLS12381G2","kty":"EC","x":"` + key.X + `","y":"` + key.Y + `"}`
+	digest := sha256.Sum256([]byte(canonical))
+	assert.Equal(t, base64.RawURLEncoding.EncodeToString(digest[:]), key.Kid, "Expected the key ID to be the thumbprint")
+
+	private, _ := FromSecretKey(result.SecretKey)
+	thumbprint, err := private.Thumbprint()
+	assert.NoError(t, err, "Expected no error computing the thumbprint")
+	assert.Equal(t, key.Kid, thumbprint, "Expected the thumbprint to ignore the private and optional members")
+}
+
+// Test for importing an OKP key holding the compressed point
+func TestKey_OKP(t *testing.T) {
+	result, _ := setup.Setup(1)
+	key := Key{Kty: KeyTypeOKP, Crv: Curve, X: base64.RawURLEncoding.EncodeToString(result.PublicKey.X2.BytesCompressed())}
+
+	publicKey, err := key.PublicKey()
+	assert.NoError(t, err, "Expected no error importing the OKP key")
+	assert.True(t, publicKey.X2.IsEqual(result.PublicKey.X2), "Expected the OKP key to decode")
+
+	thumbprint, err := key.Thumbprint()
+	assert.NoError(t, err, "Expected no error computing the OKP thumbprint")
+	digest := sha256.Sum256([]byte(`{"crv":"BLS12381G2","kty":"OKP","x":"` + key.X + `"}`))
+	assert.Equal(t, base64.RawURLEncoding.EncodeToString(digest[:]), thumbprint, "Expected the OKP thumbprint")
+}
+
+// Test for JWKs that must be rejected
+func TestKey_Invalid(t *testing.T) {
+	result, _ := setup.Setup(1)
+	valid, _ := FromSecretKey(result.SecretKey)
+
+	key := valid
+	key.Crv = "P-256"
+	_, err := key.PublicKey()
+	assert.ErrorIs(t, err, ErrInvalidKey, "Expected an error for another curve")
+
+	key = valid
+	key.Kty = "RSA"
+	_, err = key.PublicKey()
+	assert.ErrorIs(t, err, ErrInvalidKey, "Expected an error for another key type")
+
+	key = valid
+	key.Y = key.X
+	_, err = key.PublicKey()
+	assert.ErrorIs(t, err, ErrInvalidKey, "Expected an error for a point that is not in G2")
+
+	key = valid
+	x, _ := base64.RawURLEncoding.DecodeString(key.X)
+	x[0] |= 0x80
+	key.X = base64.RawURLEncoding.EncodeToString(x)
+	_, err = key.PublicKey()
+	assert.ErrorIs(t, err, ErrInvalidKey, "Expected an error for flag bits in the x coordinate")
+
+	other, _ := setup.Setup(1)
+	otherKey, _ := FromSecretKey(other.SecretKey)
+	key = valid
+	key.D = otherKey.D
+	_, err = key.SecretKey()
+	assert.ErrorIs(t, err, ErrInvalidKey, "Expected an error for a secret key that does not match")
+
+	key = valid
+	key.D = "!!"
+	_, err = key.SecretKey()
+	assert.ErrorIs(t, err, ErrInvalidKey, "Expected an error for an invalid base64url member")
+}
@@ -0,0 +1,541 @@
+// Package jwp encodes credentials and presentations as JSON Web Proofs (IETF JOSE JWP) with the BBS algorithm
+// of the JSON Proof Algorithms draft.
+//
+// The issuer protected header is signed as attribute 0 and is always disclosed; payload i is attribute i+1.
+// Headers and payloads are mapped to attributes by their SHA-256 digest, and unused attributes are padded with
+// empty strings. In the presented form, the encoded presentation protected header is the nonce of the proof.
+package jwp
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/aniagut/msc-bbs-anonymous-credentials/issue"
+	"github.com/aniagut/msc-bbs-anonymous-credentials/models"
+	"github.com/aniagut/msc-bbs-anonymous-credentials/presentation"
+	"github.com/aniagut/msc-bbs-anonymous-credentials/verify"
+)
+
+// Algorithm is the value of the alg header parameter.
+const Algorithm = "BBS"
+
+// ErrInvalidJWP is returned when a serialization is malformed or its headers are invalid.
+var ErrInvalidJWP = errors.New("invalid JWP")
+
+// IssuerHeader is the issuer protected header.
+// It contains the following elements:
+// - Alg: The algorithm, always "BBS".
+// - Kid: The identifier of the issuer key.
+// - Typ: The media type of the JWP.
+// - Claims: The names of the payloads, in order.
+type IssuerHeader struct {
+	Alg    string   `json:"alg"`
+	Kid    string   `json:"kid,omitempty"`
+	Typ    string   `json:"typ,omitempty"`
+	Claims []string `json:"claims,omitempty"`
+}
+
+// PresentationHeader is the presentation protected header.
+// It contains the following elements:
+// - Alg: The algorithm, always "BBS".
+// - Nonce: The verifier's nonce, base64url encoded.
+// - Aud: The intended verifier.
+type PresentationHeader struct {
+	Alg   string `json:"alg"`
+	Nonce string `json:"nonce,omitempty"`
+	Aud   string `json:"aud,omitempty"`
+}
+
+// Issued is a JWP in the issued form.
+// It contains the following elements:
+// - Header: The octets of the issuer protected header.
+// - Payloads: The payloads.
+// - Proof: The signature.
+type Issued struct {
+	Header   []byte
+	Payloads [][]byte
+	Proof    []byte
+}
+
+// Presented is a JWP in the presented form.
+// It contains the following elements:
+// - PresentationHeader: The octets of the presentation protected header.
+// - Header: The octets of the issuer protected header.
+// - Payloads: The payloads, nil for the undisclosed ones.
+// - Proof: The zero-knowledge proof.
+type Presented struct {
+	PresentationHeader []byte
+	Header             []byte
+	Payloads           [][]byte
+	Proof              []byte
+}
+
+// Issue signs payloads into an issued JWP.
+//
+// Parameters:
+//   - header: The issuer protected header. Its algorithm is set to "BBS".
+//   - payloads: The payloads. Together with the header they must not exceed the number of generators.
+//   - publicParams: The public parameters of the issuer.
+//   - secretKey: The secret key of the issuer.
+//
+// Returns:
+//   - Issued: The issued JWP.
+//   - error: An error if there are too many payloads or the signing process fails.
+func Issue(header IssuerHeader, payloads [][]byte, publicParams models.PublicParameters, secretKey models.SecretKey) (Issued, error) {
+	// Step 1: Encode the issuer protected header
+	header.Alg = Algorithm
+	headerBytes, err := json.Marshal(header)
+	if err != nil {
+		return Issued{}, err
+	}
+
+	// Step 2: Sign the header and the payloads
+	payloads = append([][]byte(nil), payloads...)
+	for i := range payloads {
+		payloads[i] = nonNil(payloads[i])
+	}
+	attributes, err := toAttributes(headerBytes, payloads, len(publicParams.H1))
+	if err != nil {
+		return Issued{}, err
+	}
+	signature, err := issue.Issue(attributes, publicParams, secretKey)
+	if err != nil {
+		return Issued{}, err
+	}
+	proof, err := signature.MarshalBinary()
+	if err != nil {
+		return Issued{}, err
+	}
+	return Issued{Header: headerBytes, Payloads: payloads, Proof: proof}, nil
+}
+
+// Verify checks the signature of an issued JWP, as the holder does after issuance.
+func (j Issued) Verify(publicParams models.PublicParameters, publicKey models.PublicKey) error {
+	if _, err := j.IssuerHeader(); err != nil {
+		return err
+	}
+	var signature models.Signature
+	if err := signature.UnmarshalBinary(j.Proof); err != nil {
+		return err
+	}
+	attributes, err := toAttributes(j.Header, j.Payloads, len(publicParams.H1))
+	if err != nil {
+		return err
+	}
+	valid, err := issue.VerifyCredential(attributes, signature, publicParams, publicKey)
+	if err != nil {
+		return err
+	}
+	if !valid {
+		return models.ErrPairingFailed
+	}
+	return nil
+}
+
+// IssuerHeader decodes the issuer protected header and checks its algorithm.
+func (j Issued) IssuerHeader() (IssuerHeader, error) {
+	return decodeIssuerHeader(j.Header)
+}
+
+// Present derives a presented JWP disclosing the selected payloads.
+//
+// Parameters:
+//   - header: The presentation protected header, usually carrying the verifier's nonce. Its algorithm is set to "BBS".
+//   - disclosed: The sorted indices of the payloads to disclose.
+//   - publicParams: The public parameters of the issuer.
+//
+// Returns:
+//   - Presented: The presented JWP.
+//   - error: An error if the issued JWP is malformed, an index is invalid or the proof generation fails.
+func (j Issued) Present(header PresentationHeader, disclosed []int, publicParams models.PublicParameters) (Presented, error) {
+	// Step 1: Encode the presentation protected header, the nonce of the proof
+	header.Alg = Algorithm
+	presentationHeader, err := json.Marshal(header)
+	if err != nil {
+		return Presented{}, err
+	}
+
+	// Step 2: Prove knowledge of the signature, disclosing the issuer header and the selected payloads
+	var signature models.Signature
+	if err := signature.UnmarshalBinary(j.Proof); err != nil {
+		return Presented{}, err
+	}
+	attributes, err := toAttributes(j.Header, j.Payloads, len(publicParams.H1))
+	if err != nil {
+		return Presented{}, err
+	}
+	revealed := make([]int, 0, len(disclosed)+1)
+	revealed = append(revealed, 0)
+	payloads := make([][]byte, len(j.Payloads))
+	for i, index := range disclosed {
+		if index < 0 || index >= len(j.Payloads) {
+			return Presented{}, fmt.Errorf("%w: payload %d, %d payloads", models.ErrIndexOutOfRange, index, len(j.Payloads))
+		}
+		if i > 0 && index <= disclosed[i-1] {
+			return Presented{}, fmt.Errorf("%w: payload %d follows %d", models.ErrUnsortedIndices, index, disclosed[i-1])
+		}
+		revealed = append(revealed, index+1)
+		payloads[index] = nonNil(j.Payloads[index])
+	}
+	proof, err := presentation.Presentation(attributes, signature, revealed, publicParams, presentationHeader)
+	if err != nil {
+		return Presented{}, err
+	}
+	proofBytes, err := proof.MarshalBinary()
+	if err != nil {
+		return Presented{}, err
+	}
+
+	return Presented{
+		PresentationHeader: presentationHeader,
+		Header:             j.Header,
+		Payloads:           payloads,
+		Proof:              proofBytes,
+	}, nil
+}
+
+// Verify checks a presented JWP.
+//
+// Parameters:
+//   - nonce: The nonce the verifier expects in the presentation protected header.
+//   - publicParams: The public parameters of the issuer.
+//   - publicKey: The public key of the issuer.
+//
+// Returns:
+//   - error: An error if the headers are invalid, the nonce does not match or the proof does not verify.
+func (p Presented) Verify(nonce []byte, publicParams models.PublicParameters, publicKey models.PublicKey) error {
+	// Step 1: Check the headers and the nonce
+	if _, err := decodeIssuerHeader(p.Header); err != nil {
+		return err
+	}
+	header, err := p.PresentationProtectedHeader()
+	if err != nil {
+		return err
+	}
+	if header.Nonce != base64.RawURLEncoding.EncodeToString(nonce) {
+		return fmt.Errorf("%w: presentation header carries another nonce", models.ErrChallengeMismatch)
+	}
+
+	// Step 2: Verify the proof over the issuer header and the disclosed payloads
+	var proof models.SignatureProof
+	if err := proof.UnmarshalBinary(p.Proof); err != nil {
+		return err
+	}
+	revealedAttributes := []string{digest(p.Header)}
+	revealedIndices := []int{0}
+	for i, payload := range p.Payloads {
+		if payload != nil {
+			revealedAttributes = append(revealedAttributes, digest(payload))
+			revealedIndices = append(revealedIndices, i+1)
+		}
+	}
+	valid, err := verify.Verify(proof, p.PresentationHeader, revealedAttributes, revealedIndices, publicParams, publicKey)
+	if err != nil {
+		return err
+	}
+	if !valid {
+		return models.ErrPairingFailed
+	}
+	return nil
+}
+
+// PresentationProtectedHeader decodes the presentation protected header and checks its algorithm.
+func (p Presented) PresentationProtectedHeader() (PresentationHeader, error) {
+	var header PresentationHeader
+	if err := json.Unmarshal(p.PresentationHeader, &header); err != nil {
+		return PresentationHeader{}, fmt.Errorf("%w: presentation header: %v", ErrInvalidJWP, err)
+	}
+	if header.Alg != Algorithm {
+		return PresentationHeader{}, fmt.Errorf("%w: unsupported algorithm %q", ErrInvalidJWP, header.Alg)
+	}
+	return header, nil
+}
+
+// IssuerHeader decodes the issuer protected header and checks its algorithm.
+func (p Presented) IssuerHeader() (IssuerHeader, error) {
+	return decodeIssuerHeader(p.Header)
+}
+
+// NewPresentationHeader returns a presentation protected header carrying the verifier's nonce.
+func NewPresentationHeader(nonce []byte, audience string) PresentationHeader {
+	return PresentationHeader{Alg: Algorithm, Nonce: base64.RawURLEncoding.EncodeToString(nonce), Aud: audience}
+}
+
+// Compact returns the compact serialization BASE64URL(header).payloads.proof,
+// with tilde-separated payloads and zero-length payloads written as "_".
+func (j Issued) Compact() string {
+	return strings.Join([]string{b64(j.Header), joinPayloads(j.Payloads), b64(j.Proof)}, ".")
+}
+
+// Compact returns the compact serialization BASE64URL(presentation header).BASE64URL(issuer header).payloads.proof,
+// with tilde-separated payloads, undisclosed payloads left empty and zero-length payloads written as "_".
+func (p Presented) Compact() string {
+	return strings.Join([]string{b64(p.PresentationHeader), b64(p.Header), joinPayloads(p.Payloads), b64(p.Proof)}, ".")
+}
+
+// ParseIssued parses the compact serialization of an issued JWP.
+func ParseIssued(s string) (Issued, error) {
+	parts := strings.Split(s, ".")
+	if len(parts) != 3 {
+		return Issued{}, fmt.Errorf("%w: expected 3 parts in the issued form", ErrInvalidJWP)
+	}
+	header, err := unb64(parts[0])
+	if err != nil {
+		return Issued{}, err
+	}
+	payloads, err := splitPayloads(parts[1])
+	if err != nil {
+		return Issued{}, err
+	}
+	for _, payload := range payloads {
+		if payload == nil {
+			return Issued{}, fmt.Errorf("%w: issued form with an omitted payload", ErrInvalidJWP)
+		}
+	}
+	proof, err := unb64(parts[2])
+	if err != nil {
+		return Issued{}, err
+	}
+	return Issued{Header: header, Payloads: payloads, Proof: proof}, nil
+}
+
+// ParsePresented parses the compact serialization of a presented JWP.
+func ParsePresented(s string) (Presented, error) {
+	parts := strings.Split(s, ".")
+	if len(parts) != 4 {
+		return Presented{}, fmt.Errorf("%w: expected 4 parts in the presented form", ErrInvalidJWP)
+	}
+	presentationHeader, err := unb64(parts[0])
+	if err != nil {
+		return Presented{}, err
+	}
+	header, err := unb64(parts[1])
+	if err != nil {
+		return Presented{}, err
+	}
+	payloads, err := splitPayloads(parts[2])
+	if err != nil {
+		return Presented{}, err
+	}
+	proof, err := unb64(parts[3])
+	if err != nil {
+		return Presented{}, err
+	}
+	return Presented{PresentationHeader: presentationHeader, Header: header, Payloads: payloads, Proof: proof}, nil
+}
+
+// issuedJSON is the JSON serialization of an issued JWP.
+type issuedJSON struct {
+	Issuer   string    `json:"issuer"`
+	Payloads []*string `json:"payloads"`
+	Proof    []string  `json:"proof"`
+}
+
+// presentedJSON is the JSON serialization of a presented JWP.
+type presentedJSON struct {
+	Presentation string    `json:"presentation"`
+	Issuer       string    `json:"issuer"`
+	Payloads     []*string `json:"payloads"`
+	Proof        []string  `json:"proof"`
+}
+
+// MarshalJSON implements json.Marshaler with the JWP JSON serialization.
+func (j Issued) MarshalJSON() ([]byte, error) {
+	return json.Marshal(issuedJSON{Issuer: b64(j.Header), Payloads: payloadsToJSON(j.Payloads), Proof: []string{b64(j.Proof)}})
+}
+
+// UnmarshalJSON implements json.Unmarshaler with the JWP JSON serialization.
+func (j *Issued) UnmarshalJSON(data []byte) error {
+	var aux issuedJSON
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	header, err := unb64(aux.Issuer)
+	if err != nil {
+		return err
+	}
+	payloads, err := payloadsFromJSON(aux.Payloads)
+	if err != nil {
+		return err
+	}
+	for _, payload := range payloads {
+		if payload == nil {
+			return fmt.Errorf("%w: issued form with an omitted payload", ErrInvalidJWP)
+		}
+	}
+	proof, err := proofFromJSON(aux.Proof)
+	if err != nil {
+		return err
+	}
+	*j = Issued{Header: header, Payloads: payloads, Proof: proof}
+	return nil
+}
+
+// MarshalJSON implements json.Marshaler with the JWP JSON serialization.
+func (p Presented) MarshalJSON() ([]byte, error) {
+	return json.Marshal(presentedJSON{
+		Presentation: b64(p.PresentationHeader),
+		Issuer:       b64(p.Header),
+		Payloads:     payloadsToJSON(p.Payloads),
+		Proof:        []string{b64(p.Proof)},
+	})
+}
+
+// UnmarshalJSON implements json.Unmarshaler with the JWP JSON serialization.
+func (p *Presented) UnmarshalJSON(data []byte) error {
+	var aux presentedJSON
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	presentationHeader, err := unb64(aux.Presentation)
+	if err != nil {
+		return err
+	}
+	header, err := unb64(aux.Issuer)
+	if err != nil {
+		return err
+	}
+	payloads, err := payloadsFromJSON(aux.Payloads)
+	if err != nil {
+		return err
+	}
+	proof, err := proofFromJSON(aux.Proof)
+	if err != nil {
+		return err
+	}
+	*p = Presented{PresentationHeader: presentationHeader, Header: header, Payloads: payloads, Proof: proof}
+	return nil
+}
+
+// toAttributes maps the issuer header and the payloads to attributes, padded with empty strings to l attributes.
+func toAttributes(header []byte, payloads [][]byte, l int) ([]string, error) {
+	if len(payloads)+1 > l {
+		return nil, fmt.Errorf("%w: %d payloads and the header, %d generators", models.ErrLengthMismatch, len(payloads), l)
+	}
+	attributes := make([]string, l)
+	attributes[0] = digest(header)
+	for i, payload := range payloads {
+		attributes[i+1] = digest(payload)
+	}
+	return attributes, nil
+}
+
+// decodeIssuerHeader decodes an issuer protected header and checks its algorithm.
+func decodeIssuerHeader(data []byte) (IssuerHeader, error) {
+	var header IssuerHeader
+	if err := json.Unmarshal(data, &header); err != nil {
+		return IssuerHeader{}, fmt.Errorf("%w: issuer header: %v", ErrInvalidJWP, err)
+	}
+	if header.Alg != Algorithm {
+		return IssuerHeader{}, fmt.Errorf("%w: unsupported algorithm %q", ErrInvalidJWP, header.Alg)
+	}
+	return header, nil
+}
+
+// digest maps octets to an attribute.
+func digest(data []byte) string {
+	sum := sha256.Sum256(data)
+	return string(sum[:])
+}
+
+// joinPayloads encodes tilde-separated payloads.
+func joinPayloads(payloads [][]byte) string {
+	encoded := make([]string, len(payloads))
+	for i, payload := range payloads {
+		switch {
+		case payload == nil:
+			encoded[i] = ""
+		case len(payload) == 0:
+			encoded[i] = "_"
+		default:
+			encoded[i] = b64(payload)
+		}
+	}
+	return strings.Join(encoded, "~")
+}
+
+// splitPayloads decodes tilde-separated payloads, returning nil for omitted ones.
+func splitPayloads(s string) ([][]byte, error) {
+	if s == "" {
+		return [][]byte{}, nil
+	}
+	parts := strings.Split(s, "~")
+	payloads := make([][]byte, len(parts))
+	for i, part := range parts {
+		switch part {
+		case "":
+			payloads[i] = nil
+		case "_":
+			payloads[i] = []byte{}
+		default:
+			payload, err := unb64(part)
+			if err != nil {
+				return nil, err
+			}
+			payloads[i] = payload
+		}
+	}
+	return payloads, nil
+}
+
+// payloadsToJSON encodes payloads for the JSON serialization, with null for omitted ones.
+func payloadsToJSON(payloads [][]byte) []*string {
+	encoded := make([]*string, len(payloads))
+	for i, payload := range payloads {
+		if payload != nil {
+			s := b64(payload)
+			encoded[i] = &s
+		}
+	}
+	return encoded
+}
+
+// payloadsFromJSON decodes payloads of the JSON serialization.
+func payloadsFromJSON(encoded []*string) ([][]byte, error) {
+	payloads := make([][]byte, len(encoded))
+	for i, s := range encoded {
+		if s == nil {
+			continue
+		}
+		payload, err := unb64(*s)
+		if err != nil {
+			return nil, err
+		}
+		payloads[i] = nonNil(payload)
+	}
+	return payloads, nil
+}
+
+// proofFromJSON decodes the single proof part of the JSON serialization.
+func proofFromJSON(parts []string) ([]byte, error) {
+	if len(parts) != 1 {
+		return nil, fmt.Errorf("%w: expected a single proof part", ErrInvalidJWP)
+	}
+	return unb64(parts[0])
+}
+
+// nonNil distinguishes a disclosed zero-length payload from an omitted one.
+func nonNil(payload []byte) []byte {
+	if payload == nil {
+		return []byte{}
+	}
+	return payload
+}
+
+// b64 encodes octets as unpadded base64url.
+func b64(data []byte) string {
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// unb64 decodes unpadded base64url.
+func unb64(s string) ([]byte, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidJWP, err)
+	}
+	return data, nil
+}
@@ -0,0 +1,113 @@
+package jwp
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/aniagut/msc-bbs-anonymous-credentials/models"
+	"github.com/aniagut/msc-bbs-anonymous-credentials/setup"
+	"github.com/stretchr/testify/assert"
+)
+
+// MockIssued sets up an issuer and issues a JWP over three payloads, the last one empty
+func MockIssued(t *testing.T) (Issued, models.SetupResult) {
+	setupResult, err := setup.Setup(5)
+	assert.NoError(t, err, "Expected no error during setup")
+	issued, err := Issue(IssuerHeader{Kid: setupResult.PublicKey.KeyID(), Claims: []string{"name", "age", "note"}},
+		[][]byte{[]byte(`"Alice"`), []byte("30"), {}}, setupResult.PublicParameters, setupResult.SecretKey)
+	assert.NoError(t, err, "Expected no error issuing the JWP")
+	return issued, setupResult
+}
+
+// Test for the issued form and its serializations
+func TestIssued_RoundTrip(t *testing.T) {
+	issued, setupResult := MockIssued(t)
+	assert.NoError(t, issued.Verify(setupResult.PublicParameters, setupResult.PublicKey), "Expected the issued JWP to verify")
+
+	compact := issued.Compact()
+	assert.Len(t, strings.Split(compact, "."), 3, "Expected three parts in the issued form")
+	assert.True(t, strings.HasSuffix(strings.Split(compact, ".")[1], "~_"), "Expected the empty payload to be written as _")
+	parsed, err := ParseIssued(compact)
+	assert.NoError(t, err, "Expected no error parsing the compact form")
+	assert.Equal(t, issued, parsed, "Expected the parsed JWP to equal the original")
+
+	encoded, err := json.Marshal(issued)
+	assert.NoError(t, err, "Expected no error encoding the JSON form")
+	var decoded Issued
+	assert.NoError(t, json.Unmarshal(encoded, &decoded), "Expected no error decoding the JSON form")
+	assert.Equal(t, issued, decoded, "Expected the decoded JWP to equal the original")
+
+	header, err := parsed.IssuerHeader()
+	assert.NoError(t, err, "Expected a valid issuer header")
+	assert.Equal(t, Algorithm, header.Alg, "Expected the BBS algorithm")
+}
+
+// Test for the presented form and its serializations
+func TestPresented_RoundTrip(t *testing.T) {
+	issued, setupResult := MockIssued(t)
+	nonce := []byte("verifier-nonce")
+
+	presented, err := issued.Present(NewPresentationHeader(nonce, "https://verifier.example"), []int{1, 2}, setupResult.PublicParameters)
+	assert.NoError(t, err, "Expected no error presenting the JWP")
+	assert.Nil(t, presented.Payloads[0], "Expected the name to stay hidden")
+
+	compact := presented.Compact()
+	parts := strings.Split(compact, ".")
+	assert.Len(t, parts, 4, "Expected four parts in the presented form")
+	assert.Equal(t, "~MzA~_", parts[2], "Expected the hidden payload to be empty")
+
+	parsed, err := ParsePresented(compact)
+	assert.NoError(t, err, "Expected no error parsing the compact form")
+	assert.NoError(t, parsed.Verify(nonce, setupResult.PublicParameters, setupResult.PublicKey), "Expected the presented JWP to verify")
+
+	encoded, err := json.Marshal(presented)
+	assert.NoError(t, err, "Expected no error encoding the JSON form")
+	var decoded Presented
+	assert.NoError(t, json.Unmarshal(encoded, &decoded), "Expected no error decoding the JSON form")
+	assert.Equal(t, presented, decoded, "Expected the decoded JWP to equal the original")
+	assert.NoError(t, decoded.Verify(nonce, setupResult.PublicParameters, setupResult.PublicKey), "Expected the decoded JWP to verify")
+}
+
+// Test for presented JWPs that were tampered with
+func TestPresented_Tampered(t *testing.T) {
+	issued, setupResult := MockIssued(t)
+	nonce := []byte("verifier-nonce")
+	presented, err := issued.Present(NewPresentationHeader(nonce, ""), []int{1}, setupResult.PublicParameters)
+	assert.NoError(t, err, "Expected no error presenting the JWP")
+
+	err = presented.Verify([]byte("other-nonce"), setupResult.PublicParameters, setupResult.PublicKey)
+	assert.ErrorIs(t, err, models.ErrChallengeMismatch, "Expected a JWP for another nonce to fail")
+
+	modified := presented
+	modified.Payloads = [][]byte{nil, []byte("18"), nil}
+	assert.Error(t, modified.Verify(nonce, setupResult.PublicParameters, setupResult.PublicKey), "Expected a modified payload to fail")
+
+	modified = presented
+	modified.Payloads = [][]byte{[]byte(`"Alice"`), []byte("30"), nil}
+	assert.Error(t, modified.Verify(nonce, setupResult.PublicParameters, setupResult.PublicKey), "Expected an added payload to fail")
+
+	modified = presented
+	modified.Header = []byte(`{"alg":"BBS","kid":"other"}`)
+	assert.Error(t, modified.Verify(nonce, setupResult.PublicParameters, setupResult.PublicKey), "Expected a modified issuer header to fail")
+
+	modified = presented
+	modified.PresentationHeader = []byte(`{"alg":"BBS","nonce":"dmVyaWZpZXItbm9uY2U","aud":"x"}`)
+	assert.Error(t, modified.Verify(nonce, setupResult.PublicParameters, setupResult.PublicKey), "Expected a modified presentation header to fail")
+}
+
+// Test for malformed serializations
+func TestParse_Invalid(t *testing.T) {
+	_, err := ParseIssued("a.b")
+	assert.ErrorIs(t, err, ErrInvalidJWP, "Expected an error for missing parts")
+
+	_, err = ParseIssued("e30.~MzA.AA")
+	assert.ErrorIs(t, err, ErrInvalidJWP, "Expected an error for an omitted payload in the issued form")
+
+	_, err = ParsePresented("e30.e30.!!.AA")
+	assert.ErrorIs(t, err, ErrInvalidJWP, "Expected an error for invalid base64url")
+
+	issued, setupResult := MockIssued(t)
+	issued.Header = []byte(`{"alg":"ES256"}`)
+	assert.ErrorIs(t, issued.Verify(setupResult.PublicParameters, setupResult.PublicKey), ErrInvalidJWP, "Expected an error for another algorithm")
+}
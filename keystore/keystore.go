@@ -0,0 +1,357 @@
+// Package keystore stores issuer secret keys at rest in passphrase-encrypted files.
+//
+// The secret key is encrypted with AES-256-GCM under a key derived from the passphrase with Argon2id or scrypt.
+// The public key, its key ID and the digest of the public parameters are stored in cleartext so that a keystore
+// can be identified without the passphrase, and are authenticated as additional data of the encryption together
+// with the key derivation and cipher parameters.
+package keystore
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/aniagut/msc-bbs-anonymous-credentials/models"
+	e "github.com/cloudflare/circl/ecc/bls12381"
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/scrypt"
+)
+
+// Version is the version of the keystore format.
+const Version = 1
+
+// Key derivation functions and cipher of the keystore format.
+const (
+	KDFArgon2id  = "argon2id"
+	KDFScrypt    = "scrypt"
+	CipherAESGCM = "aes-256-gcm"
+)
+
+const (
+	// keySize is the size of the derived AES-256 key.
+	keySize = 32
+	// saltSize is the size of the random salt of the key derivation.
+	saltSize = 16
+	// maxArgon2Memory bounds the Argon2id memory, in KiB, accepted from a file (4 GiB).
+	maxArgon2Memory = 4 << 20
+	// maxArgon2Time bounds the Argon2id iterations accepted from a file.
+	maxArgon2Time = 64
+	// maxScryptN bounds the scrypt cost accepted from a file.
+	maxScryptN = 1 << 22
+)
+
+var (
+	// ErrInvalidKeystore is returned when a keystore is malformed or its metadata is inconsistent.
+	ErrInvalidKeystore = errors.New("invalid keystore")
+	// ErrDecryptionFailed is returned when the passphrase is wrong or the keystore has been modified.
+	ErrDecryptionFailed = errors.New("keystore decryption failed")
+	// ErrParametersMismatch is returned when the public parameters do not match the digest of a keystore.
+	ErrParametersMismatch = errors.New("public parameters do not match the keystore")
+)
+
+// KDF holds the parameters of the key derivation.
+// It contains the following elements:
+// - Name: The key derivation function, "argon2id" or "scrypt".
+// - Salt: The random salt, base64url encoded.
+// - Time: The number of Argon2id iterations.
+// - Memory: The Argon2id memory in KiB.
+// - Threads: The Argon2id parallelism.
+// - N: The scrypt CPU/memory cost, a power of two.
+// - R: The scrypt block size.
+// - P: The scrypt parallelism.
+type KDF struct {
+	Name    string `json:"name"`
+	Salt    string `json:"salt,omitempty"`
+	Time    uint32 `json:"time,omitempty"`
+	Memory  uint32 `json:"memory,omitempty"`
+	Threads uint8  `json:"threads,omitempty"`
+	N       int    `json:"n,omitempty"`
+	R       int    `json:"r,omitempty"`
+	P       int    `json:"p,omitempty"`
+}
+
+// Argon2id returns the recommended Argon2id parameters (3 iterations, 64 MiB, 4 threads).
+func Argon2id() KDF {
+	return KDF{Name: KDFArgon2id, Time: 3, Memory: 64 * 1024, Threads: 4}
+}
+
+// Scrypt returns the recommended scrypt parameters (N = 2^15, r = 8, p = 1).
+func Scrypt() KDF {
+	return KDF{Name: KDFScrypt, N: 1 << 15, R: 8, P: 1}
+}
+
+// File is an encrypted keystore.
+// It contains the following elements:
+// - Version: The version of the keystore format.
+// - KeyID: The key ID of the public key.
+// - PublicKey: The public key matching the encrypted secret key.
+// - ParametersDigest: The hex-encoded SHA-256 digest of the binary encoding of the public parameters.
+// - KDF: The parameters of the key derivation.
+// - Cipher: The cipher, always "aes-256-gcm".
+// - Nonce: The nonce of the encryption, base64url encoded.
+// - Ciphertext: The encrypted secret key, base64url encoded.
+type File struct {
+	Version          int              `json:"version"`
+	KeyID            string           `json:"keyId"`
+	PublicKey        models.PublicKey `json:"publicKey"`
+	ParametersDigest string           `json:"parametersDigest"`
+	KDF              KDF              `json:"kdf"`
+	Cipher           string           `json:"cipher"`
+	Nonce            string           `json:"nonce"`
+	Ciphertext       string           `json:"ciphertext"`
+}
+
+// ParametersDigest computes the digest of the public parameters stored in keystores.
+func ParametersDigest(publicParams models.PublicParameters) (string, error) {
+	encoded, err := publicParams.MarshalBinary()
+	if err != nil {
+		return "", err
+	}
+	digest := sha256.Sum256(encoded)
+	return hex.EncodeToString(digest[:]), nil
+}
+
+// Encrypt encrypts the secret key under the passphrase.
+//
+// Parameters:
+//   - secretKey: The secret key to store.
+//   - publicKey: The public key matching the secret key.
+//   - publicParams: The public parameters the key is used with.
+//   - passphrase: The passphrase.
+//   - kdf: The key derivation parameters, e.g. Argon2id(). A fresh salt is always generated.
+//
+// Returns:
+//   - File: The encrypted keystore.
+//   - error: An error if the keys do not match, the parameters are invalid or the encryption fails.
+func Encrypt(secretKey models.SecretKey, publicKey models.PublicKey, publicParams models.PublicParameters, passphrase []byte, kdf KDF) (File, error) {
+	// Step 1: Check that the secret key matches the public key
+	if err := checkKeyPair(secretKey, publicKey); err != nil {
+		return File{}, err
+	}
+	digest, err := ParametersDigest(publicParams)
+	if err != nil {
+		return File{}, err
+	}
+
+	// Step 2: Assemble the metadata and encrypt the secret key
+	return seal(File{
+		Version:          Version,
+		KeyID:            publicKey.KeyID(),
+		PublicKey:        publicKey,
+		ParametersDigest: digest,
+		KDF:              kdf,
+		Cipher:           CipherAESGCM,
+	}, secretKey, passphrase)
+}
+
+// Decrypt decrypts the secret key of the keystore.
+//
+// Parameters:
+//   - passphrase: The passphrase.
+//
+// Returns:
+//   - models.SecretKey: The secret key, checked against the public key.
+//   - error: An error if the keystore is malformed, the passphrase is wrong or the keystore has been modified.
+func (f File) Decrypt(passphrase []byte) (models.SecretKey, error) {
+	// Step 1: Derive the key from the passphrase and the stored parameters
+	aead, aad, err := f.aead(passphrase)
+	if err != nil {
+		return models.SecretKey{}, err
+	}
+	nonce, err := base64.RawURLEncoding.DecodeString(f.Nonce)
+	if err != nil || len(nonce) != aead.NonceSize() {
+		return models.SecretKey{}, fmt.Errorf("%w: invalid nonce", ErrInvalidKeystore)
+	}
+	ciphertext, err := base64.RawURLEncoding.DecodeString(f.Ciphertext)
+	if err != nil {
+		return models.SecretKey{}, fmt.Errorf("%w: invalid ciphertext", ErrInvalidKeystore)
+	}
+
+	// Step 2: Decrypt the secret key, authenticating the metadata
+	plaintext, err := aead.Open(nil, nonce, ciphertext, aad)
+	if err != nil {
+		return models.SecretKey{}, ErrDecryptionFailed
+	}
+	defer clear(plaintext)
+	var secretKey models.SecretKey
+	if err := secretKey.UnmarshalBinary(plaintext); err != nil {
+		return models.SecretKey{}, fmt.Errorf("%w: %v", ErrInvalidKeystore, err)
+	}
+
+	// Step 3: Check that the secret key matches the public key
+	if err := checkKeyPair(secretKey, f.PublicKey); err != nil {
+		return models.SecretKey{}, err
+	}
+	return secretKey, nil
+}
+
+// ChangePassphrase re-encrypts the secret key under a new passphrase with the same key derivation function and
+// cost, a fresh salt and a fresh nonce.
+//
+// Parameters:
+//   - oldPassphrase: The current passphrase.
+//   - newPassphrase: The new passphrase.
+//
+// Returns:
+//   - File: The re-encrypted keystore.
+//   - error: An error if the current passphrase is wrong or the keystore is invalid.
+func (f File) ChangePassphrase(oldPassphrase, newPassphrase []byte) (File, error) {
+	secretKey, err := f.Decrypt(oldPassphrase)
+	if err != nil {
+		return File{}, err
+	}
+	defer secretKey.Zeroize()
+	return seal(f, secretKey, newPassphrase)
+}
+
+// CheckParameters checks that the public parameters are the ones the keystore was created with.
+func (f File) CheckParameters(publicParams models.PublicParameters) error {
+	digest, err := ParametersDigest(publicParams)
+	if err != nil {
+		return err
+	}
+	if digest != f.ParametersDigest {
+		return fmt.Errorf("%w: digest %s, keystore %s", ErrParametersMismatch, digest, f.ParametersDigest)
+	}
+	return nil
+}
+
+// Load reads a keystore file.
+func Load(path string) (File, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return File{}, err
+	}
+	var f File
+	if err := json.Unmarshal(data, &f); err != nil {
+		return File{}, fmt.Errorf("%w: %v", ErrInvalidKeystore, err)
+	}
+	return f, nil
+}
+
+// Save writes the keystore to a file readable only by its owner.
+func (f File) Save(path string) error {
+	data, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o600)
+}
+
+// seal encrypts the secret key into the keystore with a fresh salt and nonce, authenticating its metadata.
+func seal(f File, secretKey models.SecretKey, passphrase []byte) (File, error) {
+	// Step 1: Generate a fresh salt
+	salt := make([]byte, saltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return File{}, err
+	}
+	f.KDF.Salt = base64.RawURLEncoding.EncodeToString(salt)
+
+	// Step 2: Derive the key and encrypt the secret key with the metadata as additional data
+	aead, aad, err := f.aead(passphrase)
+	if err != nil {
+		return File{}, err
+	}
+	plaintext, err := secretKey.MarshalBinary()
+	if err != nil {
+		return File{}, err
+	}
+	defer clear(plaintext)
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return File{}, err
+	}
+	f.Nonce = base64.RawURLEncoding.EncodeToString(nonce)
+	f.Ciphertext = base64.RawURLEncoding.EncodeToString(aead.Seal(nil, nonce, plaintext, aad))
+	return f, nil
+}
+
+// metadata is the cleartext part of a keystore authenticated as additional data.
+type metadata struct {
+	Version          int              `json:"version"`
+	KeyID            string           `json:"keyId"`
+	PublicKey        models.PublicKey `json:"publicKey"`
+	ParametersDigest string           `json:"parametersDigest"`
+	KDF              KDF              `json:"kdf"`
+	Cipher           string           `json:"cipher"`
+}
+
+// aead validates the metadata, derives the key from the passphrase and returns the cipher with the additional data.
+func (f File) aead(passphrase []byte) (cipher.AEAD, []byte, error) {
+	// Step 1: Validate the metadata
+	if f.Version != Version {
+		return nil, nil, fmt.Errorf("%w: unsupported version %d", ErrInvalidKeystore, f.Version)
+	}
+	if f.Cipher != CipherAESGCM {
+		return nil, nil, fmt.Errorf("%w: unsupported cipher %q", ErrInvalidKeystore, f.Cipher)
+	}
+	if f.PublicKey.X2 == nil || f.KeyID != f.PublicKey.KeyID() {
+		return nil, nil, fmt.Errorf("%w: key ID does not match the public key", ErrInvalidKeystore)
+	}
+	salt, err := base64.RawURLEncoding.DecodeString(f.KDF.Salt)
+	if err != nil || len(salt) < saltSize {
+		return nil, nil, fmt.Errorf("%w: invalid salt", ErrInvalidKeystore)
+	}
+
+	// Step 2: Derive the key with the bounded key derivation parameters
+	var key []byte
+	switch f.KDF.Name {
+	case KDFArgon2id:
+		if f.KDF.Time < 1 || f.KDF.Time > maxArgon2Time || f.KDF.Memory < 8*uint32(f.KDF.Threads) || f.KDF.Memory > maxArgon2Memory || f.KDF.Threads < 1 || f.KDF.N != 0 || f.KDF.R != 0 || f.KDF.P != 0 {
+			return nil, nil, fmt.Errorf("%w: invalid Argon2id parameters", ErrInvalidKeystore)
+		}
+		key = argon2.IDKey(passphrase, salt, f.KDF.Time, f.KDF.Memory, f.KDF.Threads, keySize)
+	case KDFScrypt:
+		if f.KDF.N < 2 || f.KDF.N > maxScryptN || f.KDF.N&(f.KDF.N-1) != 0 || f.KDF.R < 1 || f.KDF.P < 1 || f.KDF.R*f.KDF.P >= 1<<30 || f.KDF.Time != 0 || f.KDF.Memory != 0 || f.KDF.Threads != 0 {
+			return nil, nil, fmt.Errorf("%w: invalid scrypt parameters", ErrInvalidKeystore)
+		}
+		if key, err = scrypt.Key(passphrase, salt, f.KDF.N, f.KDF.R, f.KDF.P, keySize); err != nil {
+			return nil, nil, fmt.Errorf("%w: %v", ErrInvalidKeystore, err)
+		}
+	default:
+		return nil, nil, fmt.Errorf("%w: unsupported key derivation function %q", ErrInvalidKeystore, f.KDF.Name)
+	}
+	defer clear(key)
+
+	// Step 3: Create the cipher and encode the additional data
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, nil, err
+	}
+	aad, err := json.Marshal(metadata{
+		Version:          f.Version,
+		KeyID:            f.KeyID,
+		PublicKey:        f.PublicKey,
+		ParametersDigest: f.ParametersDigest,
+		KDF:              f.KDF,
+		Cipher:           f.Cipher,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return aead, aad, nil
+}
+
+// checkKeyPair checks that the secret key is non-zero and matches the public key.
+func checkKeyPair(secretKey models.SecretKey, publicKey models.PublicKey) error {
+	if secretKey.X == nil || secretKey.X.IsZero() == 1 || publicKey.X2 == nil {
+		return fmt.Errorf("%w: missing key", ErrInvalidKeystore)
+	}
+	x2 := new(e.G2)
+	x2.ScalarMult(secretKey.X, e.G2Generator())
+	if !x2.IsEqual(publicKey.X2) {
+		return fmt.Errorf("%w: secret key does not match the public key", ErrInvalidKeystore)
+	}
+	return nil
+}
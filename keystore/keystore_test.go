@@ -0,0 +1,112 @@
+package keystore
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"testing"
+
+	"github.com/aniagut/msc-bbs-anonymous-credentials/models"
+	"github.com/aniagut/msc-bbs-anonymous-credentials/setup"
+	"github.com/stretchr/testify/assert"
+)
+
+// fastKDFs are cheap key derivation parameters keeping the tests fast
+var fastKDFs = []KDF{
+	{Name: KDFArgon2id, Time: 1, Memory: 64, Threads: 1},
+	{Name: KDFScrypt, N: 1 << 4, R: 8, P: 1},
+}
+
+// MockKeystore encrypts a fresh secret key with the given key derivation parameters
+func MockKeystore(t *testing.T, kdf KDF) (File, models.SetupResult) {
+	result, err := setup.Setup(2)
+	assert.NoError(t, err, "Expected no error during setup")
+	f, err := Encrypt(result.SecretKey, result.PublicKey, result.PublicParameters, []byte("correct horse"), kdf)
+	assert.NoError(t, err, "Expected no error encrypting the secret key")
+	return f, result
+}
+
+// Test for encrypting, saving, loading and decrypting a keystore
+func TestKeystore_RoundTrip(t *testing.T) {
+	for _, kdf := range fastKDFs {
+		f, result := MockKeystore(t, kdf)
+		assert.Equal(t, result.PublicKey.KeyID(), f.KeyID, "Expected the key ID in cleartext")
+		assert.NoError(t, f.CheckParameters(result.PublicParameters), "Expected the parameters to match")
+
+		path := filepath.Join(t.TempDir(), "keystore.json")
+		assert.NoError(t, f.Save(path), "Expected no error saving the keystore")
+		loaded, err := Load(path)
+		assert.NoError(t, err, "Expected no error loading the keystore")
+
+		secretKey, err := loaded.Decrypt([]byte("correct horse"))
+		assert.NoError(t, err, "Expected no error decrypting with the right passphrase")
+		assert.Equal(t, 1, secretKey.X.IsEqual(result.SecretKey.X), "Expected the secret key to round trip")
+
+		_, err = loaded.Decrypt([]byte("wrong horse"))
+		assert.ErrorIs(t, err, ErrDecryptionFailed, "Expected an error with a wrong passphrase")
+	}
+}
+
+// Test for re-encrypting a keystore under a new passphrase
+func TestKeystore_ChangePassphrase(t *testing.T) {
+	f, result := MockKeystore(t, fastKDFs[0])
+
+	changed, err := f.ChangePassphrase([]byte("correct horse"), []byte("battery staple"))
+	assert.NoError(t, err, "Expected no error changing the passphrase")
+	assert.NotEqual(t, f.KDF.Salt, changed.KDF.Salt, "Expected a fresh salt")
+	assert.Equal(t, f.KDF.Memory, changed.KDF.Memory, "Expected the same key derivation cost")
+
+	secretKey, err := changed.Decrypt([]byte("battery staple"))
+	assert.NoError(t, err, "Expected no error decrypting with the new passphrase")
+	assert.Equal(t, 1, secretKey.X.IsEqual(result.SecretKey.X), "Expected the same secret key")
+	_, err = changed.Decrypt([]byte("correct horse"))
+	assert.ErrorIs(t, err, ErrDecryptionFailed, "Expected the old passphrase to be rejected")
+
+	_, err = f.ChangePassphrase([]byte("wrong horse"), []byte("battery staple"))
+	assert.ErrorIs(t, err, ErrDecryptionFailed, "Expected an error with a wrong current passphrase")
+}
+
+// Test for detecting modified metadata and mismatched parameters
+func TestKeystore_Tampering(t *testing.T) {
+	f, result := MockKeystore(t, fastKDFs[1])
+
+	modified := f
+	modified.ParametersDigest = "00" + f.ParametersDigest[2:]
+	_, err := modified.Decrypt([]byte("correct horse"))
+	assert.ErrorIs(t, err, ErrDecryptionFailed, "Expected the parameters digest to be authenticated")
+
+	other, err := setup.Setup(2)
+	assert.NoError(t, err, "Expected no error during setup")
+	swapped := f
+	swapped.PublicKey = other.PublicKey
+	swapped.KeyID = other.PublicKey.KeyID()
+	_, err = swapped.Decrypt([]byte("correct horse"))
+	assert.ErrorIs(t, err, ErrDecryptionFailed, "Expected the public key to be authenticated")
+
+	mismatched := f
+	mismatched.KeyID = other.PublicKey.KeyID()
+	_, err = mismatched.Decrypt([]byte("correct horse"))
+	assert.ErrorIs(t, err, ErrInvalidKeystore, "Expected a key ID not matching the public key to be rejected")
+
+	expensive := f
+	expensive.KDF.N = 1 << 30
+	_, err = expensive.Decrypt([]byte("correct horse"))
+	assert.ErrorIs(t, err, ErrInvalidKeystore, "Expected excessive key derivation parameters to be rejected")
+
+	assert.ErrorIs(t, f.CheckParameters(other.PublicParameters), ErrParametersMismatch, "Expected other parameters to be rejected")
+	_, err = Encrypt(other.SecretKey, result.PublicKey, result.PublicParameters, []byte("pass"), fastKDFs[0])
+	assert.ErrorIs(t, err, ErrInvalidKeystore, "Expected a secret key not matching the public key to be rejected")
+}
+
+// Test for the cleartext metadata of the file format
+func TestKeystore_Format(t *testing.T) {
+	f, _ := MockKeystore(t, fastKDFs[0])
+	encoded, err := json.Marshal(f)
+	assert.NoError(t, err, "Expected no error encoding the keystore")
+
+	var fields map[string]interface{}
+	assert.NoError(t, json.Unmarshal(encoded, &fields), "Expected no error decoding the keystore")
+	for _, name := range []string{"version", "keyId", "publicKey", "parametersDigest", "kdf", "cipher", "nonce", "ciphertext"} {
+		assert.Contains(t, fields, name, "Expected the keystore to contain "+name)
+	}
+	assert.Equal(t, CipherAESGCM, fields["cipher"], "Expected AES-256-GCM")
+}
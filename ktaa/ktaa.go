@@ -0,0 +1,394 @@
+// Package ktaa adds k-times anonymous authentication: within an epoch, a holder can authenticate at most k times
+// without being linked, and a verifier detects further authentications by duplicate tags.
+//
+// The credential holds a secret key as a hidden attribute. The j-th authentication of an epoch, for a counter
+// j in [0, k), reveals the tag T = B^(1 / (key + j)), where B is hashed from the epoch (the Dodis–Yampolskiy
+// pseudorandom function). Tags of distinct counters are unlinkable, and a holder authenticating more than k times
+// must reuse a counter, and thus a tag.
+//
+// The holder commits to the counter as C = g^j * h^ρ and proves, in the transcript of the presentation:
+//   - T^(r * key) * T^u = B^r, that is T^(key + j) = B for u = r * j;
+//   - C^r = g^u * h^w for w = r * ρ, so that u = r * j for the committed j;
+//   - j ∈ [0, k) with an OR-proof that C / g^i = h^ρ for one i in [0, k).
+package ktaa
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/aniagut/msc-bbs-anonymous-credentials/models"
+	"github.com/aniagut/msc-bbs-anonymous-credentials/presentation"
+	"github.com/aniagut/msc-bbs-anonymous-credentials/utils"
+	"github.com/aniagut/msc-bbs-anonymous-credentials/verify"
+	e "github.com/cloudflare/circl/ecc/bls12381"
+)
+
+// Domain separation tags of the hashes to G1 deriving the base of an epoch and the commitment base h.
+const (
+	epochBaseDST      = "BBS_KTAA_BLS12381G1_XMD:SHA-256_SSWU_RO_EPOCH_"
+	commitmentBaseDST = "BBS_KTAA_BLS12381G1_XMD:SHA-256_SSWU_RO_COMMITMENT_"
+)
+
+// MaxLimit bounds the number of authentications per epoch, as the range proof grows linearly with it.
+const MaxLimit = 1024
+
+var (
+	// ErrInvalidAuthentication is returned when an authentication is malformed or used out of order.
+	ErrInvalidAuthentication = errors.New("invalid k-times authentication")
+	// ErrInvalidPolicy is returned when the limit or the key index of a policy is invalid.
+	ErrInvalidPolicy = errors.New("invalid k-times authentication policy")
+	// ErrCounterOutOfRange is returned when the holder's counter is not in [0, k).
+	ErrCounterOutOfRange = errors.New("counter out of range")
+	// ErrOveruse is returned when a tag has already been used in an epoch.
+	ErrOveruse = errors.New("authentication limit exceeded in this epoch")
+)
+
+// Policy holds the parameters of the verifier.
+// It contains the following elements:
+// - Epoch: The current epoch (e.g. the date).
+// - Limit: The number k of authentications allowed per epoch.
+// - KeyIndex: The index of the holder's secret key in the credential.
+type Policy struct {
+	Epoch    string
+	Limit    int
+	KeyIndex int
+}
+
+// Authentication is revealed by a k-times authentication.
+// It contains the following elements:
+// - Tag: The tag T = B^(1 / (key + j)).
+// - Commitment: The commitment C = g^j * h^ρ to the counter.
+// - Zu: The response for u = r * j.
+// - Zw: The response for w = r * ρ.
+// - RangeCh: The challenges of the branches of the range proof, summing to the challenge of the presentation.
+// - RangeZ: The responses of the branches of the range proof.
+type Authentication struct {
+	Tag        *e.G1
+	Commitment *e.G1
+	Zu         *e.Scalar
+	Zw         *e.Scalar
+	RangeCh    []e.Scalar
+	RangeZ     []e.Scalar
+}
+
+// Prover is the holder side of a k-times authentication, passed to presentation.PresentationWithExtensions.
+type Prover struct {
+	policy  Policy
+	counter int
+	// Secrets of the proof, zeroized once the proof is complete
+	j, rho, u, w, vU, vW, a e.Scalar
+	auth                    Authentication
+	state                   int
+}
+
+// States of a Prover.
+const (
+	stateNew = iota
+	stateCommitted
+	stateDone
+)
+
+// NewProver creates the holder side of the authentication with the counter, which must be in [0, k) and must not
+// have been used in the epoch.
+func NewProver(policy Policy, counter int) *Prover {
+	return &Prover{policy: policy, counter: counter}
+}
+
+// Commit implements presentation.Extension.
+func (p *Prover) Commit(witness presentation.Witness) ([]byte, error) {
+	// Step 1: Check the policy and the counter and find the secret key among the hidden attributes
+	if p.state != stateNew {
+		return nil, fmt.Errorf("%w: prover already used", ErrInvalidAuthentication)
+	}
+	if err := checkPolicy(p.policy); err != nil {
+		return nil, err
+	}
+	if p.counter < 0 || p.counter >= p.policy.Limit {
+		return nil, fmt.Errorf("%w: counter %d, limit %d", ErrCounterOutOfRange, p.counter, p.policy.Limit)
+	}
+	position, err := witness.Position(p.policy.KeyIndex)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidAuthentication, err)
+	}
+	B, h := bases(p.policy.Epoch)
+	g := e.G1Generator()
+
+	// Step 2: Compute the tag T ← B^(1 / (key + j))
+	p.j.SetUint64(uint64(p.counter))
+	exponent := new(e.Scalar)
+	exponent.Add(&witness.Attributes[position], &p.j)
+	defer utils.ZeroizeScalars(exponent)
+	if exponent.IsZero() == 1 {
+		return nil, fmt.Errorf("%w: key + j = 0", ErrInvalidAuthentication)
+	}
+	exponent.Inv(exponent)
+	T := new(e.G1)
+	T.ScalarMult(exponent, B)
+
+	// Step 3: Commit to the counter C ← g^j * h^ρ and compute u ← r * j and w ← r * ρ
+	if p.rho, err = utils.RandomScalar(); err != nil {
+		return nil, err
+	}
+	C := utils.LinearCombination([]*e.G1{g, h}, []*e.Scalar{&p.j, &p.rho})
+	p.u.Mul(witness.R, &p.j)
+	p.w.Mul(witness.R, &p.rho)
+
+	// Step 4: Compute the commitments of the linear relations
+	// U1 ← T^(v[key] + vU) * B^(-vR) and U2 ← C^vR * g^(-vU) * h^(-vW)
+	if p.vU, err = utils.RandomScalar(); err != nil {
+		return nil, err
+	}
+	if p.vW, err = utils.RandomScalar(); err != nil {
+		return nil, err
+	}
+	p.auth = Authentication{Tag: T, Commitment: C}
+	U1, U2 := relationCommitments(p.auth, B, h, witness.VR, &witness.V[position], &p.vU, &p.vW)
+
+	// Step 5: Commit to the branches of the range proof, simulating every branch but the one of the counter
+	// A[i] ← h^a for i = j, and A[i] ← h^z[i] * (C / g^i)^(-ch[i]) for random ch[i], z[i] otherwise
+	p.auth.RangeCh = make([]e.Scalar, p.policy.Limit)
+	p.auth.RangeZ = make([]e.Scalar, p.policy.Limit)
+	A := make([]*e.G1, p.policy.Limit)
+	if p.a, err = utils.RandomScalar(); err != nil {
+		return nil, err
+	}
+	for i := range A {
+		if i == p.counter {
+			A[i] = new(e.G1)
+			A[i].ScalarMult(&p.a, h)
+			continue
+		}
+		if p.auth.RangeCh[i], err = utils.RandomScalar(); err != nil {
+			return nil, err
+		}
+		if p.auth.RangeZ[i], err = utils.RandomScalar(); err != nil {
+			return nil, err
+		}
+		A[i] = branchCommitment(C, g, h, i, &p.auth.RangeCh[i], &p.auth.RangeZ[i])
+	}
+
+	p.state = stateCommitted
+	return transcript(p.policy, p.auth, U1, U2, A), nil
+}
+
+// Respond implements presentation.Extension.
+func (p *Prover) Respond(ch *e.Scalar) error {
+	if p.state != stateCommitted {
+		return fmt.Errorf("%w: prover not committed", ErrInvalidAuthentication)
+	}
+
+	// Step 1: Compute zU ← vU + ch * u and zW ← vW + ch * w
+	p.auth.Zu, p.auth.Zw = new(e.Scalar), new(e.Scalar)
+	p.auth.Zu.Mul(ch, &p.u)
+	p.auth.Zu.Add(p.auth.Zu, &p.vU)
+	p.auth.Zw.Mul(ch, &p.w)
+	p.auth.Zw.Add(p.auth.Zw, &p.vW)
+
+	// Step 2: Complete the branch of the counter with ch[j] ← ch - Σ_{i≠j} ch[i] and z[j] ← a + ch[j] * ρ
+	chJ := &p.auth.RangeCh[p.counter]
+	chJ.Set(ch)
+	for i := range p.auth.RangeCh {
+		if i != p.counter {
+			chJ.Sub(chJ, &p.auth.RangeCh[i])
+		}
+	}
+	zJ := &p.auth.RangeZ[p.counter]
+	zJ.Mul(chJ, &p.rho)
+	zJ.Add(zJ, &p.a)
+
+	utils.ZeroizeScalars(&p.j, &p.rho, &p.u, &p.w, &p.vU, &p.vW, &p.a)
+	p.state = stateDone
+	return nil
+}
+
+// Authentication returns the authentication to send to the verifier with the presentation.
+func (p *Prover) Authentication() (Authentication, error) {
+	if p.state != stateDone {
+		return Authentication{}, fmt.Errorf("%w: presentation not completed", ErrInvalidAuthentication)
+	}
+	return p.auth, nil
+}
+
+// check is the verifier side of a k-times authentication.
+type check struct {
+	policy Policy
+	auth   Authentication
+}
+
+// Check returns the verifier side of a k-times authentication, passed to verify.VerifyWithExtensions to check that
+// the tag is the pseudorandom function of the holder's key for the epoch and a counter in [0, k).
+func Check(policy Policy, auth Authentication) verify.Extension {
+	return check{policy: policy, auth: auth}
+}
+
+// Commit implements verify.Extension.
+func (c check) Commit(responses verify.Responses) ([]byte, error) {
+	// Step 1: Validate the policy and the authentication and find the response of the secret key
+	if err := checkPolicy(c.policy); err != nil {
+		return nil, err
+	}
+	if err := checkAuthentication(c.auth, c.policy.Limit); err != nil {
+		return nil, err
+	}
+	position, err := responses.Position(c.policy.KeyIndex)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidAuthentication, err)
+	}
+	B, h := bases(c.policy.Epoch)
+	g := e.G1Generator()
+
+	// Step 2: Recompute U1 ← T^(Zi[key] + Zu) * B^(-Zr) and U2 ← C^Zr * g^(-Zu) * h^(-Zw)
+	U1, U2 := relationCommitments(c.auth, B, h, responses.Zr, &responses.Z[position], c.auth.Zu, c.auth.Zw)
+
+	// Step 3: Check that the branch challenges sum to the challenge and recompute A[i] ← h^z[i] * (C / g^i)^(-ch[i])
+	sum := new(e.Scalar)
+	A := make([]*e.G1, c.policy.Limit)
+	for i := range A {
+		sum.Add(sum, &c.auth.RangeCh[i])
+		A[i] = branchCommitment(c.auth.Commitment, g, h, i, &c.auth.RangeCh[i], &c.auth.RangeZ[i])
+	}
+	if sum.IsEqual(responses.Ch) != 1 {
+		return nil, fmt.Errorf("%w: range challenges do not sum to the challenge", models.ErrChallengeMismatch)
+	}
+	return transcript(c.policy, c.auth, U1, U2, A), nil
+}
+
+// Store records the tags used in every epoch.
+// Implementations must be safe for concurrent use, and Add must be atomic, so that concurrent authentications
+// with the same tag cannot both be accepted.
+type Store interface {
+	// Add records the tag in the epoch. It returns ErrOveruse if the tag was already used in the epoch.
+	Add(epoch string, tag *e.G1) error
+}
+
+// MemoryStore is an in-memory Store.
+type MemoryStore struct {
+	mu     sync.Mutex
+	epochs map[string]map[string]bool
+}
+
+// NewMemoryStore creates an empty in-memory tag store.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{epochs: make(map[string]map[string]bool)}
+}
+
+// Add records the tag in the epoch.
+func (s *MemoryStore) Add(epoch string, tag *e.G1) error {
+	if tag == nil {
+		return fmt.Errorf("%w: missing tag", ErrInvalidAuthentication)
+	}
+	key := string(tag.BytesCompressed())
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	tags, ok := s.epochs[epoch]
+	if !ok {
+		tags = make(map[string]bool)
+		s.epochs[epoch] = tags
+	}
+	if tags[key] {
+		return ErrOveruse
+	}
+	tags[key] = true
+	return nil
+}
+
+// DropEpoch forgets the tags of an epoch that has ended.
+func (s *MemoryStore) DropEpoch(epoch string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.epochs, epoch)
+}
+
+// Accept verifies a k-times authentication and records its tag in the store.
+//
+// Parameters:
+//   - store: The store of used tags.
+//   - zkpProof: The proof of the presentation.
+//   - auth: The authentication of the presentation.
+//   - nonce: The nonce the presentation is bound to.
+//   - revealedAttributes: The list of revealed attributes.
+//   - revealedIndices: The list of indices for revealed attributes.
+//   - publicParams: The public parameters of the issuer.
+//   - publicKey: The public key of the issuer.
+//   - policy: The epoch, the limit and the index of the secret key.
+//
+// Returns:
+//   - error: ErrOveruse if the tag was already used in the epoch, or an error if the presentation does not verify.
+func Accept(store Store, zkpProof models.SignatureProof, auth Authentication, nonce []byte, revealedAttributes []string, revealedIndices []int, publicParams models.PublicParameters, publicKey models.PublicKey, policy Policy) error {
+	if _, err := verify.VerifyWithExtensions(zkpProof, nonce, revealedAttributes, revealedIndices, publicParams, publicKey, Check(policy, auth)); err != nil {
+		return err
+	}
+	return store.Add(policy.Epoch, auth.Tag)
+}
+
+// bases derives the base B of the epoch and the commitment base h.
+func bases(epoch string) (*e.G1, *e.G1) {
+	B := new(e.G1)
+	B.Hash([]byte(epoch), []byte(epochBaseDST))
+	h := new(e.G1)
+	h.Hash([]byte("commitment base"), []byte(commitmentBaseDST))
+	return B, h
+}
+
+// relationCommitments computes U1 ← T^(b + c) * B^(-a) and U2 ← C^a * g^(-c) * h^(-d), with the blindings
+// (a, b, c, d) of (r, r * key, u, w) on the holder side and the responses on the verifier side.
+func relationCommitments(auth Authentication, B, h *e.G1, a, b, c, d *e.Scalar) (*e.G1, *e.G1) {
+	bc, negA, negC, negD := new(e.Scalar), utils.Negate(a), utils.Negate(c), utils.Negate(d)
+	bc.Add(b, c)
+	defer utils.ZeroizeScalars(bc, negA, negC, negD)
+	U1 := utils.LinearCombination([]*e.G1{auth.Tag, B}, []*e.Scalar{bc, negA})
+	U2 := utils.LinearCombination([]*e.G1{auth.Commitment, e.G1Generator(), h}, []*e.Scalar{a, negC, negD})
+	return U1, U2
+}
+
+// branchCommitment computes A[i] ← h^z * (C / g^i)^(-ch).
+func branchCommitment(C, g, h *e.G1, i int, ch, z *e.Scalar) *e.G1 {
+	index := new(e.Scalar)
+	index.SetUint64(uint64(i))
+	shifted := new(e.G1)
+	shifted.ScalarMult(index, g)
+	shifted.Neg()
+	shifted.Add(shifted, C)
+	return utils.LinearCombination([]*e.G1{h, shifted}, []*e.Scalar{z, utils.Negate(ch)})
+}
+
+// checkPolicy checks the limit and the key index of a policy.
+func checkPolicy(policy Policy) error {
+	if policy.Limit < 1 || policy.Limit > MaxLimit {
+		return fmt.Errorf("%w: limit %d not in [1, %d]", ErrInvalidPolicy, policy.Limit, MaxLimit)
+	}
+	if policy.KeyIndex < 0 {
+		return fmt.Errorf("%w: key index %d", ErrInvalidPolicy, policy.KeyIndex)
+	}
+	return nil
+}
+
+// checkAuthentication checks the structure of an authentication for the limit.
+func checkAuthentication(auth Authentication, limit int) error {
+	if auth.Tag == nil || auth.Commitment == nil || auth.Zu == nil || auth.Zw == nil {
+		return fmt.Errorf("%w: missing component", ErrInvalidAuthentication)
+	}
+	if auth.Tag.IsIdentity() || !auth.Tag.IsOnG1() || !auth.Commitment.IsOnG1() {
+		return fmt.Errorf("%w: tag or commitment is not a valid element of G1", ErrInvalidAuthentication)
+	}
+	if len(auth.RangeCh) != limit || len(auth.RangeZ) != limit {
+		return fmt.Errorf("%w: range proof of %d branches, limit %d", ErrInvalidAuthentication, len(auth.RangeCh), limit)
+	}
+	return nil
+}
+
+// transcript serializes the statement and the commitments of a k-times authentication proof.
+func transcript(policy Policy, auth Authentication, U1, U2 *e.G1, A []*e.G1) []byte {
+	out := []byte("k-times-authentication")
+	out = binary.BigEndian.AppendUint64(out, uint64(policy.Limit))
+	out = binary.BigEndian.AppendUint64(out, uint64(policy.KeyIndex))
+	out = binary.BigEndian.AppendUint64(out, uint64(len(policy.Epoch)))
+	out = append(out, policy.Epoch...)
+	for _, element := range append([]*e.G1{auth.Tag, auth.Commitment, U1, U2}, A...) {
+		out = append(out, utils.SerializeG1(element)...)
+	}
+	return out
+}
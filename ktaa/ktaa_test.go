@@ -0,0 +1,97 @@
+package ktaa
+
+import (
+	"testing"
+
+	"github.com/aniagut/msc-bbs-anonymous-credentials/internal/testutil"
+	"github.com/aniagut/msc-bbs-anonymous-credentials/models"
+	"github.com/aniagut/msc-bbs-anonymous-credentials/presentation"
+	e "github.com/cloudflare/circl/ecc/bls12381"
+	"github.com/stretchr/testify/assert"
+)
+
+// MockCredential issues a credential holding a secret key (secret key, country)
+func MockCredential(t *testing.T) ([]string, models.Signature, models.SetupResult) {
+	return testutil.MockCredential(t, "k-3e9d71a04bc25f18", "PL")
+}
+
+// authenticate presents the credential with the counter, revealing the country
+func authenticate(attributes []string, signature models.Signature, result models.SetupResult, policy Policy, counter int, nonce []byte) (models.SignatureProof, Authentication, error) {
+	prover := NewProver(policy, counter)
+	proof, err := presentation.PresentationWithExtensions(attributes, signature, []int{1}, result.PublicParameters, nonce, prover)
+	if err != nil {
+		return models.SignatureProof{}, Authentication{}, err
+	}
+	auth, err := prover.Authentication()
+	return proof, auth, err
+}
+
+// Test for accepting k unlinkable authentications per epoch and detecting the next one
+func TestKTimes_Overuse(t *testing.T) {
+	attributes, signature, result := MockCredential(t)
+	store := NewMemoryStore()
+	policy := Policy{Epoch: "2026-10-18", Limit: 3, KeyIndex: 0}
+	accept := func(proof models.SignatureProof, auth Authentication, nonce []byte, policy Policy) error {
+		return Accept(store, proof, auth, nonce, []string{"PL"}, []int{1}, result.PublicParameters, result.PublicKey, policy)
+	}
+
+	// k authentications with distinct counters are accepted, with distinct tags
+	tags := make([]*e.G1, 0, policy.Limit)
+	for counter := 0; counter < policy.Limit; counter++ {
+		nonce := []byte{byte(counter)}
+		proof, auth, err := authenticate(attributes, signature, result, policy, counter, nonce)
+		assert.NoError(t, err, "Expected no error authenticating")
+		assert.NoError(t, accept(proof, auth, nonce, policy), "Expected authentication %d to be accepted", counter)
+		for _, tag := range tags {
+			assert.False(t, tag.IsEqual(auth.Tag), "Expected distinct tags")
+		}
+		tags = append(tags, auth.Tag)
+	}
+
+	// The k+1-th authentication cannot use a counter out of range, and reusing a counter is detected
+	_, _, err := authenticate(attributes, signature, result, policy, policy.Limit, []byte("overflow"))
+	assert.ErrorIs(t, err, ErrCounterOutOfRange, "Expected the counter k to be refused")
+	proof, auth, err := authenticate(attributes, signature, result, policy, 1, []byte("reuse"))
+	assert.NoError(t, err, "Expected no error authenticating")
+	assert.ErrorIs(t, accept(proof, auth, []byte("reuse"), policy), ErrOveruse, "Expected a reused counter to be detected")
+
+	// The next epoch has fresh tags
+	next := Policy{Epoch: "2026-10-19", Limit: 3, KeyIndex: 0}
+	proof, auth, err = authenticate(attributes, signature, result, next, 1, []byte("next"))
+	assert.NoError(t, err, "Expected no error authenticating")
+	assert.False(t, auth.Tag.IsEqual(tags[1]), "Expected tags of another epoch to be unlinkable")
+	assert.NoError(t, accept(proof, auth, []byte("next"), next), "Expected an authentication in the next epoch to be accepted")
+
+	// Dropping an epoch forgets its tags
+	store.DropEpoch(next.Epoch)
+	assert.NoError(t, store.Add(next.Epoch, auth.Tag), "Expected the tag to be forgotten")
+}
+
+// Test for rejecting authentications that do not match the policy or the proof
+func TestKTimes_Rejections(t *testing.T) {
+	attributes, signature, result := MockCredential(t)
+	policy := Policy{Epoch: "2026-10-18", Limit: 2, KeyIndex: 0}
+	proof, auth, err := authenticate(attributes, signature, result, policy, 1, []byte("nonce"))
+	assert.NoError(t, err, "Expected no error authenticating")
+	accept := func(auth Authentication, policy Policy) error {
+		return Accept(NewMemoryStore(), proof, auth, []byte("nonce"), []string{"PL"}, []int{1}, result.PublicParameters, result.PublicKey, policy)
+	}
+
+	// A fresh tag would escape overuse detection
+	forged := auth
+	forged.Tag = e.G1Generator()
+	assert.ErrorIs(t, accept(forged, policy), models.ErrChallengeMismatch, "Expected a forged tag to be rejected")
+
+	// The range proof is bound to the limit and its challenges to the challenge of the presentation
+	assert.ErrorIs(t, accept(auth, Policy{Epoch: policy.Epoch, Limit: 3, KeyIndex: 0}), ErrInvalidAuthentication, "Expected another limit to be rejected")
+	shifted := auth
+	shifted.RangeCh = append([]e.Scalar(nil), auth.RangeCh...)
+	shifted.RangeCh[0].SetUint64(7)
+	assert.ErrorIs(t, accept(shifted, policy), models.ErrChallengeMismatch, "Expected modified range challenges to be rejected")
+
+	// The tag is bound to the epoch and to a hidden key
+	assert.ErrorIs(t, accept(auth, Policy{Epoch: "2026-10-19", Limit: 2, KeyIndex: 0}), models.ErrChallengeMismatch, "Expected another epoch to be rejected")
+	assert.ErrorIs(t, accept(auth, Policy{Epoch: policy.Epoch, Limit: 2, KeyIndex: 1}), ErrInvalidAuthentication, "Expected a revealed key to be rejected")
+	assert.ErrorIs(t, accept(auth, Policy{Epoch: policy.Epoch, Limit: 0, KeyIndex: 0}), ErrInvalidPolicy, "Expected an empty limit to be rejected")
+	assert.NoError(t, accept(auth, policy), "Expected the untouched authentication to be accepted")
+}
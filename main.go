@@ -3,10 +3,11 @@ package main
 import (
 	"fmt"
 	"log"
+	"time"
+	"github.com/aniagut/msc-bbs-anonymous-credentials/nonce"
 	"github.com/aniagut/msc-bbs-anonymous-credentials/setup"
 	"github.com/aniagut/msc-bbs-anonymous-credentials/issue"
 	"github.com/aniagut/msc-bbs-anonymous-credentials/presentation"
-	"github.com/aniagut/msc-bbs-anonymous-credentials/verify"
 )
 
 func main() {
@@ -27,18 +28,24 @@ func main() {
 	}
 	fmt.Printf("Credential issued successfully!\n")
 
+	// The verifier issues a fresh single-use nonce for the presentation
+	nonces := nonce.NewManager(nonce.NewMemoryStore(), time.Minute)
+	challenge, err := nonces.Issue()
+	if err != nil {
+		log.Fatalf("Error issuing nonce: %v", err)
+	}
+
 	// Example usage of the presentation function
 	revealed := []int{0, 4} // Indices of revealed attributes
-	nonce := []byte("random_nonce") // Random nonce
-	proof, err := presentation.Presentation(attributes, signature, revealed, result.PublicParameters, nonce)
+	proof, err := presentation.Presentation(attributes, signature, revealed, result.PublicParameters, challenge)
 	if err != nil {
 		log.Fatalf("Error during presentation: %v", err)
 	}
 	fmt.Printf("Presentation completed successfully!\n")
 
-	// Example usage of the verify function
+	// Example usage of the verify function, rejecting reused or expired nonces
 	revealedAttributes := []string{"attribute1", "attribute5"}
-	isValid, err := verify.Verify(proof, nonce, revealedAttributes, revealed, result.PublicParameters, result.PublicKey)
+	isValid, err := nonces.Verify(proof, challenge, revealedAttributes, revealed, result.PublicParameters, result.PublicKey)
 	if err != nil {
 		log.Fatalf("Error during verification: %v", err)
 	}
@@ -0,0 +1,203 @@
+package models
+
+import (
+	"fmt"
+
+	"github.com/aniagut/msc-bbs-anonymous-credentials/cbor"
+	e "github.com/cloudflare/circl/ecc/bls12381"
+)
+
+// CBOR encodings are deterministic arrays of byte strings holding the binary encodings of the elements:
+// - PublicParameters: [G1, G2, [H1[0], ..., H1[l-1]]].
+// - PublicKey: X2.
+// - Signature: [A, E].
+// - SignatureProof: [APrim, BPrim, Ch, Zr, Ze, [Zi[0], ..., Zi[n-1]]].
+
+// MarshalCBOR encodes the public parameters as CBOR.
+func (p PublicParameters) MarshalCBOR() ([]byte, error) {
+	if p.G1 == nil || p.G2 == nil {
+		return nil, fmt.Errorf("%w: missing generator", ErrInvalidEncoding)
+	}
+	h1 := make([]interface{}, len(p.H1))
+	for i := range p.H1 {
+		h1[i] = p.H1[i].BytesCompressed()
+	}
+	return cbor.Marshal([]interface{}{p.G1.BytesCompressed(), p.G2.BytesCompressed(), h1})
+}
+
+// UnmarshalCBOR decodes the public parameters, checking that every element is in its group.
+func (p *PublicParameters) UnmarshalCBOR(data []byte) error {
+	items, err := cborArray(data, 3)
+	if err != nil {
+		return err
+	}
+	g1, err := cborG1(items[0])
+	if err != nil {
+		return err
+	}
+	g2Bytes, ok := items[1].([]byte)
+	if !ok {
+		return fmt.Errorf("%w: G2 is not a byte string", ErrInvalidEncoding)
+	}
+	g2, err := decodeG2(g2Bytes)
+	if err != nil {
+		return err
+	}
+	hItems, ok := items[2].([]interface{})
+	if !ok {
+		return fmt.Errorf("%w: H1 is not an array", ErrInvalidEncoding)
+	}
+	h1 := make([]e.G1, len(hItems))
+	for i, item := range hItems {
+		h, err := cborG1(item)
+		if err != nil {
+			return err
+		}
+		h1[i] = *h
+	}
+	*p = PublicParameters{G1: g1, G2: g2, H1: h1}
+	return nil
+}
+
+// MarshalCBOR encodes the public key as CBOR.
+func (pk PublicKey) MarshalCBOR() ([]byte, error) {
+	b, err := pk.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	return cbor.Marshal(b)
+}
+
+// UnmarshalCBOR decodes the public key, checking that X2 is a non-identity element of G2.
+func (pk *PublicKey) UnmarshalCBOR(data []byte) error {
+	value, err := cbor.Unmarshal(data)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidEncoding, err)
+	}
+	b, ok := value.([]byte)
+	if !ok {
+		return fmt.Errorf("%w: public key is not a byte string", ErrInvalidEncoding)
+	}
+	return pk.UnmarshalBinary(b)
+}
+
+// MarshalCBOR encodes the signature as CBOR.
+func (s Signature) MarshalCBOR() ([]byte, error) {
+	if s.A == nil || s.E == nil {
+		return nil, fmt.Errorf("%w: missing signature component", ErrInvalidEncoding)
+	}
+	eBytes, err := s.E.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	return cbor.Marshal([]interface{}{s.A.BytesCompressed(), eBytes})
+}
+
+// UnmarshalCBOR decodes the signature.
+func (s *Signature) UnmarshalCBOR(data []byte) error {
+	items, err := cborArray(data, 2)
+	if err != nil {
+		return err
+	}
+	a, err := cborG1(items[0])
+	if err != nil {
+		return err
+	}
+	elem, err := cborScalar(items[1])
+	if err != nil {
+		return err
+	}
+	*s = Signature{A: a, E: elem}
+	return nil
+}
+
+// MarshalCBOR encodes the signature proof as CBOR.
+func (p SignatureProof) MarshalCBOR() ([]byte, error) {
+	if p.APrim == nil || p.BPrim == nil || p.Ch == nil || p.Zr == nil || p.Ze == nil {
+		return nil, fmt.Errorf("%w: missing proof component", ErrInvalidEncoding)
+	}
+	items := []interface{}{p.APrim.BytesCompressed(), p.BPrim.BytesCompressed()}
+	for _, s := range []*e.Scalar{p.Ch, p.Zr, p.Ze} {
+		b, err := s.MarshalBinary()
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, b)
+	}
+	zi := make([]interface{}, len(p.Zi))
+	for i := range p.Zi {
+		b, err := p.Zi[i].MarshalBinary()
+		if err != nil {
+			return nil, err
+		}
+		zi[i] = b
+	}
+	return cbor.Marshal(append(items, zi))
+}
+
+// UnmarshalCBOR decodes the signature proof, checking that APrim and BPrim are elements of G1.
+func (p *SignatureProof) UnmarshalCBOR(data []byte) error {
+	items, err := cborArray(data, 6)
+	if err != nil {
+		return err
+	}
+	aPrim, err := cborG1(items[0])
+	if err != nil {
+		return err
+	}
+	bPrim, err := cborG1(items[1])
+	if err != nil {
+		return err
+	}
+	scalars := make([]*e.Scalar, 3)
+	for i := range scalars {
+		if scalars[i], err = cborScalar(items[2+i]); err != nil {
+			return err
+		}
+	}
+	zItems, ok := items[5].([]interface{})
+	if !ok {
+		return fmt.Errorf("%w: Zi is not an array", ErrInvalidEncoding)
+	}
+	zi := make([]e.Scalar, len(zItems))
+	for i, item := range zItems {
+		z, err := cborScalar(item)
+		if err != nil {
+			return err
+		}
+		zi[i] = *z
+	}
+	*p = SignatureProof{APrim: aPrim, BPrim: bPrim, Ch: scalars[0], Zr: scalars[1], Ze: scalars[2], Zi: zi}
+	return nil
+}
+
+// cborArray decodes a CBOR array of the given length.
+func cborArray(data []byte, length int) ([]interface{}, error) {
+	value, err := cbor.Unmarshal(data)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidEncoding, err)
+	}
+	items, ok := value.([]interface{})
+	if !ok || len(items) != length {
+		return nil, fmt.Errorf("%w: expected an array of %d items", ErrInvalidEncoding, length)
+	}
+	return items, nil
+}
+
+// cborG1 decodes a byte string holding a compressed element of G1.
+func cborG1(item interface{}) (*e.G1, error) {
+	b, ok := item.([]byte)
+	if !ok {
+		return nil, fmt.Errorf("%w: G1 element is not a byte string", ErrInvalidEncoding)
+	}
+	return decodeG1(b)
+}
+
+// cborScalar decodes a byte string holding a scalar.
+func cborScalar(item interface{}) (*e.Scalar, error) {
+	b, ok := item.([]byte)
+	if !ok {
+		return nil, fmt.Errorf("%w: scalar is not a byte string", ErrInvalidEncoding)
+	}
+	return decodeScalar(b)
+}
@@ -0,0 +1,70 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/aniagut/msc-bbs-anonymous-credentials/cbor"
+	e "github.com/cloudflare/circl/ecc/bls12381"
+	"github.com/stretchr/testify/assert"
+)
+
+// Test for the CBOR round trip of keys, signatures and proofs
+func TestCBOR_RoundTrip(t *testing.T) {
+	x := new(e.Scalar)
+	x.SetUint64(12345)
+	x2 := new(e.G2)
+	x2.ScalarMult(x, e.G2Generator())
+
+	params := PublicParameters{G1: e.G1Generator(), G2: e.G2Generator(), H1: []e.G1{*e.G1Generator()}}
+	data, err := params.MarshalCBOR()
+	assert.NoError(t, err, "Expected no error encoding the public parameters")
+	var decodedParams PublicParameters
+	assert.NoError(t, decodedParams.UnmarshalCBOR(data), "Expected no error decoding the public parameters")
+	assert.True(t, decodedParams.G2.IsEqual(params.G2), "Expected G2 to round trip")
+	assert.Len(t, decodedParams.H1, 1, "Expected H1 to round trip")
+
+	pk := PublicKey{X2: x2}
+	data, err = pk.MarshalCBOR()
+	assert.NoError(t, err, "Expected no error encoding the public key")
+	assert.Len(t, data, 2+G2Size, "Expected the public key to be a single byte string")
+	var decodedKey PublicKey
+	assert.NoError(t, decodedKey.UnmarshalCBOR(data), "Expected no error decoding the public key")
+	assert.True(t, decodedKey.X2.IsEqual(x2), "Expected X2 to round trip")
+
+	signature := Signature{A: e.G1Generator(), E: x}
+	data, err = signature.MarshalCBOR()
+	assert.NoError(t, err, "Expected no error encoding the signature")
+	assert.Len(t, data, 1+2+G1Size+2+ScalarSize, "Expected the signature size to match the layout")
+	var decodedSignature Signature
+	assert.NoError(t, decodedSignature.UnmarshalCBOR(data), "Expected no error decoding the signature")
+	assert.Equal(t, 1, decodedSignature.E.IsEqual(x), "Expected E to round trip")
+
+	proof := MockSignatureProof()
+	data, err = proof.MarshalCBOR()
+	assert.NoError(t, err, "Expected no error encoding the proof")
+	var decodedProof SignatureProof
+	assert.NoError(t, decodedProof.UnmarshalCBOR(data), "Expected no error decoding the proof")
+	assert.True(t, decodedProof.BPrim.IsEqual(proof.BPrim), "Expected BPrim to round trip")
+	assert.Len(t, decodedProof.Zi, 2, "Expected Zi to round trip")
+	again, _ := decodedProof.MarshalCBOR()
+	assert.Equal(t, data, again, "Expected the encoding to be deterministic")
+}
+
+// Test for CBOR encodings that do not match the layout
+func TestCBOR_Invalid(t *testing.T) {
+	var signature Signature
+	assert.ErrorIs(t, signature.UnmarshalCBOR([]byte{0x80}), ErrInvalidEncoding, "Expected an error for an empty array")
+
+	data, _ := cbor.Marshal([]interface{}{e.G1Generator().BytesCompressed(), "e"})
+	assert.ErrorIs(t, signature.UnmarshalCBOR(data), ErrInvalidEncoding, "Expected an error for a text scalar")
+
+	data, _ = cbor.Marshal([]interface{}{make([]byte, G1Size), make([]byte, ScalarSize)})
+	assert.ErrorIs(t, signature.UnmarshalCBOR(data), ErrInvalidEncoding, "Expected an error for an invalid point")
+
+	var pk PublicKey
+	data, _ = cbor.Marshal(e.G2Generator().BytesCompressed()[:10])
+	assert.ErrorIs(t, pk.UnmarshalCBOR(data), ErrInvalidEncoding, "Expected an error for a truncated key")
+
+	var proof SignatureProof
+	assert.ErrorIs(t, proof.UnmarshalCBOR([]byte{0xff}), ErrInvalidEncoding, "Expected an error for malformed CBOR")
+}
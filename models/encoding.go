@@ -0,0 +1,528 @@
+package models
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+
+	e "github.com/cloudflare/circl/ecc/bls12381"
+)
+
+// Binary encodings use compressed group elements and big-endian scalars:
+// - PublicParameters: G1 (48 bytes) || G2 (96 bytes) || H1[0..l] (48 bytes each).
+// - PublicKey: X2 (96 bytes).
+// - SecretKey: X (32 bytes).
+// - Signature: A (48 bytes) || E (32 bytes).
+// - SignatureProof: APrim (48 bytes) || BPrim (48 bytes) || Ch || Zr || Ze || Zi[0..n] (32 bytes each).
+//
+// JSON encodings hold the same elements as unpadded base64url strings.
+const (
+	// G1Size is the size of a compressed element of G1.
+	G1Size = e.G1SizeCompressed
+	// G2Size is the size of a compressed element of G2.
+	G2Size = e.G2SizeCompressed
+	// ScalarSize is the size of a scalar.
+	ScalarSize = e.ScalarSize
+	// SignatureSize is the size of an encoded signature.
+	SignatureSize = G1Size + ScalarSize
+)
+
+// MarshalBinary encodes the public parameters.
+func (p PublicParameters) MarshalBinary() ([]byte, error) {
+	if p.G1 == nil || p.G2 == nil {
+		return nil, fmt.Errorf("%w: missing generator", ErrInvalidEncoding)
+	}
+	out := make([]byte, 0, G1Size+G2Size+len(p.H1)*G1Size)
+	out = append(out, p.G1.BytesCompressed()...)
+	out = append(out, p.G2.BytesCompressed()...)
+	for _, h := range p.H1 {
+		out = append(out, h.BytesCompressed()...)
+	}
+	return out, nil
+}
+
+// UnmarshalBinary decodes the public parameters, checking that every element is in its group.
+func (p *PublicParameters) UnmarshalBinary(data []byte) error {
+	if len(data) < G1Size+G2Size || (len(data)-G1Size-G2Size)%G1Size != 0 {
+		return fmt.Errorf("%w: public parameters of %d bytes", ErrInvalidEncoding, len(data))
+	}
+	g1, err := decodeG1(data[:G1Size])
+	if err != nil {
+		return err
+	}
+	g2, err := decodeG2(data[G1Size : G1Size+G2Size])
+	if err != nil {
+		return err
+	}
+	rest := data[G1Size+G2Size:]
+	h1 := make([]e.G1, len(rest)/G1Size)
+	for i := range h1 {
+		h, err := decodeG1(rest[i*G1Size : (i+1)*G1Size])
+		if err != nil {
+			return err
+		}
+		h1[i] = *h
+	}
+	*p = PublicParameters{G1: g1, G2: g2, H1: h1}
+	return nil
+}
+
+// MarshalBinary encodes the public key.
+func (pk PublicKey) MarshalBinary() ([]byte, error) {
+	if pk.X2 == nil {
+		return nil, fmt.Errorf("%w: missing X2", ErrInvalidEncoding)
+	}
+	return pk.X2.BytesCompressed(), nil
+}
+
+// UnmarshalBinary decodes the public key, checking that X2 is a non-identity element of G2.
+func (pk *PublicKey) UnmarshalBinary(data []byte) error {
+	x2, err := decodeG2(data)
+	if err != nil {
+		return err
+	}
+	if x2.IsIdentity() {
+		return fmt.Errorf("%w: X2 is the identity", ErrInvalidEncoding)
+	}
+	pk.X2 = x2
+	return nil
+}
+
+// MarshalBinary encodes the secret key.
+func (sk SecretKey) MarshalBinary() ([]byte, error) {
+	if sk.X == nil {
+		return nil, fmt.Errorf("%w: missing X", ErrInvalidEncoding)
+	}
+	return sk.X.MarshalBinary()
+}
+
+// UnmarshalBinary decodes the secret key.
+func (sk *SecretKey) UnmarshalBinary(data []byte) error {
+	x, err := decodeScalar(data)
+	if err != nil {
+		return err
+	}
+	sk.X = x
+	return nil
+}
+
+// MarshalBinary encodes the signature.
+func (s Signature) MarshalBinary() ([]byte, error) {
+	if s.A == nil || s.E == nil {
+		return nil, fmt.Errorf("%w: missing signature component", ErrInvalidEncoding)
+	}
+	eBytes, err := s.E.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	return append(s.A.BytesCompressed(), eBytes...), nil
+}
+
+// UnmarshalBinary decodes the signature.
+func (s *Signature) UnmarshalBinary(data []byte) error {
+	if len(data) != SignatureSize {
+		return fmt.Errorf("%w: signature of %d bytes", ErrInvalidEncoding, len(data))
+	}
+	a, err := decodeG1(data[:G1Size])
+	if err != nil {
+		return err
+	}
+	elem, err := decodeScalar(data[G1Size:])
+	if err != nil {
+		return err
+	}
+	*s = Signature{A: a, E: elem}
+	return nil
+}
+
+// MarshalBinary encodes the signature proof.
+func (p SignatureProof) MarshalBinary() ([]byte, error) {
+	if p.APrim == nil || p.BPrim == nil || p.Ch == nil || p.Zr == nil || p.Ze == nil {
+		return nil, fmt.Errorf("%w: missing proof component", ErrInvalidEncoding)
+	}
+	out := make([]byte, 0, 2*G1Size+(3+len(p.Zi))*ScalarSize)
+	out = append(out, p.APrim.BytesCompressed()...)
+	out = append(out, p.BPrim.BytesCompressed()...)
+	for _, s := range []*e.Scalar{p.Ch, p.Zr, p.Ze} {
+		b, err := s.MarshalBinary()
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, b...)
+	}
+	for i := range p.Zi {
+		b, err := p.Zi[i].MarshalBinary()
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, b...)
+	}
+	return out, nil
+}
+
+// UnmarshalBinary decodes the signature proof, checking that APrim and BPrim are elements of G1.
+func (p *SignatureProof) UnmarshalBinary(data []byte) error {
+	fixed := 2*G1Size + 3*ScalarSize
+	if len(data) < fixed || (len(data)-fixed)%ScalarSize != 0 {
+		return fmt.Errorf("%w: proof of %d bytes", ErrInvalidEncoding, len(data))
+	}
+	aPrim, err := decodeG1(data[:G1Size])
+	if err != nil {
+		return err
+	}
+	bPrim, err := decodeG1(data[G1Size : 2*G1Size])
+	if err != nil {
+		return err
+	}
+	scalars := make([]*e.Scalar, 3)
+	for i := range scalars {
+		offset := 2*G1Size + i*ScalarSize
+		if scalars[i], err = decodeScalar(data[offset : offset+ScalarSize]); err != nil {
+			return err
+		}
+	}
+	rest := data[fixed:]
+	zi := make([]e.Scalar, len(rest)/ScalarSize)
+	for i := range zi {
+		z, err := decodeScalar(rest[i*ScalarSize : (i+1)*ScalarSize])
+		if err != nil {
+			return err
+		}
+		zi[i] = *z
+	}
+	*p = SignatureProof{APrim: aPrim, BPrim: bPrim, Ch: scalars[0], Zr: scalars[1], Ze: scalars[2], Zi: zi}
+	return nil
+}
+
+// publicParametersJSON is the JSON form of PublicParameters.
+type publicParametersJSON struct {
+	G1 string   `json:"g1"`
+	G2 string   `json:"g2"`
+	H1 []string `json:"h1"`
+}
+
+// MarshalJSON encodes the public parameters as JSON.
+func (p PublicParameters) MarshalJSON() ([]byte, error) {
+	if p.G1 == nil || p.G2 == nil {
+		return nil, fmt.Errorf("%w: missing generator", ErrInvalidEncoding)
+	}
+	out := publicParametersJSON{
+		G1: encode(p.G1.BytesCompressed()),
+		G2: encode(p.G2.BytesCompressed()),
+		H1: make([]string, len(p.H1)),
+	}
+	for i, h := range p.H1 {
+		out.H1[i] = encode(h.BytesCompressed())
+	}
+	return json.Marshal(out)
+}
+
+// UnmarshalJSON decodes the public parameters from JSON.
+func (p *PublicParameters) UnmarshalJSON(data []byte) error {
+	var in publicParametersJSON
+	if err := json.Unmarshal(data, &in); err != nil {
+		return err
+	}
+	g1, err := decodeG1String(in.G1)
+	if err != nil {
+		return err
+	}
+	g2Bytes, err := decode(in.G2)
+	if err != nil {
+		return err
+	}
+	g2, err := decodeG2(g2Bytes)
+	if err != nil {
+		return err
+	}
+	h1 := make([]e.G1, len(in.H1))
+	for i, s := range in.H1 {
+		h, err := decodeG1String(s)
+		if err != nil {
+			return err
+		}
+		h1[i] = *h
+	}
+	*p = PublicParameters{G1: g1, G2: g2, H1: h1}
+	return nil
+}
+
+// MarshalJSON encodes the public key as JSON.
+func (pk PublicKey) MarshalJSON() ([]byte, error) {
+	b, err := pk.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(struct {
+		X2 string `json:"x2"`
+	}{encode(b)})
+}
+
+// UnmarshalJSON decodes the public key from JSON.
+func (pk *PublicKey) UnmarshalJSON(data []byte) error {
+	var in struct {
+		X2 string `json:"x2"`
+	}
+	if err := json.Unmarshal(data, &in); err != nil {
+		return err
+	}
+	b, err := decode(in.X2)
+	if err != nil {
+		return err
+	}
+	return pk.UnmarshalBinary(b)
+}
+
+// MarshalJSON encodes the secret key as JSON.
+func (sk SecretKey) MarshalJSON() ([]byte, error) {
+	b, err := sk.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(struct {
+		X string `json:"x"`
+	}{encode(b)})
+}
+
+// UnmarshalJSON decodes the secret key from JSON.
+func (sk *SecretKey) UnmarshalJSON(data []byte) error {
+	var in struct {
+		X string `json:"x"`
+	}
+	if err := json.Unmarshal(data, &in); err != nil {
+		return err
+	}
+	b, err := decode(in.X)
+	if err != nil {
+		return err
+	}
+	return sk.UnmarshalBinary(b)
+}
+
+// MarshalJSON encodes the signature as JSON.
+func (s Signature) MarshalJSON() ([]byte, error) {
+	if s.A == nil || s.E == nil {
+		return nil, fmt.Errorf("%w: missing signature component", ErrInvalidEncoding)
+	}
+	eBytes, err := s.E.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(struct {
+		A string `json:"a"`
+		E string `json:"e"`
+	}{encode(s.A.BytesCompressed()), encode(eBytes)})
+}
+
+// UnmarshalJSON decodes the signature from JSON.
+func (s *Signature) UnmarshalJSON(data []byte) error {
+	var in struct {
+		A string `json:"a"`
+		E string `json:"e"`
+	}
+	if err := json.Unmarshal(data, &in); err != nil {
+		return err
+	}
+	a, err := decodeG1String(in.A)
+	if err != nil {
+		return err
+	}
+	elem, err := decodeScalarString(in.E)
+	if err != nil {
+		return err
+	}
+	*s = Signature{A: a, E: elem}
+	return nil
+}
+
+// signatureProofJSON is the JSON form of SignatureProof.
+type signatureProofJSON struct {
+	APrim string   `json:"aPrim"`
+	BPrim string   `json:"bPrim"`
+	Ch    string   `json:"ch"`
+	Zr    string   `json:"zr"`
+	Zi    []string `json:"zi"`
+	Ze    string   `json:"ze"`
+}
+
+// MarshalJSON encodes the signature proof as JSON.
+func (p SignatureProof) MarshalJSON() ([]byte, error) {
+	if p.APrim == nil || p.BPrim == nil || p.Ch == nil || p.Zr == nil || p.Ze == nil {
+		return nil, fmt.Errorf("%w: missing proof component", ErrInvalidEncoding)
+	}
+	out := signatureProofJSON{
+		APrim: encode(p.APrim.BytesCompressed()),
+		BPrim: encode(p.BPrim.BytesCompressed()),
+		Ch:    encodeScalar(p.Ch),
+		Zr:    encodeScalar(p.Zr),
+		Zi:    make([]string, len(p.Zi)),
+		Ze:    encodeScalar(p.Ze),
+	}
+	for i := range p.Zi {
+		out.Zi[i] = encodeScalar(&p.Zi[i])
+	}
+	return json.Marshal(out)
+}
+
+// UnmarshalJSON decodes the signature proof from JSON.
+func (p *SignatureProof) UnmarshalJSON(data []byte) error {
+	var in signatureProofJSON
+	if err := json.Unmarshal(data, &in); err != nil {
+		return err
+	}
+	aPrim, err := decodeG1String(in.APrim)
+	if err != nil {
+		return err
+	}
+	bPrim, err := decodeG1String(in.BPrim)
+	if err != nil {
+		return err
+	}
+	ch, err := decodeScalarString(in.Ch)
+	if err != nil {
+		return err
+	}
+	zr, err := decodeScalarString(in.Zr)
+	if err != nil {
+		return err
+	}
+	ze, err := decodeScalarString(in.Ze)
+	if err != nil {
+		return err
+	}
+	zi := make([]e.Scalar, len(in.Zi))
+	for i, s := range in.Zi {
+		z, err := decodeScalarString(s)
+		if err != nil {
+			return err
+		}
+		zi[i] = *z
+	}
+	*p = SignatureProof{APrim: aPrim, BPrim: bPrim, Ch: ch, Zr: zr, Zi: zi, Ze: ze}
+	return nil
+}
+
+// blindCommitmentJSON is the JSON form of BlindCommitment.
+type blindCommitmentJSON struct {
+	Commitment string   `json:"commitment"`
+	Indices    []int    `json:"indices"`
+	Ch         string   `json:"ch"`
+	Z          []string `json:"z"`
+}
+
+// MarshalJSON encodes the blind commitment as JSON.
+func (c BlindCommitment) MarshalJSON() ([]byte, error) {
+	if c.Commitment == nil || c.Ch == nil {
+		return nil, fmt.Errorf("%w: missing commitment component", ErrInvalidEncoding)
+	}
+	out := blindCommitmentJSON{
+		Commitment: encode(c.Commitment.BytesCompressed()),
+		Indices:    c.Indices,
+		Ch:         encodeScalar(c.Ch),
+		Z:          make([]string, len(c.Z)),
+	}
+	for i := range c.Z {
+		out.Z[i] = encodeScalar(&c.Z[i])
+	}
+	return json.Marshal(out)
+}
+
+// UnmarshalJSON decodes the blind commitment from JSON.
+func (c *BlindCommitment) UnmarshalJSON(data []byte) error {
+	var in blindCommitmentJSON
+	if err := json.Unmarshal(data, &in); err != nil {
+		return err
+	}
+	commitment, err := decodeG1String(in.Commitment)
+	if err != nil {
+		return err
+	}
+	ch, err := decodeScalarString(in.Ch)
+	if err != nil {
+		return err
+	}
+	z := make([]e.Scalar, len(in.Z))
+	for i, s := range in.Z {
+		zi, err := decodeScalarString(s)
+		if err != nil {
+			return err
+		}
+		z[i] = *zi
+	}
+	*c = BlindCommitment{Commitment: commitment, Indices: in.Indices, Ch: ch, Z: z}
+	return nil
+}
+
+// encode encodes bytes as an unpadded base64url string.
+func encode(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// decode decodes an unpadded base64url string.
+func decode(s string) ([]byte, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidEncoding, err)
+	}
+	return b, nil
+}
+
+// encodeScalar encodes a scalar as an unpadded base64url string.
+func encodeScalar(s *e.Scalar) string {
+	b, _ := s.MarshalBinary()
+	return encode(b)
+}
+
+// decodeG1 decodes a compressed element of G1, checking that it is in the subgroup.
+func decodeG1(b []byte) (*e.G1, error) {
+	if len(b) != G1Size {
+		return nil, fmt.Errorf("%w: G1 element of %d bytes", ErrInvalidEncoding, len(b))
+	}
+	g := new(e.G1)
+	if err := g.SetBytes(b); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidEncoding, err)
+	}
+	return g, nil
+}
+
+// decodeG2 decodes a compressed element of G2, checking that it is in the subgroup.
+func decodeG2(b []byte) (*e.G2, error) {
+	if len(b) != G2Size {
+		return nil, fmt.Errorf("%w: G2 element of %d bytes", ErrInvalidEncoding, len(b))
+	}
+	g := new(e.G2)
+	if err := g.SetBytes(b); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidEncoding, err)
+	}
+	return g, nil
+}
+
+// decodeScalar decodes a scalar, checking that it is reduced.
+func decodeScalar(b []byte) (*e.Scalar, error) {
+	if len(b) != ScalarSize {
+		return nil, fmt.Errorf("%w: scalar of %d bytes", ErrInvalidEncoding, len(b))
+	}
+	s := new(e.Scalar)
+	if err := s.UnmarshalBinary(b); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidEncoding, err)
+	}
+	return s, nil
+}
+
+// decodeG1String decodes a base64url-encoded element of G1.
+func decodeG1String(s string) (*e.G1, error) {
+	b, err := decode(s)
+	if err != nil {
+		return nil, err
+	}
+	return decodeG1(b)
+}
+
+// decodeScalarString decodes a base64url-encoded scalar.
+func decodeScalarString(s string) (*e.Scalar, error) {
+	b, err := decode(s)
+	if err != nil {
+		return nil, err
+	}
+	return decodeScalar(b)
+}
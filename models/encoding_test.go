@@ -0,0 +1,101 @@
+package models
+
+import (
+	"encoding/json"
+	"testing"
+
+	e "github.com/cloudflare/circl/ecc/bls12381"
+	"github.com/stretchr/testify/assert"
+)
+
+// MockSignatureProof creates a mock signature proof object for testing
+func MockSignatureProof() SignatureProof {
+	scalar := func(v uint64) *e.Scalar {
+		s := new(e.Scalar)
+		s.SetUint64(v)
+		return s
+	}
+	bPrim := new(e.G1)
+	bPrim.ScalarMult(scalar(7), e.G1Generator())
+	zi := make([]e.Scalar, 2)
+	zi[0].SetUint64(11)
+	zi[1].SetUint64(12)
+	return SignatureProof{
+		APrim: e.G1Generator(),
+		BPrim: bPrim,
+		Ch:    scalar(1),
+		Zr:    scalar(2),
+		Zi:    zi,
+		Ze:    scalar(3),
+	}
+}
+
+// Test for the binary round trip of a signature proof
+func TestSignatureProof_Binary(t *testing.T) {
+	proof := MockSignatureProof()
+
+	data, err := proof.MarshalBinary()
+	assert.NoError(t, err, "Expected no error when encoding the proof")
+	assert.Len(t, data, 2*G1Size+5*ScalarSize, "Expected the proof size to match the layout")
+
+	var decoded SignatureProof
+	assert.NoError(t, decoded.UnmarshalBinary(data), "Expected no error when decoding the proof")
+	assert.True(t, decoded.BPrim.IsEqual(proof.BPrim), "Expected BPrim to round trip")
+	assert.Equal(t, 1, decoded.Ze.IsEqual(proof.Ze), "Expected Ze to round trip")
+	assert.Len(t, decoded.Zi, 2, "Expected Zi to round trip")
+
+	assert.ErrorIs(t, decoded.UnmarshalBinary(data[:len(data)-1]), ErrInvalidEncoding, "Expected a truncated proof to be rejected")
+}
+
+// Test for the JSON round trip of keys, signatures and proofs
+func TestJSON_RoundTrip(t *testing.T) {
+	x := new(e.Scalar)
+	x.SetUint64(12345)
+	x2 := new(e.G2)
+	x2.ScalarMult(x, e.G2Generator())
+	params := PublicParameters{G1: e.G1Generator(), G2: e.G2Generator(), H1: []e.G1{*e.G1Generator()}}
+	credential := Credential{
+		Schema:      []string{"name"},
+		Attributes:  []string{"Alice"},
+		Signature:   Signature{A: e.G1Generator(), E: x},
+		IssuerKeyID: PublicKey{X2: x2}.KeyID(),
+	}
+
+	data, err := json.Marshal(params)
+	assert.NoError(t, err, "Expected no error when encoding the public parameters")
+	var decodedParams PublicParameters
+	assert.NoError(t, json.Unmarshal(data, &decodedParams), "Expected no error when decoding the public parameters")
+	assert.True(t, decodedParams.G2.IsEqual(params.G2), "Expected G2 to round trip")
+	assert.Len(t, decodedParams.H1, 1, "Expected H1 to round trip")
+
+	data, err = json.Marshal(PublicKey{X2: x2})
+	assert.NoError(t, err, "Expected no error when encoding the public key")
+	var decodedKey PublicKey
+	assert.NoError(t, json.Unmarshal(data, &decodedKey), "Expected no error when decoding the public key")
+	assert.Equal(t, credential.IssuerKeyID, decodedKey.KeyID(), "Expected the key ID to survive the round trip")
+
+	data, err = json.Marshal(credential)
+	assert.NoError(t, err, "Expected no error when encoding the credential")
+	var decodedCredential Credential
+	assert.NoError(t, json.Unmarshal(data, &decodedCredential), "Expected no error when decoding the credential")
+	assert.Equal(t, 1, decodedCredential.Signature.E.IsEqual(x), "Expected the signature to round trip")
+
+	data, err = json.Marshal(MockSignatureProof())
+	assert.NoError(t, err, "Expected no error when encoding the proof")
+	var decodedProof SignatureProof
+	assert.NoError(t, json.Unmarshal(data, &decodedProof), "Expected no error when decoding the proof")
+	assert.Len(t, decodedProof.Zi, 2, "Expected Zi to round trip")
+}
+
+// Test for rejecting points that are not in the group
+func TestPublicKey_InvalidPoint(t *testing.T) {
+	data := make([]byte, G2Size)
+	data[0] = 0x80 | 0x01
+
+	var pk PublicKey
+	assert.ErrorIs(t, pk.UnmarshalBinary(data), ErrInvalidEncoding, "Expected an invalid point to be rejected")
+
+	identity := make([]byte, G2Size)
+	identity[0] = 0xc0
+	assert.ErrorIs(t, pk.UnmarshalBinary(identity), ErrInvalidEncoding, "Expected the identity to be rejected")
+}
@@ -0,0 +1,38 @@
+package models
+
+import (
+	"errors"
+)
+
+// Errors returned by the setup, issue, presentation and verify packages.
+// They are wrapped with details of the failure and can be tested with errors.Is.
+var (
+	// ErrInvalidSetupSize is returned when the number of generators requested from setup is not positive.
+	ErrInvalidSetupSize = errors.New("the number of independent generators must be greater than 0")
+	// ErrLengthMismatch is returned when an attribute or scalar vector does not match the number of generators.
+	ErrLengthMismatch = errors.New("vector length does not match the number of generators")
+	// ErrTooManyRevealed is returned when more attributes are revealed than the credential holds.
+	ErrTooManyRevealed = errors.New("revealed attributes exceed total attributes")
+	// ErrIndexOutOfRange is returned when a revealed index does not refer to an attribute.
+	ErrIndexOutOfRange = errors.New("revealed index out of range")
+	// ErrUnsortedIndices is returned when revealed indices are not strictly increasing, including duplicates.
+	ErrUnsortedIndices = errors.New("revealed indices are not sorted or contain duplicates")
+	// ErrRandomness is returned when the random number generator fails.
+	ErrRandomness = errors.New("failed to generate randomness")
+	// ErrHashing is returned when hashing to a scalar fails.
+	ErrHashing = errors.New("failed to hash input")
+	// ErrSigningFailed is returned when the BBS++ signature cannot be generated.
+	ErrSigningFailed = errors.New("failed to generate signature")
+	// ErrInvalidEncoding is returned when encoded keys, signatures or proofs cannot be decoded.
+	ErrInvalidEncoding = errors.New("invalid encoding")
+	// ErrInvalidCommitment is returned when a blind commitment or its proof of knowledge is invalid.
+	ErrInvalidCommitment = errors.New("invalid blind commitment")
+	// ErrMalformedProof is returned when a proof is structurally invalid.
+	ErrMalformedProof = errors.New("malformed proof")
+	// ErrInvalidPublicKey is returned when the public key or the public parameters are missing or not valid group elements.
+	ErrInvalidPublicKey = errors.New("invalid public key or public parameters")
+	// ErrChallengeMismatch is returned when the recomputed challenge differs from the challenge of the proof.
+	ErrChallengeMismatch = errors.New("challenge mismatch")
+	// ErrPairingFailed is returned when the pairing check e(APrim, X2) = e(BPrim, G2) fails.
+	ErrPairingFailed = errors.New("pairing check failed")
+)
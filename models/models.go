@@ -1,6 +1,9 @@
 package models
 
 import (
+    "crypto/sha256"
+    "encoding/hex"
+
     e "github.com/cloudflare/circl/ecc/bls12381"
 )
 
@@ -32,6 +35,13 @@ type PublicKey struct {
 	X2 *e.G2
 }
 
+// KeyID returns a stable identifier of the public key, computed as the hex-encoded
+// SHA-256 digest of the compressed encoding of X2.
+func (pk PublicKey) KeyID() string {
+    digest := sha256.Sum256(pk.X2.BytesCompressed())
+    return hex.EncodeToString(digest[:])
+}
+
 // SecretKey represents the secret key of the system.
 // It contains the following elements:
 // - X: A random scalar used in the signing process.
@@ -48,6 +58,32 @@ type Signature struct {
 	E *e.Scalar
 }
 
+// Credential represents a credential stored by the holder.
+// It contains the following elements:
+// - Schema: The names of the attributes, in the order in which they were signed.
+// - Attributes: The attribute values, aligned with Schema.
+// - Signature: The BBS++ signature issued over Attributes.
+// - IssuerKeyID: The identifier of the public key of the issuer.
+type Credential struct {
+    Schema      []string  `json:"schema"`
+    Attributes  []string  `json:"attributes"`
+    Signature   Signature `json:"signature"`
+    IssuerKeyID string    `json:"issuerKeyId"`
+}
+
+// BlindCommitment represents the holder's commitment to attributes hidden from the issuer during issuance.
+// It contains the following elements:
+// - Commitment: The commitment ∏_i h₁[i]^m[i] for i ∈ Indices.
+// - Indices: The sorted indices of the committed attributes.
+// - Ch: The challenge of the proof of knowledge of the committed attributes.
+// - Z: The responses of the proof of knowledge, aligned with Indices.
+type BlindCommitment struct {
+    Commitment *e.G1
+    Indices    []int
+    Ch         *e.Scalar
+    Z          []e.Scalar
+}
+
 // SignatureProof represents the proof of a BBS++ signature.
 // It contains the following elements:
 // - APrim: The first component of the proof masking the signature.
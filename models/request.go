@@ -0,0 +1,57 @@
+package models
+
+import (
+	"time"
+)
+
+// Predicate operators supported in a presentation request.
+const (
+	PredicateEqual          = "eq"
+	PredicateNotEqual       = "ne"
+	PredicateGreater        = "gt"
+	PredicateGreaterOrEqual = "ge"
+	PredicateLess           = "lt"
+	PredicateLessOrEqual    = "le"
+)
+
+// Predicate represents a condition the verifier wants an attribute to satisfy.
+// It contains the following elements:
+// - Attribute: The name of the attribute the predicate applies to.
+// - Operator: One of the Predicate* operators.
+// - Value: The value the attribute is compared with. Ordering operators compare integers.
+//
+// The scheme has no range proofs, so the attribute a predicate refers to is disclosed
+// and the predicate is evaluated over the disclosed value.
+type Predicate struct {
+	Attribute string `json:"attribute"`
+	Operator  string `json:"operator"`
+	Value     string `json:"value"`
+}
+
+// PresentationRequest represents the verifier's request for a presentation.
+// It contains the following elements:
+// - RequestedAttributes: The names of the attributes that must be revealed.
+// - Predicates: The predicates that the revealed attributes must satisfy.
+// - Nonce: The nonce the presentation proof must be bound to.
+// - IssuerKeyIDs: The identifiers of the accepted issuer keys. Empty means any trusted issuer.
+// - Expiry: The time after which the request is no longer answered. Zero means no expiry.
+type PresentationRequest struct {
+	RequestedAttributes []string    `json:"requestedAttributes,omitempty"`
+	Predicates          []Predicate `json:"predicates,omitempty"`
+	Nonce               []byte      `json:"nonce"`
+	IssuerKeyIDs        []string    `json:"issuerKeyIds,omitempty"`
+	Expiry              time.Time   `json:"expiry"`
+}
+
+// PresentationResponse represents the holder's answer to a presentation request.
+// It contains the following elements:
+// - IssuerKeyID: The identifier of the public key of the issuer of the presented credential.
+// - Proof: The proof of knowledge of the credential.
+// - RevealedIndices: The sorted indices of the revealed attributes.
+// - RevealedAttributes: The values of the revealed attributes, aligned with RevealedIndices.
+type PresentationResponse struct {
+	IssuerKeyID        string         `json:"issuerKeyId"`
+	Proof              SignatureProof `json:"proof"`
+	RevealedIndices    []int          `json:"revealedIndices"`
+	RevealedAttributes []string       `json:"revealedAttributes"`
+}
@@ -0,0 +1,23 @@
+package models
+
+// Zeroize overwrites the secret scalar of the key with zero and clears the key, so that it no longer holds secret
+// material. The zeroization is best effort, as copies made by the runtime are not reached.
+func (sk *SecretKey) Zeroize() {
+	if sk.X != nil {
+		sk.X.SetUint64(0)
+	}
+	sk.X = nil
+}
+
+// Zeroize overwrites the components of the signature, which is secret to its holder, with the identity and zero,
+// and clears the signature.
+func (s *Signature) Zeroize() {
+	if s.A != nil {
+		s.A.SetIdentity()
+	}
+	if s.E != nil {
+		s.E.SetUint64(0)
+	}
+	s.A = nil
+	s.E = nil
+}
@@ -0,0 +1,31 @@
+package models
+
+import (
+	"testing"
+
+	e "github.com/cloudflare/circl/ecc/bls12381"
+	"github.com/stretchr/testify/assert"
+)
+
+// Test for zeroizing secret keys and signatures
+func TestZeroize(t *testing.T) {
+	x := new(e.Scalar)
+	x.SetUint64(42)
+	sk := SecretKey{X: x}
+	sk.Zeroize()
+	assert.Nil(t, sk.X, "Expected the secret key to be cleared")
+	assert.Equal(t, 1, x.IsZero(), "Expected the secret scalar to be overwritten")
+
+	a := new(e.G1)
+	*a = *e.G1Generator()
+	elem := new(e.Scalar)
+	elem.SetUint64(7)
+	sig := Signature{A: a, E: elem}
+	sig.Zeroize()
+	assert.Nil(t, sig.A, "Expected the signature to be cleared")
+	assert.True(t, a.IsIdentity(), "Expected A to be overwritten")
+	assert.Equal(t, 1, elem.IsZero(), "Expected E to be overwritten")
+
+	var empty SecretKey
+	empty.Zeroize()
+}
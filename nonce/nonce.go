@@ -0,0 +1,196 @@
+package nonce
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"time"
+
+	"github.com/aniagut/msc-bbs-anonymous-credentials/models"
+	"github.com/aniagut/msc-bbs-anonymous-credentials/verify"
+)
+
+const (
+	// randomSize is the number of random bytes in a nonce.
+	randomSize = 32
+	// expirySize is the size of the expiry timestamp embedded in a stateless nonce.
+	expirySize = 8
+	// statelessSize is the size of a stateless nonce: random bytes, expiry and HMAC-SHA256 tag.
+	statelessSize = randomSize + expirySize + sha256.Size
+	// minKeySize is the minimal size of the HMAC key of a stateless manager.
+	minKeySize = 32
+)
+
+var (
+	// ErrUnknownNonce is returned when a nonce was not issued by the manager or was already consumed.
+	ErrUnknownNonce = errors.New("unknown nonce")
+	// ErrNonceExists is returned by a Store when a nonce is recorded twice.
+	ErrNonceExists = errors.New("nonce already recorded")
+	// ErrNonceReused is returned when a stateless nonce is presented a second time.
+	ErrNonceReused = errors.New("nonce already used")
+	// ErrExpiredNonce is returned when a nonce is consumed after its expiry.
+	ErrExpiredNonce = errors.New("nonce has expired")
+	// ErrInvalidNonce is returned when a stateless nonce is malformed or its tag does not verify.
+	ErrInvalidNonce = errors.New("invalid nonce")
+	// ErrStoreFull is returned by a Store when it holds as many nonces as it allows.
+	ErrStoreFull = errors.New("nonce store full")
+)
+
+// Manager issues random, time-limited, single-use nonces for presentation proofs.
+//
+// A stateful manager records every issued nonce in its Store and removes it when it is consumed.
+// A stateless manager embeds the expiry in the nonce and authenticates it with HMAC-SHA256, so nothing is stored
+// at issuance; the Store then only records consumed nonces until they expire, to reject replays.
+type Manager struct {
+	store Store
+	ttl   time.Duration
+	key   []byte
+	now   func() time.Time
+}
+
+// NewManager creates a stateful nonce manager.
+//
+// Parameters:
+//   - store: The store recording the issued nonces.
+//   - ttl: The lifetime of the issued nonces.
+//
+// Returns:
+//   - *Manager: The nonce manager.
+//
+// NewManager panics if store is nil, as the manager could not record any nonce.
+func NewManager(store Store, ttl time.Duration) *Manager {
+	if store == nil {
+		panic("nonce: NewManager requires a store")
+	}
+	return &Manager{
+		store: store,
+		ttl:   ttl,
+		now:   time.Now,
+	}
+}
+
+// NewStatelessManager creates a nonce manager issuing HMAC-authenticated nonces.
+//
+// Parameters:
+//   - key: The HMAC key, at least 32 bytes long. It must be shared by all verifier instances.
+//   - store: The store recording the consumed nonces.
+//   - ttl: The lifetime of the issued nonces.
+//
+// Returns:
+//   - *Manager: The nonce manager.
+//   - error: An error if the key is too short or the store is missing.
+func NewStatelessManager(key []byte, store Store, ttl time.Duration) (*Manager, error) {
+	if len(key) < minKeySize {
+		return nil, errors.New("the HMAC key must be at least 32 bytes long")
+	}
+	if store == nil {
+		return nil, errors.New("a store is required to record the consumed nonces")
+	}
+	return &Manager{
+		store: store,
+		ttl:   ttl,
+		key:   append([]byte(nil), key...),
+		now:   time.Now,
+	}, nil
+}
+
+// Issue generates a fresh nonce.
+func (m *Manager) Issue() ([]byte, error) {
+	random := make([]byte, randomSize)
+	if _, err := rand.Read(random); err != nil {
+		return nil, errors.New("failed to generate random nonce")
+	}
+	expiresAt := m.now().Add(m.ttl)
+
+	// Stateful nonces are recorded until they are consumed
+	if m.key == nil {
+		if err := m.store.Add(random, expiresAt); err != nil {
+			return nil, err
+		}
+		return random, nil
+	}
+
+	// Stateless nonces carry their expiry, authenticated with the HMAC key
+	nonce := make([]byte, 0, statelessSize)
+	nonce = append(nonce, random...)
+	nonce = binary.BigEndian.AppendUint64(nonce, uint64(expiresAt.UnixNano()))
+	nonce = append(nonce, m.tag(nonce)...)
+	return nonce, nil
+}
+
+// Stateless reports whether the manager issues HMAC-authenticated nonces, which stores nothing at issuance.
+func (m *Manager) Stateless() bool {
+	return m.key != nil
+}
+
+// TTL returns the lifetime of the nonces issued by the manager.
+func (m *Manager) TTL() time.Duration {
+	return m.ttl
+}
+
+// Consume checks that the nonce was issued by the manager, has not expired and was not used before,
+// and marks it as used.
+func (m *Manager) Consume(nonce []byte) error {
+	now := m.now()
+
+	if m.key == nil {
+		expiresAt, err := m.store.Remove(nonce)
+		if err != nil {
+			return err
+		}
+		if now.After(expiresAt) {
+			return ErrExpiredNonce
+		}
+		return nil
+	}
+
+	if len(nonce) != statelessSize {
+		return ErrInvalidNonce
+	}
+	body, tag := nonce[:randomSize+expirySize], nonce[randomSize+expirySize:]
+	if !hmac.Equal(tag, m.tag(body)) {
+		return ErrInvalidNonce
+	}
+	expiresAt := time.Unix(0, int64(binary.BigEndian.Uint64(body[randomSize:])))
+	if now.After(expiresAt) {
+		return ErrExpiredNonce
+	}
+	// Record the nonce as used until it expires, after which it is rejected anyway
+	if err := m.store.Add(nonce, expiresAt); err != nil {
+		if errors.Is(err, ErrNonceExists) {
+			return ErrNonceReused
+		}
+		return err
+	}
+	return nil
+}
+
+// Verify consumes the nonce and then runs verify.Verify with it.
+// A proof bound to an unknown, expired or already used nonce is rejected before it is verified.
+//
+// Parameters:
+//   - zkpProof: The zero-knowledge proof to be verified.
+//   - nonce: The nonce issued by the manager that the proof is bound to.
+//   - revealedAttributes: The list of revealed attributes.
+//   - revealedIndices: The list of indices for revealed attributes.
+//   - publicParams: The public parameters of the system.
+//   - publicKey: The public key of the system.
+//
+// Returns:
+//   - bool: true if the nonce is fresh and the proof is valid, false otherwise.
+//   - error: An error if the nonce is rejected or the verification fails.
+func (m *Manager) Verify(zkpProof models.SignatureProof, nonce []byte, revealedAttributes []string, revealedIndices []int, publicParams models.PublicParameters, publicKey models.PublicKey) (bool, error) {
+	if err := m.Consume(nonce); err != nil {
+		return false, err
+	}
+	return verify.Verify(zkpProof, nonce, revealedAttributes, revealedIndices, publicParams, publicKey)
+}
+
+// tag computes the HMAC-SHA256 tag of the nonce body.
+func (m *Manager) tag(body []byte) []byte {
+	mac := hmac.New(sha256.New, m.key)
+	mac.Write(body)
+	return mac.Sum(nil)
+}
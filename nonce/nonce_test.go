@@ -0,0 +1,139 @@
+package nonce
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/aniagut/msc-bbs-anonymous-credentials/issue"
+	"github.com/aniagut/msc-bbs-anonymous-credentials/presentation"
+	"github.com/aniagut/msc-bbs-anonymous-credentials/setup"
+	"github.com/stretchr/testify/assert"
+)
+
+// MockKey creates a mock HMAC key for testing
+func MockKey() []byte {
+	return bytes.Repeat([]byte{0x42}, 32)
+}
+
+// Test for single use of stateful nonces
+func TestManager_SingleUse(t *testing.T) {
+	manager := NewManager(NewMemoryStore(), time.Minute)
+
+	nonce, err := manager.Issue()
+	assert.NoError(t, err, "Expected no error during nonce issuance")
+	assert.Len(t, nonce, randomSize, "Expected a nonce of the random size")
+
+	assert.NoError(t, manager.Consume(nonce), "Expected a fresh nonce to be accepted")
+	assert.ErrorIs(t, manager.Consume(nonce), ErrUnknownNonce, "Expected a used nonce to be rejected")
+	assert.ErrorIs(t, manager.Consume([]byte("random_nonce")), ErrUnknownNonce, "Expected an unknown nonce to be rejected")
+}
+
+// Test for expiry of stateful nonces
+func TestManager_Expired(t *testing.T) {
+	manager := NewManager(NewMemoryStore(), time.Minute)
+	nonce, err := manager.Issue()
+	assert.NoError(t, err, "Expected no error during nonce issuance")
+
+	manager.now = func() time.Time { return time.Now().Add(2 * time.Minute) }
+	assert.ErrorIs(t, manager.Consume(nonce), ErrExpiredNonce, "Expected an expired nonce to be rejected")
+}
+
+// Test for stateless nonces
+func TestStatelessManager(t *testing.T) {
+	store := NewMemoryStore()
+	manager, err := NewStatelessManager(MockKey(), store, time.Minute)
+	assert.NoError(t, err, "Expected no error when creating the manager")
+
+	nonce, err := manager.Issue()
+	assert.NoError(t, err, "Expected no error during nonce issuance")
+	assert.Equal(t, 0, store.Len(), "Expected nothing to be stored at issuance")
+
+	assert.NoError(t, manager.Consume(nonce), "Expected a fresh nonce to be accepted")
+	assert.ErrorIs(t, manager.Consume(nonce), ErrNonceReused, "Expected a used nonce to be rejected")
+
+	tampered, err := manager.Issue()
+	assert.NoError(t, err, "Expected no error during nonce issuance")
+	tampered[0] ^= 0x01
+	assert.ErrorIs(t, manager.Consume(tampered), ErrInvalidNonce, "Expected a tampered nonce to be rejected")
+
+	expired, err := manager.Issue()
+	assert.NoError(t, err, "Expected no error during nonce issuance")
+	manager.now = func() time.Time { return time.Now().Add(2 * time.Minute) }
+	assert.ErrorIs(t, manager.Consume(expired), ErrExpiredNonce, "Expected an expired nonce to be rejected")
+}
+
+// Test for a short HMAC key
+func TestNewStatelessManager_ShortKey(t *testing.T) {
+	_, err := NewStatelessManager([]byte("short"), NewMemoryStore(), time.Minute)
+
+	assert.Error(t, err, "Expected an error for a short HMAC key")
+}
+
+// Test for a missing nonce store
+func TestNewManager_NilStore(t *testing.T) {
+	assert.Panics(t, func() { NewManager(nil, time.Minute) }, "Expected a panic for a nil store")
+
+	_, err := NewStatelessManager(MockKey(), nil, time.Minute)
+	assert.Error(t, err, "Expected an error for a nil store")
+}
+
+// Test for the verify wrapper rejecting replayed proofs
+func TestManager_Verify(t *testing.T) {
+	attributes := []string{"attribute1", "attribute2", "attribute3"}
+	revealed := []int{0}
+	setupResult, err := setup.Setup(len(attributes))
+	assert.NoError(t, err, "Expected no error during setup")
+	signature, err := issue.Issue(attributes, setupResult.PublicParameters, setupResult.SecretKey)
+	assert.NoError(t, err, "Expected no error during issuance")
+
+	manager := NewManager(NewMemoryStore(), time.Minute)
+	nonce, err := manager.Issue()
+	assert.NoError(t, err, "Expected no error during nonce issuance")
+	proof, err := presentation.Presentation(attributes, signature, revealed, setupResult.PublicParameters, nonce)
+	assert.NoError(t, err, "Expected no error during presentation")
+
+	valid, err := manager.Verify(proof, nonce, attributes[:1], revealed, setupResult.PublicParameters, setupResult.PublicKey)
+	assert.NoError(t, err, "Expected no error for a fresh nonce")
+	assert.True(t, valid, "Expected the proof to be valid")
+
+	valid, err = manager.Verify(proof, nonce, attributes[:1], revealed, setupResult.PublicParameters, setupResult.PublicKey)
+	assert.ErrorIs(t, err, ErrUnknownNonce, "Expected a replayed proof to be rejected")
+	assert.False(t, valid, "Expected a replayed proof to be invalid")
+}
+
+// Test for pruning expired nonces in expiry order and for the size limit of the memory store
+func TestMemoryStore_PruneAndLimit(t *testing.T) {
+	store := NewMemoryStoreWithLimit(3)
+	now := time.Now()
+	store.now = func() time.Time { return now }
+
+	assert.NoError(t, store.Add([]byte("a"), now.Add(time.Second)), "Expected no error recording a nonce")
+	assert.NoError(t, store.Add([]byte("b"), now.Add(time.Minute)), "Expected no error recording a nonce")
+	assert.NoError(t, store.Add([]byte("c"), now.Add(time.Hour)), "Expected no error recording a nonce")
+	assert.ErrorIs(t, store.Add([]byte("d"), now.Add(time.Hour)), ErrStoreFull, "Expected the store to be full")
+
+	// Once the first nonce expires, it is pruned and makes room for another one
+	now = now.Add(2 * time.Second)
+	assert.NoError(t, store.Add([]byte("d"), now.Add(time.Hour)), "Expected the expired nonce to make room")
+	assert.Equal(t, 3, store.Len(), "Expected the expired nonce to be pruned")
+	_, err := store.Remove([]byte("a"))
+	assert.ErrorIs(t, err, ErrUnknownNonce, "Expected the expired nonce to be gone")
+
+	// A removed nonce recorded again is not pruned by the expiry of its first record
+	_, err = store.Remove([]byte("b"))
+	assert.NoError(t, err, "Expected no error removing a nonce")
+	assert.NoError(t, store.Add([]byte("b"), now.Add(2*time.Hour)), "Expected no error recording the nonce again")
+	now = now.Add(2 * time.Minute)
+	assert.ErrorIs(t, store.Add([]byte("b"), now.Add(time.Hour)), ErrNonceExists, "Expected the nonce to be recorded until its new expiry")
+
+	// Removing nonces keeps the expiry heap linear in the number of recorded nonces
+	churn := NewMemoryStoreWithLimit(DefaultMaxEntries)
+	for i := 0; i < 1000; i++ {
+		nonce := []byte{byte(i), byte(i >> 8)}
+		assert.NoError(t, churn.Add(nonce, time.Now().Add(time.Hour)), "Expected no error recording a nonce")
+		_, err := churn.Remove(nonce)
+		assert.NoError(t, err, "Expected no error removing a nonce")
+	}
+	assert.LessOrEqual(t, len(churn.expiries), 2*churn.Len()+65, "Expected the expiry heap to be compacted")
+}
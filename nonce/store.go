@@ -0,0 +1,131 @@
+package nonce
+
+import (
+	"container/heap"
+	"sync"
+	"time"
+)
+
+// DefaultMaxEntries is the number of nonces a MemoryStore created with NewMemoryStore holds at most.
+const DefaultMaxEntries = 1 << 20
+
+// Store records nonces until they expire.
+// Implementations must be safe for concurrent use, and Add and Remove must be atomic,
+// so that a nonce cannot be consumed twice by concurrent verifications.
+type Store interface {
+	// Add records the nonce until expiresAt. It returns ErrNonceExists if the nonce is already recorded.
+	Add(nonce []byte, expiresAt time.Time) error
+	// Remove deletes the nonce and returns the time it expires at. It returns ErrUnknownNonce if the nonce is not recorded.
+	Remove(nonce []byte) (time.Time, error)
+}
+
+// MemoryStore is an in-memory Store. Expired nonces are pruned lazily when new nonces are added, in the order of
+// their expiry, so that adding a nonce costs O(log n) amortized.
+type MemoryStore struct {
+	mu         sync.Mutex
+	entries    map[string]time.Time
+	expiries   expiryHeap
+	maxEntries int
+	now        func() time.Time
+}
+
+// NewMemoryStore creates an empty in-memory nonce store holding at most DefaultMaxEntries nonces.
+func NewMemoryStore() *MemoryStore {
+	return NewMemoryStoreWithLimit(DefaultMaxEntries)
+}
+
+// NewMemoryStoreWithLimit creates an empty in-memory nonce store holding at most maxEntries unexpired nonces.
+// Add returns ErrStoreFull once the limit is reached, so that clients minting nonces cannot exhaust the memory.
+func NewMemoryStoreWithLimit(maxEntries int) *MemoryStore {
+	return &MemoryStore{
+		entries:    make(map[string]time.Time),
+		maxEntries: maxEntries,
+		now:        time.Now,
+	}
+}
+
+// Add records the nonce until expiresAt.
+func (s *MemoryStore) Add(nonce []byte, expiresAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.prune()
+	key := string(nonce)
+	if _, ok := s.entries[key]; ok {
+		return ErrNonceExists
+	}
+	if len(s.entries) >= s.maxEntries {
+		return ErrStoreFull
+	}
+	s.entries[key] = expiresAt
+	heap.Push(&s.expiries, expiry{key: key, expiresAt: expiresAt})
+	return nil
+}
+
+// Remove deletes the nonce and returns the time it expires at.
+// Its entry in the expiry heap is discarded when it expires or when the heap is compacted.
+func (s *MemoryStore) Remove(nonce []byte) (time.Time, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := string(nonce)
+	expiresAt, ok := s.entries[key]
+	if !ok {
+		return time.Time{}, ErrUnknownNonce
+	}
+	delete(s.entries, key)
+	s.compact()
+	return expiresAt, nil
+}
+
+// Len returns the number of recorded nonces, including expired ones that have not been pruned yet.
+func (s *MemoryStore) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.entries)
+}
+
+// prune removes the expired nonces, popping them from the expiry heap. The caller must hold the lock.
+func (s *MemoryStore) prune() {
+	now := s.now()
+	for len(s.expiries) > 0 && now.After(s.expiries[0].expiresAt) {
+		e := heap.Pop(&s.expiries).(expiry)
+		// The nonce may have been removed, or removed and recorded again with a later expiry
+		if expiresAt, ok := s.entries[e.key]; ok && now.After(expiresAt) {
+			delete(s.entries, e.key)
+		}
+	}
+}
+
+// compact rebuilds the expiry heap from the recorded nonces once most of its entries belong to removed nonces,
+// which keeps its size linear in the number of recorded nonces at an amortized O(1) cost. The caller must hold the lock.
+func (s *MemoryStore) compact() {
+	if len(s.expiries) <= 2*len(s.entries)+64 {
+		return
+	}
+	s.expiries = s.expiries[:0]
+	for key, expiresAt := range s.entries {
+		s.expiries = append(s.expiries, expiry{key: key, expiresAt: expiresAt})
+	}
+	heap.Init(&s.expiries)
+}
+
+// expiry is an entry of the expiry heap.
+type expiry struct {
+	key       string
+	expiresAt time.Time
+}
+
+// expiryHeap is a min-heap of expiries implementing heap.Interface.
+type expiryHeap []expiry
+
+func (h expiryHeap) Len() int           { return len(h) }
+func (h expiryHeap) Less(i, j int) bool { return h[i].expiresAt.Before(h[j].expiresAt) }
+func (h expiryHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+func (h *expiryHeap) Push(x any)        { *h = append(*h, x.(expiry)) }
+func (h *expiryHeap) Pop() any {
+	old := *h
+	last := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return last
+}
@@ -0,0 +1,362 @@
+// Package oneshow adds one-show presentations: a credential can be shown once per scope (e.g. an election or a
+// coupon campaign), and showing it twice in the same scope reveals the identity of its holder.
+//
+// The credential holds a secret serial key k and an identity id as hidden attributes. A show in a scope reveals
+// the serial S = B1^k and the double-show tag T = g^id * B2^(k * R), where B1 and B2 are hashed from the scope and
+// R is hashed from the verifier's nonce. The serial is the same for every show in a scope, so verifiers detect a
+// second show, and two tags with different R reveal g^id. Shows in different scopes are unlinkable.
+// Since the presentation proves knowledge of r, r * k and r * id, the holder proves S^r = B1^(r * k) and
+// T^r = g^(r * id) * B2^(R * r * k).
+package oneshow
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/aniagut/msc-bbs-anonymous-credentials/models"
+	"github.com/aniagut/msc-bbs-anonymous-credentials/presentation"
+	"github.com/aniagut/msc-bbs-anonymous-credentials/utils"
+	"github.com/aniagut/msc-bbs-anonymous-credentials/verify"
+	e "github.com/cloudflare/circl/ecc/bls12381"
+)
+
+// Domain separation tags of the hashes to G1 deriving the bases of a scope.
+const (
+	serialBaseDST = "BBS_ONESHOW_BLS12381G1_XMD:SHA-256_SSWU_RO_SERIAL_"
+	tagBaseDST    = "BBS_ONESHOW_BLS12381G1_XMD:SHA-256_SSWU_RO_TAG_"
+)
+
+var (
+	// ErrInvalidToken is returned when a token is malformed, uses invalid indices or is used out of order.
+	ErrInvalidToken = errors.New("invalid one-show token")
+	// ErrDoubleShow is returned when a serial has already been shown in a scope.
+	ErrDoubleShow = errors.New("credential already shown in this scope")
+	// ErrIdentityNotExtracted is returned when the identity cannot be extracted from two records.
+	ErrIdentityNotExtracted = errors.New("identity cannot be extracted")
+)
+
+// Indices are the indices of the attributes of a one-show credential.
+// It contains the following elements:
+// - Serial: The index of the secret serial key, which should be a high-entropy holder secret issued blindly.
+// - Identity: The index of the identity revealed by a double show.
+type Indices struct {
+	Serial   int
+	Identity int
+}
+
+// Token is revealed by a one-show presentation.
+// It contains the following elements:
+// - Scope: The scope of the show.
+// - Serial: The serial S = B1^k of the credential in the scope.
+// - Tag: The double-show tag T = g^id * B2^(k * R).
+type Token struct {
+	Scope  string
+	Serial *e.G1
+	Tag    *e.G1
+}
+
+// Record is an accepted show: the token with the nonce the presentation was bound to.
+type Record struct {
+	Token Token
+	Nonce []byte
+}
+
+// Show is the holder side of a one-show presentation, passed to presentation.PresentationWithExtensions.
+type Show struct {
+	scope   string
+	nonce   []byte
+	indices Indices
+	token   Token
+	done    bool
+}
+
+// NewShow creates the holder side of a show of the credential in the scope, for the nonce of the presentation.
+func NewShow(scope string, nonce []byte, indices Indices) *Show {
+	return &Show{scope: scope, nonce: nonce, indices: indices}
+}
+
+// Commit implements presentation.Extension.
+func (s *Show) Commit(witness presentation.Witness) ([]byte, error) {
+	// Step 1: Find the serial key and the identity among the hidden attributes
+	if s.done {
+		return nil, fmt.Errorf("%w: show already used", ErrInvalidToken)
+	}
+	serial, identity, err := positions(s.indices, witness.Position)
+	if err != nil {
+		return nil, err
+	}
+	B1, B2, R, err := bases(s.scope, s.nonce)
+	if err != nil {
+		return nil, err
+	}
+	k, id := &witness.Attributes[serial], &witness.Attributes[identity]
+
+	// Step 2: Compute the serial S ← B1^k and the tag T ← g^id * B2^(k * R)
+	kR := new(e.Scalar)
+	kR.Mul(k, &R)
+	defer utils.ZeroizeScalars(kR)
+	S := new(e.G1)
+	S.ScalarMult(k, B1)
+	T := utils.LinearCombination([]*e.G1{e.G1Generator(), B2}, []*e.Scalar{id, kR})
+
+	// Step 3: Compute the commitments U1 ← S^vR * B1^(-v[k]) and U2 ← T^vR * g^(-v[id]) * B2^(-R * v[k])
+	s.token = Token{Scope: s.scope, Serial: S, Tag: T}
+	U1, U2 := commitments(s.token, B1, B2, &R, witness.VR, &witness.V[serial], &witness.V[identity])
+	s.done = true
+	return transcript(s.token, s.indices, U1, U2), nil
+}
+
+// Respond implements presentation.Extension. The statement has no witnesses of its own.
+func (s *Show) Respond(ch *e.Scalar) error {
+	if !s.done {
+		return fmt.Errorf("%w: show not committed", ErrInvalidToken)
+	}
+	return nil
+}
+
+// Token returns the token to send to the verifier with the presentation.
+func (s *Show) Token() (Token, error) {
+	if !s.done {
+		return Token{}, fmt.Errorf("%w: presentation not completed", ErrInvalidToken)
+	}
+	return s.token, nil
+}
+
+// check is the verifier side of a one-show presentation.
+type check struct {
+	scope   string
+	token   Token
+	nonce   []byte
+	indices Indices
+}
+
+// Check returns the verifier side of a one-show presentation, passed to verify.VerifyWithExtensions to check that
+// the token was computed from the hidden attributes of the credential for the scope expected by the verifier and the
+// nonce.
+func Check(scope string, token Token, nonce []byte, indices Indices) verify.Extension {
+	return check{scope: scope, token: token, nonce: nonce, indices: indices}
+}
+
+// Commit implements verify.Extension.
+func (c check) Commit(responses verify.Responses) ([]byte, error) {
+	// Step 1: Validate the token for the expected scope and find the responses of the serial key and the identity
+	if err := checkToken(c.token); err != nil {
+		return nil, err
+	}
+	if c.token.Scope != c.scope {
+		return nil, fmt.Errorf("%w: token for scope %q, expected %q", ErrInvalidToken, c.token.Scope, c.scope)
+	}
+	serial, identity, err := positions(c.indices, responses.Position)
+	if err != nil {
+		return nil, err
+	}
+	B1, B2, R, err := bases(c.token.Scope, c.nonce)
+	if err != nil {
+		return nil, err
+	}
+
+	// Step 2: Recompute U1 ← S^Zr * B1^(-Zi[k]) and U2 ← T^Zr * g^(-Zi[id]) * B2^(-R * Zi[k])
+	U1, U2 := commitments(c.token, B1, B2, &R, responses.Zr, &responses.Z[serial], &responses.Z[identity])
+	return transcript(c.token, c.indices, U1, U2), nil
+}
+
+// Store records the serials shown in every scope.
+// Implementations must be safe for concurrent use, and Spend must be atomic, so that concurrent shows of the same
+// serial cannot both be accepted.
+type Store interface {
+	// Spend records the show of the serial of the record in its scope. If the serial was already shown in the scope,
+	// it returns the earlier record and ErrDoubleShow.
+	Spend(record Record) (Record, error)
+}
+
+// MemoryStore is an in-memory Store.
+type MemoryStore struct {
+	mu      sync.Mutex
+	entries map[string]Record
+}
+
+// NewMemoryStore creates an empty in-memory store of spent serials.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{entries: make(map[string]Record)}
+}
+
+// Spend records the show of the serial of the record in its scope.
+func (s *MemoryStore) Spend(record Record) (Record, error) {
+	if err := checkToken(record.Token); err != nil {
+		return Record{}, err
+	}
+	key := binary.BigEndian.AppendUint64(nil, uint64(len(record.Token.Scope)))
+	key = append(append(key, record.Token.Scope...), record.Token.Serial.BytesCompressed()...)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if previous, ok := s.entries[string(key)]; ok {
+		return previous, ErrDoubleShow
+	}
+	s.entries[string(key)] = record
+	return Record{}, nil
+}
+
+// Accept verifies a one-show presentation and records its serial in the store.
+//
+// Parameters:
+//   - store: The store of spent serials.
+//   - scope: The scope expected by the verifier, which the token must be computed for.
+//   - zkpProof: The proof of the presentation.
+//   - token: The token of the presentation.
+//   - nonce: The nonce the presentation is bound to.
+//   - revealedAttributes: The list of revealed attributes.
+//   - revealedIndices: The list of indices for revealed attributes.
+//   - publicParams: The public parameters of the issuer.
+//   - publicKey: The public key of the issuer.
+//   - indices: The indices of the serial key and the identity.
+//
+// Returns:
+//   - Record: For a second show in the scope, the earlier record, which ExtractIdentity combines with the record of this show.
+//   - error: ErrDoubleShow for a second show in the scope, or an error if the presentation does not verify.
+func Accept(store Store, scope string, zkpProof models.SignatureProof, token Token, nonce []byte, revealedAttributes []string, revealedIndices []int, publicParams models.PublicParameters, publicKey models.PublicKey, indices Indices) (Record, error) {
+	if _, err := verify.VerifyWithExtensions(zkpProof, nonce, revealedAttributes, revealedIndices, publicParams, publicKey, Check(scope, token, nonce, indices)); err != nil {
+		return Record{}, err
+	}
+	return store.Spend(Record{Token: token, Nonce: nonce})
+}
+
+// ExtractIdentity extracts the encoded identity g^id of the holder from two shows of the same serial in a scope with
+// different nonces. With T = g^id * F^R for F = B2^k, it computes F ← (T1 / T2)^(1 / (R1 - R2)) and g^id ← T1 / F^R1.
+//
+// Parameters:
+//   - first: The record of the first show.
+//   - second: The record of the second show.
+//
+// Returns:
+//   - *e.G1: The encoded identity, which can be matched with Identify.
+//   - error: An error if the records are not two shows of the same serial with different nonces.
+func ExtractIdentity(first, second Record) (*e.G1, error) {
+	// Step 1: Check that the records show the same serial in the same scope
+	if err := checkToken(first.Token); err != nil {
+		return nil, err
+	}
+	if err := checkToken(second.Token); err != nil {
+		return nil, err
+	}
+	if first.Token.Scope != second.Token.Scope || !first.Token.Serial.IsEqual(second.Token.Serial) {
+		return nil, fmt.Errorf("%w: records of different serials", ErrIdentityNotExtracted)
+	}
+	_, _, R1, err := bases(first.Token.Scope, first.Nonce)
+	if err != nil {
+		return nil, err
+	}
+	_, _, R2, err := bases(second.Token.Scope, second.Nonce)
+	if err != nil {
+		return nil, err
+	}
+	delta := new(e.Scalar)
+	delta.Sub(&R1, &R2)
+	if delta.IsZero() == 1 {
+		return nil, fmt.Errorf("%w: records with the same nonce", ErrIdentityNotExtracted)
+	}
+
+	// Step 2: Compute F ← (T1 / T2)^(1 / (R1 - R2))
+	delta.Inv(delta)
+	negT2 := new(e.G1)
+	*negT2 = *second.Token.Tag
+	negT2.Neg()
+	quotient := new(e.G1)
+	quotient.Add(first.Token.Tag, negT2)
+	F := new(e.G1)
+	F.ScalarMult(delta, quotient)
+
+	// Step 3: Compute g^id ← T1 * F^(-R1)
+	R1.Neg()
+	identity := new(e.G1)
+	identity.ScalarMult(&R1, F)
+	identity.Add(identity, first.Token.Tag)
+	return identity, nil
+}
+
+// EncodeIdentity returns the encoded identity g^id extracted for the identity attribute value id.
+func EncodeIdentity(value string) *e.G1 {
+	id := new(e.Scalar)
+	id.SetBytes(utils.SerializeString(value))
+	encoded := new(e.G1)
+	encoded.ScalarMult(id, e.G1Generator())
+	return encoded
+}
+
+// Identify returns the candidate identity whose encoding is the extracted identity.
+func Identify(identity *e.G1, candidates []string) (string, error) {
+	for _, candidate := range candidates {
+		if EncodeIdentity(candidate).IsEqual(identity) {
+			return candidate, nil
+		}
+	}
+	return "", fmt.Errorf("%w: identity matches no candidate", ErrIdentityNotExtracted)
+}
+
+// positions returns the positions of the serial key and the identity among the hidden attributes.
+func positions(indices Indices, position func(int) (int, error)) (int, int, error) {
+	if indices.Serial == indices.Identity {
+		return 0, 0, fmt.Errorf("%w: serial key and identity are the same attribute", ErrInvalidToken)
+	}
+	serial, err := position(indices.Serial)
+	if err != nil {
+		return 0, 0, fmt.Errorf("%w: %w", ErrInvalidToken, err)
+	}
+	identity, err := position(indices.Identity)
+	if err != nil {
+		return 0, 0, fmt.Errorf("%w: %w", ErrInvalidToken, err)
+	}
+	return serial, identity, nil
+}
+
+// bases derives the serial base B1 and the tag base B2 of the scope and the tag exponent R of the nonce.
+func bases(scope string, nonce []byte) (*e.G1, *e.G1, e.Scalar, error) {
+	B1 := new(e.G1)
+	B1.Hash([]byte(scope), []byte(serialBaseDST))
+	B2 := new(e.G1)
+	B2.Hash([]byte(scope), []byte(tagBaseDST))
+	R, err := utils.HashToScalar([]byte("one-show-tag"), binary.BigEndian.AppendUint64(nil, uint64(len(scope))), []byte(scope), nonce)
+	if err != nil {
+		return nil, nil, e.Scalar{}, err
+	}
+	return B1, B2, R, nil
+}
+
+// commitments computes U1 ← S^a * B1^(-b) and U2 ← T^a * g^(-c) * B2^(-R * b), with the blindings (a, b, c) of
+// (r, r * k, r * id) on the holder side and the responses on the verifier side.
+func commitments(token Token, B1, B2 *e.G1, R, a, b, c *e.Scalar) (*e.G1, *e.G1) {
+	negB, negC := new(e.Scalar), new(e.Scalar)
+	negB.Set(b)
+	negB.Neg()
+	negC.Set(c)
+	negC.Neg()
+	negRB := new(e.Scalar)
+	negRB.Mul(R, negB)
+	defer utils.ZeroizeScalars(negB, negC, negRB)
+	U1 := utils.LinearCombination([]*e.G1{token.Serial, B1}, []*e.Scalar{a, negB})
+	U2 := utils.LinearCombination([]*e.G1{token.Tag, e.G1Generator(), B2}, []*e.Scalar{a, negC, negRB})
+	return U1, U2
+}
+
+// checkToken checks that the serial and the tag of the token are elements of G1.
+func checkToken(token Token) error {
+	if token.Serial == nil || token.Tag == nil || token.Serial.IsIdentity() || !token.Serial.IsOnG1() || !token.Tag.IsOnG1() {
+		return fmt.Errorf("%w: serial or tag is not a valid element of G1", ErrInvalidToken)
+	}
+	return nil
+}
+
+// transcript serializes the statement and the commitments of a one-show proof.
+func transcript(token Token, indices Indices, U1, U2 *e.G1) []byte {
+	out := []byte("one-show")
+	out = binary.BigEndian.AppendUint64(out, uint64(indices.Serial))
+	out = binary.BigEndian.AppendUint64(out, uint64(indices.Identity))
+	out = binary.BigEndian.AppendUint64(out, uint64(len(token.Scope)))
+	out = append(out, token.Scope...)
+	for _, element := range []*e.G1{token.Serial, token.Tag, U1, U2} {
+		out = append(out, utils.SerializeG1(element)...)
+	}
+	return out
+}
@@ -0,0 +1,96 @@
+package oneshow
+
+import (
+	"testing"
+
+	"github.com/aniagut/msc-bbs-anonymous-credentials/internal/testutil"
+	"github.com/aniagut/msc-bbs-anonymous-credentials/models"
+	"github.com/aniagut/msc-bbs-anonymous-credentials/presentation"
+	"github.com/stretchr/testify/assert"
+)
+
+// indices of the serial key and the identity in the mock credential (serial key, identity, country)
+var indices = Indices{Serial: 0, Identity: 1}
+
+// MockCredential issues a one-show credential
+func MockCredential(t *testing.T) ([]string, models.Signature, models.SetupResult) {
+	return testutil.MockCredential(t, "k-8f3a1c9e2b7d4f60", "voter-1337", "PL")
+}
+
+// present shows the credential in the scope for the nonce, revealing the country
+func present(t *testing.T, attributes []string, signature models.Signature, result models.SetupResult, scope string, nonce []byte) (models.SignatureProof, Token) {
+	show := NewShow(scope, nonce, indices)
+	proof, err := presentation.PresentationWithExtensions(attributes, signature, []int{2}, result.PublicParameters, nonce, show)
+	assert.NoError(t, err, "Expected no error during the presentation")
+	token, err := show.Token()
+	assert.NoError(t, err, "Expected the token once the presentation is complete")
+	return proof, token
+}
+
+// Test for accepting one show per scope and extracting the identity of a double show
+func TestOneShow_DoubleShow(t *testing.T) {
+	attributes, signature, result := MockCredential(t)
+	store := NewMemoryStore()
+	accept := func(scope string, proof models.SignatureProof, token Token, nonce []byte) (Record, error) {
+		return Accept(store, scope, proof, token, nonce, []string{"PL"}, []int{2}, result.PublicParameters, result.PublicKey, indices)
+	}
+
+	proof, token := present(t, attributes, signature, result, "election-2026", []byte("nonce-1"))
+	_, err := accept("election-2026", proof, token, []byte("nonce-1"))
+	assert.NoError(t, err, "Expected the first show to be accepted")
+
+	// A show in another scope is accepted and unlinkable
+	otherProof, otherToken := present(t, attributes, signature, result, "election-2027", []byte("nonce-2"))
+	assert.False(t, token.Serial.IsEqual(otherToken.Serial), "Expected different serials in different scopes")
+	_, err = accept("election-2027", otherProof, otherToken, []byte("nonce-2"))
+	assert.NoError(t, err, "Expected a show in another scope to be accepted")
+
+	// A second show in the same scope is detected and reveals the identity
+	secondProof, secondToken := present(t, attributes, signature, result, "election-2026", []byte("nonce-3"))
+	previous, err := accept("election-2026", secondProof, secondToken, []byte("nonce-3"))
+	assert.ErrorIs(t, err, ErrDoubleShow, "Expected the second show to be detected")
+	identity, err := ExtractIdentity(previous, Record{Token: secondToken, Nonce: []byte("nonce-3")})
+	assert.NoError(t, err, "Expected no error extracting the identity")
+	id, err := Identify(identity, []string{"voter-1", "voter-1337"})
+	assert.NoError(t, err, "Expected the identity among the candidates")
+	assert.Equal(t, "voter-1337", id, "Expected the identity of the double-showing holder")
+
+	// A show for a scope of the holder's choice would have a fresh serial, so it is rejected
+	for _, scope := range []string{"election-2026 ", "Election-2026"} {
+		evasiveProof, evasiveToken := present(t, attributes, signature, result, scope, []byte("nonce-4"))
+		_, err = accept("election-2026", evasiveProof, evasiveToken, []byte("nonce-4"))
+		assert.ErrorIs(t, err, ErrInvalidToken, "Expected a show for the scope %q to be rejected", scope)
+	}
+
+	_, err = ExtractIdentity(previous, previous)
+	assert.ErrorIs(t, err, ErrIdentityNotExtracted, "Expected no extraction from a single show")
+	_, err = ExtractIdentity(previous, Record{Token: otherToken, Nonce: []byte("nonce-2")})
+	assert.ErrorIs(t, err, ErrIdentityNotExtracted, "Expected no extraction from different serials")
+}
+
+// Test for rejecting tokens that do not match the credential, the scope or the nonce
+func TestOneShow_Rejections(t *testing.T) {
+	attributes, signature, result := MockCredential(t)
+	store := NewMemoryStore()
+	proof, token := present(t, attributes, signature, result, "coupon-42", []byte("nonce-1"))
+	accept := func(token Token, nonce []byte, indices Indices) error {
+		_, err := Accept(store, "coupon-42", proof, token, nonce, []string{"PL"}, []int{2}, result.PublicParameters, result.PublicKey, indices)
+		return err
+	}
+
+	// A fresh serial would escape double-show detection
+	forged := token
+	forged.Serial = EncodeIdentity("fresh")
+	assert.ErrorIs(t, accept(forged, []byte("nonce-1"), indices), models.ErrChallengeMismatch, "Expected a forged serial to be rejected")
+
+	// The token is bound to the scope and the nonce
+	moved := token
+	moved.Scope = "coupon-43"
+	assert.ErrorIs(t, accept(moved, []byte("nonce-1"), indices), ErrInvalidToken, "Expected another scope to be rejected")
+	assert.ErrorIs(t, accept(token, []byte("nonce-2"), indices), models.ErrChallengeMismatch, "Expected another nonce to be rejected")
+
+	// The tag must encode the identity attribute
+	assert.ErrorIs(t, accept(token, []byte("nonce-1"), Indices{Serial: 0, Identity: 0}), ErrInvalidToken, "Expected distinct attributes")
+	assert.ErrorIs(t, accept(token, []byte("nonce-1"), Indices{Serial: 0, Identity: 2}), ErrInvalidToken, "Expected a revealed identity to be rejected")
+	assert.NoError(t, accept(token, []byte("nonce-1"), indices), "Expected the untouched show to be accepted")
+}
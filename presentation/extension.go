@@ -0,0 +1,68 @@
+package presentation
+
+import (
+	"github.com/aniagut/msc-bbs-anonymous-credentials/utils"
+	e "github.com/cloudflare/circl/ecc/bls12381"
+)
+
+// Extension proves an additional statement about the hidden attributes of a presentation in the same Fiat–Shamir
+// transcript as the proof of knowledge of the credential.
+//
+// The proof of knowledge proves knowledge of the randomizer r and of r * a[j] for every hidden attribute a[j], with
+// the responses Zr = vR + ch * r and Zi[j] = v[j] + ch * r * a[j]. An extension proves relations that are linear in
+// these witnesses (and in witnesses of its own) by committing with the same blindings vR and v[j], so that a verifier
+// can recompute its commitments from Zr and Zi.
+type Extension interface {
+	// Commit returns the commitments of the extension, which are appended to the input of the challenge.
+	// The witness is zeroized once the presentation returns and must not be retained.
+	Commit(witness Witness) ([]byte, error)
+	// Respond computes the responses of the extension to the challenge.
+	Respond(ch *e.Scalar) error
+}
+
+// Witness holds the secrets of a presentation that extensions prove statements about, with their blindings.
+// It contains the following elements:
+// - R: The randomizer r of the presentation.
+// - VR: The blinding of r.
+// - Hidden: The indices of the hidden attributes, in increasing order.
+// - Attributes: The hidden attributes a[j] as scalars, aligned with Hidden.
+// - V: The blindings of r * a[j], aligned with Hidden.
+type Witness struct {
+	R          *e.Scalar
+	VR         *e.Scalar
+	Hidden     []int
+	Attributes []e.Scalar
+	V          []e.Scalar
+}
+
+// Position returns the position of an attribute among the hidden attributes, which is also the position of its
+// response in the Zi of the proof.
+func (w Witness) Position(index int) (int, error) {
+	return utils.HiddenPosition(w.Hidden, index)
+}
+
+// commitExtensions computes the commitments of the extensions.
+func commitExtensions(extensions []Extension, attributes []string, revealed []int, hiddenAttributes []string, r, vR *e.Scalar, vJ []e.Scalar) ([][]byte, error) {
+	if len(extensions) == 0 {
+		return nil, nil
+	}
+
+	// Step 1: Assemble the witness
+	hiddenScalars := make([]e.Scalar, len(hiddenAttributes))
+	defer utils.ZeroizeScalarSlice(hiddenScalars)
+	for i, attribute := range hiddenAttributes {
+		hiddenScalars[i].SetBytes(utils.SerializeString(attribute))
+	}
+	witness := Witness{R: r, VR: vR, Hidden: utils.HiddenIndices(len(attributes), revealed), Attributes: hiddenScalars, V: vJ}
+
+	// Step 2: Collect the commitments of every extension
+	commitments := make([][]byte, len(extensions))
+	for i, extension := range extensions {
+		commitment, err := extension.Commit(witness)
+		if err != nil {
+			return nil, err
+		}
+		commitments[i] = commitment
+	}
+	return commitments, nil
+}
@@ -4,7 +4,6 @@ import (
     "fmt"
     "github.com/aniagut/msc-bbs-anonymous-credentials/models"
     "github.com/aniagut/msc-bbs-anonymous-credentials/utils"
-    "log"
     e "github.com/cloudflare/circl/ecc/bls12381"
 )
 
@@ -22,34 +21,58 @@ import (
 //   - SignatureProof: The generated proof of knowledge of the valid credential for the given attributes.
 //   - error: An error if the presentation process fails.
 func Presentation(attributes []string, credential models.Signature, revealed []int, publicParams models.PublicParameters, nonce []byte) (models.SignatureProof, error){
-    // Step 1: Compute the revealed and hidden attributes
+    return PresentationWithExtensions(attributes, credential, revealed, publicParams, nonce)
+}
+
+// PresentationWithExtensions presents attributes like Presentation and additionally proves the statements of the
+// extensions about the hidden attributes in the same Fiat–Shamir transcript.
+//
+// Parameters:
+//   - attributes: The list of attributes to be presented.
+//   - credential: The BBS+ signature representing the credential.
+//   - revealed: The list of indexes for revealed attributes.
+//   - publicParams: The public parameters of the system.
+//   - nonce: A random nonce used for the proof.
+//   - extensions: The extensions proving additional statements, which hold their own proofs once the function returns.
+//
+// Returns:
+//   - SignatureProof: The generated proof of knowledge of the valid credential for the given attributes.
+//   - error: An error if the presentation process or an extension fails.
+//
+// The ephemeral scalars r, vR, vE and {vJ} are zeroized before it returns.
+func PresentationWithExtensions(attributes []string, credential models.Signature, revealed []int, publicParams models.PublicParameters, nonce []byte, extensions ...Extension) (models.SignatureProof, error) {
+    // Step 1: Compute the revealed and hidden attributes (revealed may be empty to prove mere possession of the credential)
     revealedAttributes, hiddenAttributes, err := ComputeRevealedAndHiddenAttributes(attributes, revealed)
     if err != nil {
-        log.Printf("Error computing revealed and hidden attributes: %v", err)
+        utils.Logger().Debug("error computing revealed and hidden attributes", "error", err)
         return models.SignatureProof{}, err
     }
+    if len(attributes) != len(publicParams.H1) {
+        return models.SignatureProof{}, fmt.Errorf("%w: %d attributes, %d generators", models.ErrLengthMismatch, len(attributes), len(publicParams.H1))
+    }
 
     // Step 2: Compute h values h₁[i] ← g1^m[i] for revealed and hidden attributes a[i]
     revealedH, hiddenH, err := utils.ComputeRevealedAndHiddenH(publicParams.H1, revealed)
     if err != nil {
-        log.Printf("Error computing revealed and hidden h values: %v", err)
+        utils.Logger().Debug("error computing revealed and hidden h values", "error", err)
         return models.SignatureProof{}, err
     }
 
     // Step 3: Compute the commitment for revealed attributes C_rev ← g1 * ∏_i h₁[i]^a[i]
-    // where m[i] is the i-th revealed attribute.
+    // where m[i] is the i-th revealed attribute. If no attribute is revealed, C_rev = g1.
     CRev, err := utils.ComputeCommitment(revealedAttributes, revealedH, publicParams.G1)
     if err != nil {
-        log.Printf("Error computing commitment: %v", err)
+        utils.Logger().Debug("error computing commitment", "error", err)
         return models.SignatureProof{}, err
     }
 
     // Step 4: Select random r ← Z_p*
     r, err := utils.RandomScalar()
     if err != nil {
-        log.Printf("Error generating random scalar r: %v", err)
+        utils.Logger().Debug("error generating random scalar r", "error", err)
         return models.SignatureProof{}, err
     }
+    defer utils.ZeroizeScalars(&r)
 
     // Step 5: Compute the signature component APrim ← A^r
     APrim := new(e.G1)
@@ -58,35 +81,52 @@ func Presentation(attributes []string, credential models.Signature, revealed []i
     // Step 6: Compute the signature component BPrim = C^r * A^(-re)
     BPrim, err := ComputeBPrim(attributes, APrim, credential.E, publicParams, r)
     if err != nil {
-        log.Printf("Error computing BPrim: %v", err)
+        utils.Logger().Debug("error computing BPrim", "error", err)
         return models.SignatureProof{}, err
     }
 
     // Step 7: Compute random scalars vR, vE, {vJ} for j ∈ hidden
     vR, vE, vJ, err := ComputeVValues(len(hiddenAttributes))
     if err != nil {
-        log.Printf("Error generating random scalars: %v", err)
+        utils.Logger().Debug("error generating random scalars", "error", err)
         return models.SignatureProof{}, err
     }
+    defer func() {
+        utils.ZeroizeScalars(&vR, &vE)
+        utils.ZeroizeScalarSlice(vJ)
+    }()
 
     // Step 8: Compute U ← CRev^vR * ∏_j h₁[j]^vJ * APrim^vE for j ∈ hidden
     U, err := ComputeU(vR, vE, vJ, CRev, APrim, hiddenH)
     if err != nil {
-        log.Printf("Error computing U: %v", err)
+        utils.Logger().Debug("error computing U", "error", err)
+        return models.SignatureProof{}, err
+    }
+
+    // Step 9: Compute the commitments of the extensions with the blindings of r and {r * a_j} for j ∈ hidden
+    commitments, err := commitExtensions(extensions, attributes, revealed, hiddenAttributes, &r, &vR, vJ)
+    if err != nil {
+        utils.Logger().Debug("error committing extensions", "error", err)
         return models.SignatureProof{}, err
     }
 
-    // Step 9: Compute the challenge ch ← H(nonce, U, APrim, BPrim, {a_i}) for i ∈ revealed
-    ch, err := utils.ComputeChallenge(nonce, U, APrim, BPrim, revealedAttributes)
+    // Step 10: Compute the challenge ch ← H(nonce, U, APrim, BPrim, {a_i}, extensions) for i ∈ revealed
+    ch, err := utils.ComputeExtendedChallenge(nonce, U, APrim, BPrim, revealedAttributes, commitments)
     if err != nil {
-        log.Printf("Error computing challenge: %v", err)
+        utils.Logger().Debug("error computing challenge", "error", err)
         return models.SignatureProof{}, err
     }
 
-    // Step 10: Blind vR, {vJ} for j ∈ hidden and vE
+    // Step 11: Blind vR, {vJ} for j ∈ hidden and vE, and let the extensions respond to the challenge
     zR, zE, zJ := ComputeZValues(vR, vE, vJ, credential.E, ch, r, hiddenAttributes)
+    for _, extension := range extensions {
+        if err := extension.Respond(&ch); err != nil {
+            utils.Logger().Debug("error computing extension responses", "error", err)
+            return models.SignatureProof{}, err
+        }
+    }
 
-    // Step 11: Return the proof of knowledge of the valid credential for the given attributes
+    // Step 12: Return the proof of knowledge of the valid credential for the given attributes
     return models.SignatureProof{
         APrim: APrim,
         BPrim: BPrim,
@@ -99,16 +139,13 @@ func Presentation(attributes []string, credential models.Signature, revealed []i
 
 // ComputeRevealedAndHiddenAttributes computes the lists of hidden and revealed attributes based on the given indexes.
 func ComputeRevealedAndHiddenAttributes(attributes []string, revealed []int) ([]string, []string, error) {
-    if len(revealed) == 0 {
-        return nil, nil, fmt.Errorf("no revealed attributes provided")
-    }
     if len(revealed) > len(attributes) {
-        return nil, nil, fmt.Errorf("revealed attributes exceed total attributes")
+        return nil, nil, models.ErrTooManyRevealed
     }
     // Check if revealed indexes are valid
     for _, index := range revealed {
         if index < 0 || index >= len(attributes) {
-            return nil, nil, fmt.Errorf("revealed index %d out of bounds", index)
+            return nil, nil, fmt.Errorf("%w: index %d, %d attributes", models.ErrIndexOutOfRange, index, len(attributes))
         }
     }
     
@@ -139,7 +176,7 @@ func ComputeBPrim(attributes []string, APrim *e.G1, elem *e.Scalar, publicParams
     // where m[i] is the i-th attribute.
     C, err := utils.ComputeCommitment(attributes, publicParams.H1, publicParams.G1)
     if err != nil {
-        log.Printf("Error computing commitment: %v", err)
+        utils.Logger().Debug("error computing commitment", "error", err)
         return nil, err
     }
     // Step 2: Compute C^r
@@ -152,6 +189,7 @@ func ComputeBPrim(attributes []string, APrim *e.G1, elem *e.Scalar, publicParams
     eNeg.Neg()
     APrimExp := new(e.G1)
     APrimExp.ScalarMult(eNeg, APrim)
+    utils.ZeroizeScalars(eNeg)
 
     // Step 4: Compute BPrim = C^r * A^(-re)
     BPrim := new(e.G1)
@@ -166,14 +204,14 @@ func ComputeVValues(hiddenAttrLen int) (e.Scalar, e.Scalar, []e.Scalar, error) {
     // Step 1. Compute random scalar vR <- Z_p*
     vR, err := utils.RandomScalar()
     if err != nil {
-        log.Printf("Error generating random scalar vR: %v", err)
+        utils.Logger().Debug("error generating random scalar vR", "error", err)
         return e.Scalar{}, e.Scalar{}, []e.Scalar{}, err
     }
 
     // Step 2. Compute random scalar vE <- Z_p*
     vE, err := utils.RandomScalar()
     if err != nil {
-        log.Printf("Error generating random scalar vE: %v", err)
+        utils.Logger().Debug("error generating random scalar vE", "error", err)
         return e.Scalar{}, e.Scalar{}, []e.Scalar{}, err
     }
 
@@ -182,7 +220,7 @@ func ComputeVValues(hiddenAttrLen int) (e.Scalar, e.Scalar, []e.Scalar, error) {
     for i := 0; i < hiddenAttrLen; i++ {
         vJ[i], err = utils.RandomScalar()
         if err != nil {
-            log.Printf("Error generating random scalar vJ[%d]: %v", i, err)
+            utils.Logger().Debug("error generating random scalar vJ", "index", i, "error", err)
             return e.Scalar{}, e.Scalar{}, []e.Scalar{}, err
         }
     }
@@ -198,7 +236,7 @@ func ComputeU(vR e.Scalar, vE e.Scalar, vJ []e.Scalar, CRev *e.G1, APrim *e.G1,
     // Step 2: Compute ∏_j h₁[j]^vJ for j ∈ hidden
     h1ExpVJ, err := utils.ComputeH1Exp(hiddenH, vJ)
     if err != nil {
-        log.Printf("Error computing hidden h1 exponent: %v", err)
+        utils.Logger().Debug("error computing hidden h1 exponent", "error", err)
         return nil, err
     }
     // Step 3: Compute APrim^vE
@@ -236,6 +274,7 @@ func ComputeZValues(vR e.Scalar, vE e.Scalar, vJ []e.Scalar, elem *e.Scalar, ch
         aScalar.SetBytes(utils.SerializeString(hiddenAttributes[i]))
         zJ[i].Mul(&zJ[i], aScalar)
         zJ[i].Add(&zJ[i], &vJ[i])
+        utils.ZeroizeScalars(aScalar)
     }
     
     // Step 4: Return zR, zJ, and zE
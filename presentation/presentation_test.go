@@ -3,7 +3,10 @@ package presentation
 import (
     "testing"
 
+    "github.com/aniagut/msc-bbs-anonymous-credentials/issue"
     "github.com/aniagut/msc-bbs-anonymous-credentials/models"
+    "github.com/aniagut/msc-bbs-anonymous-credentials/setup"
+    "github.com/aniagut/msc-bbs-anonymous-credentials/verify"
     e "github.com/cloudflare/circl/ecc/bls12381"
     "github.com/stretchr/testify/assert"
 )
@@ -59,7 +62,7 @@ func TestPresentation_InvalidRevealedIndices(t *testing.T) {
 
     _, err := Presentation(attributes, credential, revealed, publicParams, nonce)
 
-    assert.Error(t, err, "Expected an error for out-of-bounds revealed indices")
+    assert.ErrorIs(t, err, models.ErrIndexOutOfRange, "Expected an error for out-of-bounds revealed indices")
 }
 
 // Test for empty attributes
@@ -73,4 +76,45 @@ func TestPresentation_EmptyAttributes(t *testing.T) {
     _, err := Presentation(attributes, credential, revealed, publicParams, nonce)
 
     assert.Error(t, err, "Expected an error for empty attributes")
-}
\ No newline at end of file
+}
+
+// PresentAndVerify issues a credential, presents it with the given revealed indices and verifies the proof
+func PresentAndVerify(t *testing.T, attributes []string, revealed []int) (models.SignatureProof, bool, error) {
+    setupResult, err := setup.Setup(len(attributes))
+    assert.NoError(t, err, "Expected no error during setup")
+    credential, err := issue.Issue(attributes, setupResult.PublicParameters, setupResult.SecretKey)
+    assert.NoError(t, err, "Expected no error during issuance")
+    nonce := []byte("random_nonce")
+
+    proof, err := Presentation(attributes, credential, revealed, setupResult.PublicParameters, nonce)
+    assert.NoError(t, err, "Expected no error during proof generation")
+
+    revealedAttributes := make([]string, len(revealed))
+    for i, index := range revealed {
+        revealedAttributes[i] = attributes[index]
+    }
+    valid, err := verify.Verify(proof, nonce, revealedAttributes, revealed, setupResult.PublicParameters, setupResult.PublicKey)
+    return proof, valid, err
+}
+
+// Test for a presentation that hides every attribute, proving mere possession of the credential
+func TestPresentation_FullyHidden(t *testing.T) {
+    attributes := []string{"attribute1", "attribute2", "attribute3"}
+
+    proof, valid, err := PresentAndVerify(t, attributes, []int{})
+
+    assert.NoError(t, err, "Expected no error when verifying a fully hidden presentation")
+    assert.True(t, valid, "Expected the fully hidden presentation to be valid")
+    assert.Equal(t, len(attributes), len(proof.Zi), "Expected one response per hidden attribute")
+}
+
+// Test for a presentation that reveals every attribute
+func TestPresentation_FullyRevealed(t *testing.T) {
+    attributes := []string{"attribute1", "attribute2", "attribute3"}
+
+    proof, valid, err := PresentAndVerify(t, attributes, []int{0, 1, 2})
+
+    assert.NoError(t, err, "Expected no error when verifying a fully revealed presentation")
+    assert.True(t, valid, "Expected the fully revealed presentation to be valid")
+    assert.Empty(t, proof.Zi, "Expected no responses when every attribute is revealed")
+}
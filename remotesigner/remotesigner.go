@@ -0,0 +1,276 @@
+// Package remotesigner runs an issue.Signer in a separate keystore process and reaches it over a Unix socket,
+// so that the issuer's secret key never enters the memory of the issuing service.
+//
+// Every connection carries newline-delimited JSON messages. A request holds the commitment C (a compressed element of
+// G1) and the exponent e (a scalar), both unpadded base64url, and the response holds A = C^{1/(x+e)} in the same
+// encoding, or an error message. The Client checks every response against the issuer's public key, so a faulty or
+// compromised keystore cannot make the issuer hand out invalid signatures.
+package remotesigner
+
+import (
+	"bufio"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/aniagut/msc-bbs-anonymous-credentials/issue"
+	"github.com/aniagut/msc-bbs-anonymous-credentials/models"
+	"github.com/aniagut/msc-bbs-anonymous-credentials/utils"
+	e "github.com/cloudflare/circl/ecc/bls12381"
+)
+
+// DefaultTimeout bounds a signing round trip of the Client.
+const DefaultTimeout = 5 * time.Second
+
+// DefaultIdleTimeout bounds the time the Server waits for the next request of a connection.
+const DefaultIdleTimeout = 30 * time.Second
+
+// maxMessageSize bounds the size of a message, which is far larger than any valid request or response.
+const maxMessageSize = 4096
+
+// ErrRemote is returned when the keystore process is unreachable, answers with an error or returns an invalid signature.
+var ErrRemote = errors.New("remote signer failed")
+
+// Request is a signing request.
+// It contains the following elements:
+// - Commitment: The commitment C, a compressed element of G1.
+// - Exponent: The exponent e.
+type Request struct {
+	Commitment string `json:"commitment"`
+	Exponent   string `json:"exponent"`
+}
+
+// Response is the answer to a signing request.
+// It contains the following elements:
+// - A: The signature component A = C^{1/(x+e)}, a compressed element of G1.
+// - Error: The reason the request failed.
+type Response struct {
+	A     string `json:"a,omitempty"`
+	Error string `json:"error,omitempty"`
+}
+
+// Server answers signing requests with a Signer, typically an issue.KeySigner in the keystore process.
+type Server struct {
+	signer      issue.Signer
+	idleTimeout time.Duration
+}
+
+// NewServer creates a server for the signer, closing connections idle for DefaultIdleTimeout.
+func NewServer(signer issue.Signer) *Server {
+	return &Server{signer: signer, idleTimeout: DefaultIdleTimeout}
+}
+
+// Listen creates a Unix socket at path that only the owner of the process may connect to.
+// The socket is bound in a fresh directory that only the owner may enter and restricted there before it is linked
+// at path, so that it is never reachable with the default permissions. Closing the listener removes the socket.
+//
+// Parameters:
+//   - path: The path of the socket, which must not exist.
+//
+// Returns:
+//   - net.Listener: The listener.
+//   - error: An error if the socket cannot be created.
+func Listen(path string) (net.Listener, error) {
+	// Step 1: Bind the socket in a private directory next to path and restrict its permissions
+	dir, err := os.MkdirTemp(filepath.Dir(path), ".signer-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+	private := filepath.Join(dir, "s")
+	listener, err := net.ListenUnix("unix", &net.UnixAddr{Name: private, Net: "unix"})
+	if err != nil {
+		return nil, err
+	}
+	listener.SetUnlinkOnClose(false)
+	if err := os.Chmod(private, 0o600); err != nil {
+		listener.Close()
+		return nil, err
+	}
+
+	// Step 2: Link the socket at path, failing if path exists
+	if err := os.Link(private, path); err != nil {
+		listener.Close()
+		return nil, err
+	}
+	return &socketListener{UnixListener: listener, path: path}, nil
+}
+
+// socketListener is a Unix listener bound through Listen, which removes the socket at path when closed.
+type socketListener struct {
+	*net.UnixListener
+	path string
+}
+
+// Addr implements net.Listener.
+func (l *socketListener) Addr() net.Addr {
+	return &net.UnixAddr{Name: l.path, Net: "unix"}
+}
+
+// Close implements net.Listener.
+func (l *socketListener) Close() error {
+	err := l.UnixListener.Close()
+	if err == nil {
+		os.Remove(l.path)
+	}
+	return err
+}
+
+// Serve accepts connections until the listener is closed, serving each one in its own goroutine.
+//
+// Parameters:
+//   - listener: The listener, usually from Listen.
+//
+// Returns:
+//   - error: nil once the listener is closed, or the error that stopped accepting connections.
+func (s *Server) Serve(listener net.Listener) error {
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+			return err
+		}
+		go s.ServeConn(conn)
+	}
+}
+
+// ServeConn answers the requests of a connection until it is closed, a request is malformed or the connection stays
+// idle for the idle timeout of the server.
+func (s *Server) ServeConn(conn net.Conn) {
+	defer conn.Close()
+	scanner := bufio.NewScanner(conn)
+	scanner.Buffer(make([]byte, 0, maxMessageSize), maxMessageSize)
+	encoder := json.NewEncoder(conn)
+	for {
+		// The deadline also bounds writing the response to a client that stops reading
+		if err := conn.SetDeadline(time.Now().Add(s.idleTimeout)); err != nil || !scanner.Scan() {
+			return
+		}
+		var req Request
+		if err := json.Unmarshal(scanner.Bytes(), &req); err != nil {
+			encoder.Encode(Response{Error: "malformed request"})
+			return
+		}
+		if err := encoder.Encode(s.handle(req)); err != nil {
+			return
+		}
+	}
+}
+
+// handle decodes a request and signs it.
+func (s *Server) handle(req Request) Response {
+	C, err := decodeG1(req.Commitment)
+	if err != nil {
+		return Response{Error: "invalid commitment"}
+	}
+	exponent, err := decodeScalar(req.Exponent)
+	if err != nil {
+		return Response{Error: "invalid exponent"}
+	}
+	A, err := s.signer.Sign(C, exponent)
+	if err != nil {
+		utils.Logger().Debug("error signing a remote request", "error", err)
+		return Response{Error: "signing failed"}
+	}
+	return Response{A: base64.RawURLEncoding.EncodeToString(A.BytesCompressed())}
+}
+
+// Client is an issue.Signer that forwards requests to a keystore process over a Unix socket.
+type Client struct {
+	path      string
+	publicKey models.PublicKey
+	timeout   time.Duration
+}
+
+// NewClient creates a client for the socket at path.
+//
+// Parameters:
+//   - path: The path of the socket of the keystore process.
+//   - publicKey: The public key of the issuer, used to check the responses.
+//
+// Returns:
+//   - *Client: The client, with DefaultTimeout.
+func NewClient(path string, publicKey models.PublicKey) *Client {
+	return &Client{path: path, publicKey: publicKey, timeout: DefaultTimeout}
+}
+
+// Sign implements issue.Signer.
+func (c *Client) Sign(commitment *e.G1, exponent *e.Scalar) (*e.G1, error) {
+	// Step 1: Encode the request
+	if commitment == nil || exponent == nil || c.publicKey.X2 == nil {
+		return nil, fmt.Errorf("%w: missing commitment, exponent or public key", ErrRemote)
+	}
+	exponentBytes, err := exponent.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	req := Request{
+		Commitment: base64.RawURLEncoding.EncodeToString(commitment.BytesCompressed()),
+		Exponent:   base64.RawURLEncoding.EncodeToString(exponentBytes),
+	}
+
+	// Step 2: Send the request and read the response
+	conn, err := net.DialTimeout("unix", c.path, c.timeout)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrRemote, err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(c.timeout))
+	if err := json.NewEncoder(conn).Encode(req); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrRemote, err)
+	}
+	var resp Response
+	if err := json.NewDecoder(bufio.NewReaderSize(conn, maxMessageSize)).Decode(&resp); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrRemote, err)
+	}
+	if resp.Error != "" {
+		return nil, fmt.Errorf("%w: %s", ErrRemote, resp.Error)
+	}
+
+	// Step 3: Check e(A, X2 * g2^e) = e(C, g2)
+	A, err := decodeG1(resp.A)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrRemote, err)
+	}
+	g2 := e.G2Generator()
+	x2e := new(e.G2)
+	x2e.ScalarMult(exponent, g2)
+	x2e.Add(x2e, c.publicKey.X2)
+	if !e.Pair(A, x2e).IsEqual(e.Pair(commitment, g2)) {
+		return nil, fmt.Errorf("%w: the response does not match the public key", ErrRemote)
+	}
+	return A, nil
+}
+
+// decodeG1 decodes a base64url-encoded compressed element of G1.
+func decodeG1(s string) (*e.G1, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil || len(b) != models.G1Size {
+		return nil, fmt.Errorf("%w: invalid G1 element", models.ErrInvalidEncoding)
+	}
+	g := new(e.G1)
+	if err := g.SetBytes(b); err != nil {
+		return nil, fmt.Errorf("%w: %v", models.ErrInvalidEncoding, err)
+	}
+	return g, nil
+}
+
+// decodeScalar decodes a base64url-encoded scalar.
+func decodeScalar(s string) (*e.Scalar, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil || len(b) != models.ScalarSize {
+		return nil, fmt.Errorf("%w: invalid scalar", models.ErrInvalidEncoding)
+	}
+	scalar := new(e.Scalar)
+	if err := scalar.UnmarshalBinary(b); err != nil {
+		return nil, fmt.Errorf("%w: %v", models.ErrInvalidEncoding, err)
+	}
+	return scalar, nil
+}
@@ -0,0 +1,133 @@
+package remotesigner
+
+import (
+	"bufio"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/aniagut/msc-bbs-anonymous-credentials/issue"
+	"github.com/aniagut/msc-bbs-anonymous-credentials/models"
+	"github.com/aniagut/msc-bbs-anonymous-credentials/setup"
+	"github.com/stretchr/testify/assert"
+)
+
+// MockKeystore starts a keystore server for the secret key on a fresh Unix socket and returns the socket path
+func MockKeystore(t *testing.T, secretKey models.SecretKey) string {
+	// Socket paths are limited in length, so avoid the long per-test directories
+	dir, err := os.MkdirTemp("", "signer")
+	assert.NoError(t, err, "Expected no error creating the socket directory")
+	path := filepath.Join(dir, "signer.sock")
+	listener, err := Listen(path)
+	assert.NoError(t, err, "Expected no error listening on the socket")
+	go NewServer(issue.NewKeySigner(secretKey)).Serve(listener)
+	t.Cleanup(func() {
+		listener.Close()
+		os.RemoveAll(dir)
+	})
+	return path
+}
+
+// Test for issuing credentials with the key held by the keystore process
+func TestClient_Issue(t *testing.T) {
+	setupResult, err := setup.Setup(3)
+	assert.NoError(t, err, "Expected no error during setup")
+	path := MockKeystore(t, setupResult.SecretKey)
+
+	info, err := os.Stat(path)
+	assert.NoError(t, err, "Expected the socket to exist")
+	assert.Equal(t, os.FileMode(0o600), info.Mode().Perm(), "Expected the socket to be private")
+
+	client := NewClient(path, setupResult.PublicKey)
+	attributes := []string{"Alice", "30", "PL"}
+	signature, err := issue.IssueWithSigner(attributes, setupResult.PublicParameters, client)
+	assert.NoError(t, err, "Expected no error issuing with the remote signer")
+	valid, err := issue.VerifyCredential(attributes, signature, setupResult.PublicParameters, setupResult.PublicKey)
+	assert.NoError(t, err, "Expected no error verifying the credential")
+	assert.True(t, valid, "Expected the credential to verify")
+
+	commitment, secret, err := issue.CommitBlind(nil, nil, 0, setupResult.PublicParameters, []byte("nonce"))
+	assert.NoError(t, err, "Expected no error committing to the hidden attribute")
+	signature, err = issue.IssueBlindWithSigner(commitment, map[int]string{1: "30", 2: "PL"}, setupResult.PublicParameters, client, []byte("nonce"))
+	assert.NoError(t, err, "Expected no error issuing blindly with the remote signer")
+	valid, _ = issue.VerifyCredential([]string{secret, "30", "PL"}, signature, setupResult.PublicParameters, setupResult.PublicKey)
+	assert.True(t, valid, "Expected the blind credential to verify")
+}
+
+// Test for detecting a keystore holding another key
+func TestClient_WrongKey(t *testing.T) {
+	setupResult, _ := setup.Setup(2)
+	other, _ := setup.Setup(2)
+	path := MockKeystore(t, other.SecretKey)
+
+	_, err := issue.IssueWithSigner([]string{"a", "b"}, setupResult.PublicParameters, NewClient(path, setupResult.PublicKey))
+	assert.ErrorIs(t, err, ErrRemote, "Expected a response for another key to be rejected")
+	assert.ErrorIs(t, err, models.ErrSigningFailed, "Expected the issuance to fail")
+}
+
+// Test for unreachable keystores and malformed requests
+func TestServer_Errors(t *testing.T) {
+	setupResult, _ := setup.Setup(1)
+
+	_, err := issue.IssueWithSigner([]string{"a"}, setupResult.PublicParameters, NewClient("/nonexistent/signer.sock", setupResult.PublicKey))
+	assert.ErrorIs(t, err, ErrRemote, "Expected an error for an unreachable keystore")
+
+	path := MockKeystore(t, setupResult.SecretKey)
+	conn, err := net.Dial("unix", path)
+	assert.NoError(t, err, "Expected no error connecting to the keystore")
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+
+	conn.Write([]byte(`{"commitment":"AA","exponent":"AA"}` + "\n"))
+	line, err := reader.ReadString('\n')
+	assert.NoError(t, err, "Expected a response")
+	assert.JSONEq(t, `{"error":"invalid commitment"}`, line, "Expected an error for an invalid commitment")
+
+	conn.Write([]byte("not json\n"))
+	line, err = reader.ReadString('\n')
+	assert.NoError(t, err, "Expected a response")
+	assert.JSONEq(t, `{"error":"malformed request"}`, line, "Expected an error for a malformed request")
+}
+
+// Test for the socket lifecycle: no leftover directory, no overwritten path, removal on close
+func TestListen_Lifecycle(t *testing.T) {
+	dir, err := os.MkdirTemp("", "signer")
+	assert.NoError(t, err, "Expected no error creating the socket directory")
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "signer.sock")
+
+	listener, err := Listen(path)
+	assert.NoError(t, err, "Expected no error listening on the socket")
+	entries, _ := os.ReadDir(dir)
+	assert.Len(t, entries, 1, "Expected only the socket in the directory")
+	assert.Equal(t, path, listener.Addr().String(), "Expected the listener to report the socket path")
+
+	_, err = Listen(path)
+	assert.Error(t, err, "Expected an existing path not to be replaced")
+
+	assert.NoError(t, listener.Close(), "Expected no error closing the listener")
+	_, err = os.Stat(path)
+	assert.True(t, os.IsNotExist(err), "Expected the socket to be removed on close")
+}
+
+// Test for closing connections that stay idle
+func TestServer_IdleTimeout(t *testing.T) {
+	setupResult, _ := setup.Setup(1)
+	server := NewServer(issue.NewKeySigner(setupResult.SecretKey))
+	server.idleTimeout = 50 * time.Millisecond
+	client, conn := net.Pipe()
+	defer client.Close()
+
+	done := make(chan struct{})
+	go func() {
+		server.ServeConn(conn)
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected the idle connection to be closed")
+	}
+}
@@ -0,0 +1,240 @@
+package request
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/aniagut/msc-bbs-anonymous-credentials/models"
+	"github.com/aniagut/msc-bbs-anonymous-credentials/presentation"
+	"github.com/aniagut/msc-bbs-anonymous-credentials/verify"
+)
+
+// Issuer describes an issuer trusted by the verifier.
+// It contains the following elements:
+// - PublicParameters: The public parameters the issuer signs under.
+// - PublicKey: The public key of the issuer.
+// - Schema: The names of the attributes in the issuer's credentials, in signing order.
+type Issuer struct {
+	PublicParameters models.PublicParameters
+	PublicKey        models.PublicKey
+	Schema           []string
+}
+
+// Respond builds the holder's response to a presentation request from a stored credential.
+// It reveals every requested attribute and every attribute referenced by a predicate, and keeps the rest hidden.
+//
+// Parameters:
+//   - req: The presentation request received from the verifier.
+//   - credential: The credential stored by the holder.
+//   - publicParams: The public parameters of the issuer of the credential.
+//
+// Returns:
+//   - models.PresentationResponse: The response to be sent to the verifier.
+//   - error: An error if the credential cannot satisfy the request or the proof generation fails.
+func Respond(req models.PresentationRequest, credential models.Credential, publicParams models.PublicParameters) (models.PresentationResponse, error) {
+	// Step 1: Check that the request is still valid and accepts the issuer of the credential
+	if err := checkRequest(req, credential.IssuerKeyID, time.Now()); err != nil {
+		return models.PresentationResponse{}, err
+	}
+	if len(credential.Schema) != len(credential.Attributes) {
+		return models.PresentationResponse{}, errors.New("credential schema does not match the attributes")
+	}
+
+	// Step 2: Compute the indices of the attributes that have to be revealed
+	revealed, err := RevealedIndices(req, credential.Schema)
+	if err != nil {
+		return models.PresentationResponse{}, err
+	}
+
+	// Step 3: Check locally that the predicates hold, so that no proof is produced for an unsatisfiable request
+	values := make(map[string]string, len(revealed))
+	revealedAttributes := make([]string, len(revealed))
+	for i, index := range revealed {
+		values[credential.Schema[index]] = credential.Attributes[index]
+		revealedAttributes[i] = credential.Attributes[index]
+	}
+	if err := CheckPredicates(req.Predicates, values); err != nil {
+		return models.PresentationResponse{}, err
+	}
+
+	// Step 4: Generate the proof bound to the nonce of the request
+	proof, err := presentation.Presentation(credential.Attributes, credential.Signature, revealed, publicParams, req.Nonce)
+	if err != nil {
+		return models.PresentationResponse{}, err
+	}
+
+	return models.PresentationResponse{
+		IssuerKeyID:        credential.IssuerKeyID,
+		Proof:              proof,
+		RevealedIndices:    revealed,
+		RevealedAttributes: revealedAttributes,
+	}, nil
+}
+
+// Check verifies that a presentation response fully satisfies the presentation request.
+// It checks the expiry of the request, that the issuer is accepted and trusted, that every requested attribute
+// is revealed at the position defined by the issuer's schema, that the predicates hold, and finally the proof itself.
+//
+// Parameters:
+//   - req: The presentation request sent to the holder.
+//   - resp: The response received from the holder.
+//   - issuers: The trusted issuers, by key ID.
+//
+// Returns:
+//   - map[string]string: The revealed attributes by name.
+//   - error: An error if the response does not satisfy the request.
+func Check(req models.PresentationRequest, resp models.PresentationResponse, issuers map[string]Issuer) (map[string]string, error) {
+	// Step 1: Check that the request is still valid and accepts the issuer of the response
+	if err := checkRequest(req, resp.IssuerKeyID, time.Now()); err != nil {
+		return nil, err
+	}
+	issuer, ok := issuers[resp.IssuerKeyID]
+	if !ok {
+		return nil, fmt.Errorf("issuer %q is not trusted", resp.IssuerKeyID)
+	}
+
+	// Step 2: Check that the revealed attributes are exactly the ones required by the request
+	expected, err := RevealedIndices(req, issuer.Schema)
+	if err != nil {
+		return nil, err
+	}
+	if len(resp.RevealedAttributes) != len(resp.RevealedIndices) {
+		return nil, errors.New("revealed attributes do not match revealed indices")
+	}
+	if !equalIndices(expected, resp.RevealedIndices) {
+		return nil, errors.New("revealed indices do not match the request")
+	}
+
+	// Step 3: Check the predicates over the revealed values
+	values := make(map[string]string, len(resp.RevealedIndices))
+	for i, index := range resp.RevealedIndices {
+		values[issuer.Schema[index]] = resp.RevealedAttributes[i]
+	}
+	if err := CheckPredicates(req.Predicates, values); err != nil {
+		return nil, err
+	}
+
+	// Step 4: Verify the proof against the nonce of the request
+	valid, err := verify.Verify(resp.Proof, req.Nonce, resp.RevealedAttributes, resp.RevealedIndices, issuer.PublicParameters, issuer.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+	if !valid {
+		return nil, errors.New("invalid presentation proof")
+	}
+	return values, nil
+}
+
+// RevealedIndices computes the sorted indices of the attributes that must be revealed to answer the request,
+// that is the requested attributes and the attributes referenced by predicates.
+func RevealedIndices(req models.PresentationRequest, schema []string) ([]int, error) {
+	positions := make(map[string]int, len(schema))
+	for i, name := range schema {
+		positions[name] = i
+	}
+
+	names := make([]string, 0, len(req.RequestedAttributes)+len(req.Predicates))
+	names = append(names, req.RequestedAttributes...)
+	for _, predicate := range req.Predicates {
+		names = append(names, predicate.Attribute)
+	}
+
+	seen := make(map[int]bool, len(names))
+	indices := make([]int, 0, len(names))
+	for _, name := range names {
+		index, ok := positions[name]
+		if !ok {
+			return nil, fmt.Errorf("attribute %q is not part of the credential schema", name)
+		}
+		if !seen[index] {
+			seen[index] = true
+			indices = append(indices, index)
+		}
+	}
+	sort.Ints(indices)
+	return indices, nil
+}
+
+// CheckPredicates evaluates the predicates over the given attribute values.
+func CheckPredicates(predicates []models.Predicate, values map[string]string) error {
+	for _, predicate := range predicates {
+		value, ok := values[predicate.Attribute]
+		if !ok {
+			return fmt.Errorf("attribute %q referenced by predicate is not revealed", predicate.Attribute)
+		}
+		holds, err := evaluate(predicate, value)
+		if err != nil {
+			return err
+		}
+		if !holds {
+			return fmt.Errorf("predicate %s %s %s is not satisfied", predicate.Attribute, predicate.Operator, predicate.Value)
+		}
+	}
+	return nil
+}
+
+// evaluate evaluates a single predicate over the value of its attribute.
+func evaluate(predicate models.Predicate, value string) (bool, error) {
+	switch predicate.Operator {
+	case models.PredicateEqual:
+		return value == predicate.Value, nil
+	case models.PredicateNotEqual:
+		return value != predicate.Value, nil
+	}
+
+	// Ordering operators compare the values as integers
+	left, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return false, fmt.Errorf("attribute %q is not an integer", predicate.Attribute)
+	}
+	right, err := strconv.ParseInt(predicate.Value, 10, 64)
+	if err != nil {
+		return false, fmt.Errorf("predicate value %q is not an integer", predicate.Value)
+	}
+	switch predicate.Operator {
+	case models.PredicateGreater:
+		return left > right, nil
+	case models.PredicateGreaterOrEqual:
+		return left >= right, nil
+	case models.PredicateLess:
+		return left < right, nil
+	case models.PredicateLessOrEqual:
+		return left <= right, nil
+	}
+	return false, fmt.Errorf("unsupported predicate operator %q", predicate.Operator)
+}
+
+// checkRequest checks that the request has not expired and accepts the given issuer key.
+func checkRequest(req models.PresentationRequest, issuerKeyID string, now time.Time) error {
+	if !req.Expiry.IsZero() && now.After(req.Expiry) {
+		return errors.New("presentation request has expired")
+	}
+	if len(req.Nonce) == 0 {
+		return errors.New("presentation request has no nonce")
+	}
+	if len(req.IssuerKeyIDs) == 0 {
+		return nil
+	}
+	for _, id := range req.IssuerKeyIDs {
+		if id == issuerKeyID {
+			return nil
+		}
+	}
+	return fmt.Errorf("issuer %q is not accepted by the request", issuerKeyID)
+}
+
+// equalIndices reports whether two index lists are identical.
+func equalIndices(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,135 @@
+package request
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aniagut/msc-bbs-anonymous-credentials/issue"
+	"github.com/aniagut/msc-bbs-anonymous-credentials/models"
+	"github.com/aniagut/msc-bbs-anonymous-credentials/setup"
+	"github.com/stretchr/testify/assert"
+)
+
+// MockIssuer sets up an issuer and a credential issued by it for testing
+func MockIssuer(t *testing.T) (Issuer, models.Credential) {
+	schema := []string{"name", "surname", "age", "country"}
+	attributes := []string{"Alice", "Smith", "30", "PL"}
+
+	setupResult, err := setup.Setup(len(schema))
+	assert.NoError(t, err, "Expected no error during setup")
+	signature, err := issue.Issue(attributes, setupResult.PublicParameters, setupResult.SecretKey)
+	assert.NoError(t, err, "Expected no error during issuance")
+
+	issuer := Issuer{
+		PublicParameters: setupResult.PublicParameters,
+		PublicKey:        setupResult.PublicKey,
+		Schema:           schema,
+	}
+	credential := models.Credential{
+		Schema:      schema,
+		Attributes:  attributes,
+		Signature:   signature,
+		IssuerKeyID: setupResult.PublicKey.KeyID(),
+	}
+	return issuer, credential
+}
+
+// Test for a response satisfying the request
+func TestRespondAndCheck_Success(t *testing.T) {
+	issuer, credential := MockIssuer(t)
+	req := models.PresentationRequest{
+		RequestedAttributes: []string{"country"},
+		Predicates:          []models.Predicate{{Attribute: "age", Operator: models.PredicateGreaterOrEqual, Value: "18"}},
+		Nonce:               []byte("random_nonce"),
+		IssuerKeyIDs:        []string{credential.IssuerKeyID},
+		Expiry:              time.Now().Add(time.Minute),
+	}
+
+	resp, err := Respond(req, credential, issuer.PublicParameters)
+	assert.NoError(t, err, "Expected no error when building the response")
+	assert.Equal(t, []int{2, 3}, resp.RevealedIndices, "Expected the requested and predicate attributes to be revealed")
+
+	values, err := Check(req, resp, map[string]Issuer{credential.IssuerKeyID: issuer})
+	assert.NoError(t, err, "Expected the response to satisfy the request")
+	assert.Equal(t, map[string]string{"age": "30", "country": "PL"}, values, "Expected the revealed attributes by name")
+}
+
+// Test for a predicate the credential does not satisfy
+func TestRespond_UnsatisfiedPredicate(t *testing.T) {
+	issuer, credential := MockIssuer(t)
+	req := models.PresentationRequest{
+		Predicates: []models.Predicate{{Attribute: "age", Operator: models.PredicateGreater, Value: "65"}},
+		Nonce:      []byte("random_nonce"),
+	}
+
+	_, err := Respond(req, credential, issuer.PublicParameters)
+	assert.Error(t, err, "Expected an error for an unsatisfied predicate")
+}
+
+// Test for a response that reveals fewer attributes than requested
+func TestCheck_MissingAttribute(t *testing.T) {
+	issuer, credential := MockIssuer(t)
+	req := models.PresentationRequest{
+		RequestedAttributes: []string{"name", "country"},
+		Nonce:               []byte("random_nonce"),
+	}
+	narrower := models.PresentationRequest{
+		RequestedAttributes: []string{"country"},
+		Nonce:               req.Nonce,
+	}
+
+	resp, err := Respond(narrower, credential, issuer.PublicParameters)
+	assert.NoError(t, err, "Expected no error when building the response")
+
+	_, err = Check(req, resp, map[string]Issuer{credential.IssuerKeyID: issuer})
+	assert.Error(t, err, "Expected an error for a response missing a requested attribute")
+}
+
+// Test for a response bound to a different nonce
+func TestCheck_NonceMismatch(t *testing.T) {
+	issuer, credential := MockIssuer(t)
+	req := models.PresentationRequest{
+		RequestedAttributes: []string{"country"},
+		Nonce:               []byte("random_nonce"),
+	}
+
+	resp, err := Respond(req, credential, issuer.PublicParameters)
+	assert.NoError(t, err, "Expected no error when building the response")
+
+	req.Nonce = []byte("other_nonce")
+	_, err = Check(req, resp, map[string]Issuer{credential.IssuerKeyID: issuer})
+	assert.Error(t, err, "Expected an error for a proof bound to another nonce")
+}
+
+// Test for an expired request and an untrusted issuer
+func TestCheck_ExpiredAndUntrusted(t *testing.T) {
+	issuer, credential := MockIssuer(t)
+	req := models.PresentationRequest{
+		RequestedAttributes: []string{"country"},
+		Nonce:               []byte("random_nonce"),
+	}
+
+	resp, err := Respond(req, credential, issuer.PublicParameters)
+	assert.NoError(t, err, "Expected no error when building the response")
+
+	_, err = Check(req, resp, map[string]Issuer{})
+	assert.Error(t, err, "Expected an error for an untrusted issuer")
+
+	req.Expiry = time.Now().Add(-time.Minute)
+	_, err = Check(req, resp, map[string]Issuer{credential.IssuerKeyID: issuer})
+	assert.Error(t, err, "Expected an error for an expired request")
+}
+
+// Test for a request that asks for no attributes, proving mere possession of a credential
+func TestRespondAndCheck_NothingRevealed(t *testing.T) {
+	issuer, credential := MockIssuer(t)
+	req := models.PresentationRequest{Nonce: []byte("random_nonce")}
+
+	resp, err := Respond(req, credential, issuer.PublicParameters)
+	assert.NoError(t, err, "Expected no error when building the response")
+	assert.Empty(t, resp.RevealedIndices, "Expected no attribute to be revealed")
+
+	values, err := Check(req, resp, map[string]Issuer{credential.IssuerKeyID: issuer})
+	assert.NoError(t, err, "Expected the response to satisfy the request")
+	assert.Empty(t, values, "Expected no revealed attributes")
+}
@@ -0,0 +1,70 @@
+package setup
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"github.com/aniagut/msc-bbs-anonymous-credentials/models"
+	e "github.com/cloudflare/circl/ecc/bls12381"
+)
+
+// generatorDST is the domain separation tag for hashing to the generators h_1[1..l].
+const generatorDST = "BBS_PLUS_BLS12381G1_XMD:SHA-256_SSWU_RO_H1_"
+
+// DeriveParameters derives the public parameters bound to a public key, so that verifiers who know only the key
+// (e.g. from its did:key identifier) can recompute them. The generators are h_1[i] = HashToG1(X2 || i) with the
+// compressed encoding of X2 and i as a 4-byte big-endian integer.
+//
+// Parameters:
+//   - publicKey: The public key of the issuer.
+//   - l: The number of independent generators to be derived.
+//
+// Returns:
+//   - models.PublicParameters: The derived public parameters.
+//   - error: An error if l is not positive or the key is missing.
+func DeriveParameters(publicKey models.PublicKey, l int) (models.PublicParameters, error) {
+	// Step 1: Validate the input parameters
+	if l <= 0 {
+		return models.PublicParameters{}, models.ErrInvalidSetupSize
+	}
+	if publicKey.X2 == nil {
+		return models.PublicParameters{}, fmt.Errorf("%w: missing X2", models.ErrInvalidEncoding)
+	}
+
+	// Step 2: Hash the key and the index of each generator to G1
+	seed := publicKey.X2.BytesCompressed()
+	h1 := make([]e.G1, l)
+	for i := range h1 {
+		h1[i].Hash(binary.BigEndian.AppendUint32(append([]byte(nil), seed...), uint32(i)), []byte(generatorDST))
+	}
+
+	return models.PublicParameters{
+		G1: e.G1Generator(),
+		G2: e.G2Generator(),
+		H1: h1,
+	}, nil
+}
+
+// SetupDerived generates a key pair together with public parameters derived from the public key with
+// DeriveParameters, instead of independent random generators.
+//
+// Parameters:
+//   - l: The number of independent generators to be derived.
+//
+// Returns:
+//   - models.SetupResult: The result containing public parameters, public key, and secret key.
+//   - error: An error if the setup process fails.
+func SetupDerived(l int) (models.SetupResult, error) {
+	// Step 1: Generate the keys
+	result, err := Setup(l)
+	if err != nil {
+		return models.SetupResult{}, err
+	}
+
+	// Step 2: Replace the random generators with the derived ones
+	result.PublicParameters, err = DeriveParameters(result.PublicKey, l)
+	if err != nil {
+		return models.SetupResult{}, err
+	}
+	return result, nil
+}
@@ -0,0 +1,36 @@
+package setup
+
+import (
+	"testing"
+
+	"github.com/aniagut/msc-bbs-anonymous-credentials/issue"
+	"github.com/aniagut/msc-bbs-anonymous-credentials/models"
+	"github.com/stretchr/testify/assert"
+)
+
+// Test for deriving the public parameters from the public key
+func TestDeriveParameters(t *testing.T) {
+	result, err := SetupDerived(4)
+	assert.NoError(t, err, "Expected no error during setup")
+
+	derived, err := DeriveParameters(result.PublicKey, 4)
+	assert.NoError(t, err, "Expected no error deriving the parameters")
+	for i := range derived.H1 {
+		assert.True(t, derived.H1[i].IsEqual(&result.PublicParameters.H1[i]), "Expected the same generators for the same key")
+	}
+	assert.False(t, derived.H1[0].IsEqual(&derived.H1[1]), "Expected distinct generators")
+
+	attributes := []string{"a", "b", "c", "d"}
+	signature, err := issue.Issue(attributes, derived, result.SecretKey)
+	assert.NoError(t, err, "Expected no error issuing with the derived parameters")
+	valid, err := issue.VerifyCredential(attributes, signature, derived, result.PublicKey)
+	assert.NoError(t, err, "Expected no error verifying the credential")
+	assert.True(t, valid, "Expected the credential to verify with the derived parameters")
+
+	other, _ := Setup(1)
+	otherDerived, _ := DeriveParameters(other.PublicKey, 1)
+	assert.False(t, otherDerived.H1[0].IsEqual(&derived.H1[0]), "Expected other keys to derive other generators")
+
+	_, err = DeriveParameters(result.PublicKey, 0)
+	assert.ErrorIs(t, err, models.ErrInvalidSetupSize, "Expected an error for l = 0")
+}
@@ -0,0 +1,39 @@
+package setup
+
+import (
+	"fmt"
+
+	"github.com/aniagut/msc-bbs-anonymous-credentials/models"
+	"github.com/aniagut/msc-bbs-anonymous-credentials/utils"
+	e "github.com/cloudflare/circl/ecc/bls12381"
+)
+
+// KeyGen generates a key pair for existing public parameters, so that several issuers can share them (e.g. for
+// issuer-hiding presentations).
+//
+// Parameters:
+//   - publicParams: The public parameters shared by the issuers.
+//
+// Returns:
+//   - models.SetupResult: The result containing the shared public parameters and the new public key and secret key.
+//   - error: An error if the parameters are missing g2 or the sampling fails.
+func KeyGen(publicParams models.PublicParameters) (models.SetupResult, error) {
+	// Step 1: Validate the public parameters
+	if publicParams.G2 == nil {
+		return models.SetupResult{}, fmt.Errorf("%w: missing G2", models.ErrInvalidEncoding)
+	}
+
+	// Step 2: Select the secret key x ← Z_p* and compute the public key X2 = g2^x
+	x, err := utils.RandomScalar()
+	if err != nil {
+		return models.SetupResult{}, err
+	}
+	x2 := new(e.G2)
+	x2.ScalarMult(&x, publicParams.G2)
+
+	return models.SetupResult{
+		PublicParameters: publicParams,
+		PublicKey:        models.PublicKey{X2: x2},
+		SecretKey:        models.SecretKey{X: &x},
+	}, nil
+}
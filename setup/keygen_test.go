@@ -0,0 +1,28 @@
+package setup
+
+import (
+	"testing"
+
+	"github.com/aniagut/msc-bbs-anonymous-credentials/issue"
+	"github.com/aniagut/msc-bbs-anonymous-credentials/models"
+	"github.com/stretchr/testify/assert"
+)
+
+// Test for generating a second issuer key for shared public parameters
+func TestKeyGen(t *testing.T) {
+	result, err := Setup(2)
+	assert.NoError(t, err, "Expected no error during setup")
+	other, err := KeyGen(result.PublicParameters)
+	assert.NoError(t, err, "Expected no error generating the key")
+	assert.False(t, other.PublicKey.X2.IsEqual(result.PublicKey.X2), "Expected a fresh key")
+
+	attributes := []string{"a", "b"}
+	signature, err := issue.Issue(attributes, other.PublicParameters, other.SecretKey)
+	assert.NoError(t, err, "Expected no error issuing with the new key")
+	valid, err := issue.VerifyCredential(attributes, signature, result.PublicParameters, other.PublicKey)
+	assert.NoError(t, err, "Expected no error verifying the credential")
+	assert.True(t, valid, "Expected the credential to verify with the shared parameters")
+
+	_, err = KeyGen(models.PublicParameters{})
+	assert.ErrorIs(t, err, models.ErrInvalidEncoding, "Expected an error for missing parameters")
+}
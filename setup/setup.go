@@ -1,11 +1,11 @@
 package setup
 
 import (
-	"github.com/aniagut/msc-bbs-plus-plus/keygen"
 	"github.com/aniagut/msc-bbs-anonymous-credentials/models"
-	"errors"
+	"github.com/aniagut/msc-bbs-anonymous-credentials/utils"
+	e "github.com/cloudflare/circl/ecc/bls12381"
 )
-	
+
 // Setup initializes the public parameters and keys for the BBS++ system.
 // It generates the generators g1 and g2, independent generators h_1[1..l], and the secret key x.
 // The function returns the public parameters, public key, and secret key.
@@ -20,28 +20,31 @@ import (
 func Setup(l int) (models.SetupResult, error) {
 	// Validate the input parameter l
 	if l <= 0 {
-		return models.SetupResult{}, errors.New("the number of independent generators must be greater than 0")
+		return models.SetupResult{}, models.ErrInvalidSetupSize
 	}
 
-	// Run KeyGen from the BBS++ library to generate the public parameters, public key and secret key
-	result, err := keygen.KeyGen(l)
+	// Select the generators g1 ∈ G1, g2 ∈ G2 and random independent generators h_1[1..l] of G1
+	h1, err := utils.GenerateLRandomG1Elements(l)
 	if err != nil {
 		return models.SetupResult{}, err
 	}
+	publicParams := models.PublicParameters{
+		G1: e.G1Generator(),
+		G2: e.G2Generator(),
+		H1: h1,
+	}
 
-	// Create the setup result struct
-	setupResult := models.SetupResult{
-		PublicParameters: models.PublicParameters{
-			G1: result.PublicParameters.G1,
-			G2: result.PublicParameters.G2,
-			H1: result.PublicParameters.H1,
-		},
-		PublicKey: models.PublicKey{
-			X2: result.VerificationKey.X2,
-		},
-		SecretKey: models.SecretKey{
-			X: result.SigningKey.X,
-		},
+	// Select the secret key x ← Z_p* with the constant-time utils.RandomScalar and compute the public key X2 = g2^x
+	x, err := utils.RandomScalar()
+	if err != nil {
+		return models.SetupResult{}, err
 	}
-	return setupResult, nil
-}
\ No newline at end of file
+	x2 := new(e.G2)
+	x2.ScalarMult(&x, publicParams.G2)
+
+	return models.SetupResult{
+		PublicParameters: publicParams,
+		PublicKey:        models.PublicKey{X2: x2},
+		SecretKey:        models.SecretKey{X: &x},
+	}, nil
+}
@@ -2,6 +2,7 @@ package setup
 
 import (
     "testing"
+    "github.com/aniagut/msc-bbs-anonymous-credentials/models"
     "github.com/stretchr/testify/assert"
 )
 
@@ -23,5 +24,5 @@ func TestSetup_InvalidInput(t *testing.T) {
     l := 0 // Invalid number of generators
     _, err := Setup(l)
 
-    assert.Error(t, err, "Expected an error for invalid input (l = 0)")
+    assert.ErrorIs(t, err, models.ErrInvalidSetupSize, "Expected an error for invalid input (l = 0)")
 }
\ No newline at end of file
@@ -0,0 +1,338 @@
+package threshold
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/aniagut/msc-bbs-anonymous-credentials/models"
+	"github.com/aniagut/msc-bbs-anonymous-credentials/utils"
+	e "github.com/cloudflare/circl/ecc/bls12381"
+)
+
+// The distributed key generation is the joint Feldman protocol of Pedersen: every participant deals a random secret
+// with Feldman VSS, participants complain about shares that do not match the dealer's commitment, dealers answer
+// complaints by revealing the disputed shares, and the key is the sum of the secrets of the qualified dealers.
+// Commitments, complaints and justifications must be broadcast so that every participant sees the same messages;
+// shares are sent over private channels. No participant learns x, and the outputs are compatible with Split.
+
+// Commitment is a dealer's broadcast Feldman commitment to its polynomial f.
+// It contains the following elements:
+// - Dealer: The index of the dealer.
+// - Coefficients: The commitments g2^{a_k} to the t coefficients of f, the constant term first.
+type Commitment struct {
+	Dealer       int
+	Coefficients []*e.G2
+}
+
+// Share is a dealer's private share for a recipient.
+// It contains the following elements:
+// - Dealer: The index of the dealer.
+// - Recipient: The index of the recipient.
+// - Value: The share f(Recipient).
+type Share struct {
+	Dealer    int
+	Recipient int
+	Value     *e.Scalar
+}
+
+// Complaint is a broadcast accusation that a dealer's share is missing or does not match its commitment.
+// It contains the following elements:
+// - Accuser: The index of the recipient complaining.
+// - Dealer: The index of the accused dealer.
+type Complaint struct {
+	Accuser int
+	Dealer  int
+}
+
+// Justification is a dealer's broadcast answer to a complaint, revealing the disputed share.
+// It contains the following elements:
+// - Dealer: The index of the dealer.
+// - Recipient: The index of the accuser.
+// - Value: The share f(Recipient).
+type Justification struct {
+	Dealer    int
+	Recipient int
+	Value     *e.Scalar
+}
+
+// session is one participant's view of a round of Feldman VSS in which every participant deals.
+type session struct {
+	index        int
+	threshold    int
+	n            int
+	coefficients []e.Scalar
+	commitments  map[int]Commitment
+	shares       map[int]*e.Scalar
+}
+
+// newSession creates a session dealing the secret with a random polynomial of degree t-1.
+func newSession(index, t, n int, secret *e.Scalar) (*session, error) {
+	if t < 1 || t > n {
+		return nil, fmt.Errorf("%w: %d of %d", ErrInvalidThreshold, t, n)
+	}
+	if index < 1 || index > n {
+		return nil, fmt.Errorf("%w: participant index %d out of range", ErrInvalidShare, index)
+	}
+	coefficients, err := randomPolynomial(secret, t-1)
+	if err != nil {
+		return nil, err
+	}
+	return &session{
+		index:        index,
+		threshold:    t,
+		n:            n,
+		coefficients: coefficients,
+		commitments:  make(map[int]Commitment),
+		shares:       make(map[int]*e.Scalar),
+	}, nil
+}
+
+// deal commits to the polynomial and computes the shares of the other participants, keeping its own share.
+func (s *session) deal() (Commitment, []Share) {
+	commitment := Commitment{Dealer: s.index, Coefficients: make([]*e.G2, len(s.coefficients))}
+	for k := range s.coefficients {
+		commitment.Coefficients[k] = commitG2(&s.coefficients[k])
+	}
+	s.commitments[s.index] = commitment
+	s.shares[s.index] = evaluate(s.coefficients, s.index)
+
+	shares := make([]Share, 0, s.n-1)
+	for j := 1; j <= s.n; j++ {
+		if j != s.index {
+			shares = append(shares, Share{Dealer: s.index, Recipient: j, Value: evaluate(s.coefficients, j)})
+		}
+	}
+	return commitment, shares
+}
+
+// receive stores the well-formed commitments that pass the check and the shares addressed to this participant,
+// and returns complaints against dealers whose share is missing or does not match their commitment.
+// Dealers with a malformed commitment are publicly disqualified and need no complaint.
+func (s *session) receive(commitments []Commitment, shares []Share, check func(Commitment) error) []Complaint {
+	// Step 1: Store the commitments of the other dealers
+	for _, c := range commitments {
+		if c.Dealer == s.index || c.Dealer < 1 || c.Dealer > s.n || len(c.Coefficients) != s.threshold {
+			continue
+		}
+		if _, seen := s.commitments[c.Dealer]; seen || hasNil(c.Coefficients) {
+			continue
+		}
+		if check != nil && check(c) != nil {
+			continue
+		}
+		s.commitments[c.Dealer] = c
+	}
+
+	// Step 2: Verify the shares addressed to this participant
+	for _, share := range shares {
+		if share.Recipient != s.index || share.Value == nil {
+			continue
+		}
+		c, ok := s.commitments[share.Dealer]
+		if !ok || share.Dealer == s.index {
+			continue
+		}
+		if _, seen := s.shares[share.Dealer]; !seen && commitG2(share.Value).IsEqual(evaluateCommitment(c.Coefficients, s.index)) {
+			s.shares[share.Dealer] = share.Value
+		}
+	}
+
+	// Step 3: Complain about the dealers without a valid share
+	var complaints []Complaint
+	for _, dealer := range s.dealers() {
+		if _, ok := s.shares[dealer]; !ok {
+			complaints = append(complaints, Complaint{Accuser: s.index, Dealer: dealer})
+		}
+	}
+	return complaints
+}
+
+// justify reveals the shares disputed by complaints against this participant.
+func (s *session) justify(complaints []Complaint) []Justification {
+	var justifications []Justification
+	for _, c := range complaints {
+		if c.Dealer == s.index && c.Accuser >= 1 && c.Accuser <= s.n && c.Accuser != s.index {
+			justifications = append(justifications, Justification{Dealer: s.index, Recipient: c.Accuser, Value: evaluate(s.coefficients, c.Accuser)})
+		}
+	}
+	return justifications
+}
+
+// qualify disqualifies the dealers that did not answer a complaint with a share matching their commitment, adopts
+// justified shares addressed to this participant, and returns the qualified dealers in increasing order.
+func (s *session) qualify(complaints []Complaint, justifications []Justification) []int {
+	disqualified := make(map[int]bool)
+	for _, complaint := range complaints {
+		c, ok := s.commitments[complaint.Dealer]
+		if !ok {
+			continue
+		}
+		justified := false
+		for _, j := range justifications {
+			if j.Dealer != complaint.Dealer || j.Recipient != complaint.Accuser || j.Value == nil {
+				continue
+			}
+			if commitG2(j.Value).IsEqual(evaluateCommitment(c.Coefficients, complaint.Accuser)) {
+				justified = true
+				if complaint.Accuser == s.index {
+					s.shares[complaint.Dealer] = j.Value
+				}
+				break
+			}
+		}
+		if !justified {
+			disqualified[complaint.Dealer] = true
+		}
+	}
+
+	qualified := make([]int, 0, len(s.commitments))
+	for _, dealer := range s.dealers() {
+		if _, ok := s.shares[dealer]; ok && !disqualified[dealer] {
+			qualified = append(qualified, dealer)
+		}
+	}
+	return qualified
+}
+
+// output combines the shares and commitments of the dealers with the given weights into this participant's share
+// of Σ w_j f_j(0), the verification shares of all participants and the commitment to the joint secret.
+func (s *session) output(dealers []int, weights []*e.Scalar) (*e.Scalar, []*e.G2, *e.G2) {
+	share := new(e.Scalar)
+	verificationShares := make([]*e.G2, s.n)
+	for i := range verificationShares {
+		verificationShares[i] = new(e.G2)
+		verificationShares[i].SetIdentity()
+	}
+	joint := new(e.G2)
+	joint.SetIdentity()
+
+	for k, dealer := range dealers {
+		weighted := new(e.Scalar)
+		weighted.Mul(weights[k], s.shares[dealer])
+		share.Add(share, weighted)
+
+		coefficients := s.commitments[dealer].Coefficients
+		for i := range verificationShares {
+			term := new(e.G2)
+			term.ScalarMult(weights[k], evaluateCommitment(coefficients, i+1))
+			verificationShares[i].Add(verificationShares[i], term)
+		}
+		term := new(e.G2)
+		term.ScalarMult(weights[k], coefficients[0])
+		joint.Add(joint, term)
+	}
+	return share, verificationShares, joint
+}
+
+// dealers returns the dealers with a stored commitment in increasing order.
+func (s *session) dealers() []int {
+	dealers := make([]int, 0, len(s.commitments))
+	for dealer := range s.commitments {
+		dealers = append(dealers, dealer)
+	}
+	sort.Ints(dealers)
+	return dealers
+}
+
+// Participant runs the distributed key generation.
+//
+// The protocol has four steps, each taking the messages of the previous one from all participants:
+// Deal, Receive, Justify and Finalize.
+type Participant struct {
+	session *session
+}
+
+// NewParticipant creates a participant of a distributed key generation.
+//
+// Parameters:
+//   - index: The index of the participant, from 1 to n.
+//   - t: The threshold.
+//   - n: The number of participants.
+//
+// Returns:
+//   - *Participant: The participant.
+//   - error: An error if the index or threshold is invalid or sampling fails.
+func NewParticipant(index, t, n int) (*Participant, error) {
+	secret, err := utils.RandomScalar()
+	if err != nil {
+		return nil, err
+	}
+	s, err := newSession(index, t, n, &secret)
+	if err != nil {
+		return nil, err
+	}
+	return &Participant{session: s}, nil
+}
+
+// Deal returns the commitment to broadcast and the shares to send privately to the other participants.
+func (p *Participant) Deal() (Commitment, []Share) {
+	return p.session.deal()
+}
+
+// Receive verifies the broadcast commitments and the shares received, and returns the complaints to broadcast.
+func (p *Participant) Receive(commitments []Commitment, shares []Share) []Complaint {
+	return p.session.receive(commitments, shares, nil)
+}
+
+// Justify returns the justifications to broadcast in answer to the complaints against this participant.
+func (p *Participant) Justify(complaints []Complaint) []Justification {
+	return p.session.justify(complaints)
+}
+
+// Finalize disqualifies the dealers that did not justify their shares and computes the key share and group key.
+//
+// Parameters:
+//   - complaints: The complaints broadcast by all participants.
+//   - justifications: The justifications broadcast by all participants.
+//
+// Returns:
+//   - KeyShare: The key share of this participant.
+//   - GroupKey: The group key, with the joint public key X2 = g2^x.
+//   - error: An error if fewer than threshold dealers are qualified.
+func (p *Participant) Finalize(complaints []Complaint, justifications []Justification) (KeyShare, GroupKey, error) {
+	// Step 1: Determine the qualified dealers
+	qualified := p.session.qualify(complaints, justifications)
+	if len(qualified) < p.session.threshold {
+		return KeyShare{}, GroupKey{}, fmt.Errorf("%w: %d qualified dealers, threshold %d", ErrNotEnoughShares, len(qualified), p.session.threshold)
+	}
+
+	// Step 2: Sum the secrets of the qualified dealers
+	weights := make([]*e.Scalar, len(qualified))
+	for i := range weights {
+		weights[i] = new(e.Scalar)
+		weights[i].SetOne()
+	}
+	x, verificationShares, x2 := p.session.output(qualified, weights)
+	if x2.IsIdentity() {
+		return KeyShare{}, GroupKey{}, fmt.Errorf("%w: joint public key is the identity", ErrInvalidShare)
+	}
+	return KeyShare{Index: p.session.index, X: x}, GroupKey{
+		Threshold:          p.session.threshold,
+		PublicKey:          models.PublicKey{X2: x2},
+		VerificationShares: verificationShares,
+	}, nil
+}
+
+// evaluateCommitment computes g2^{f(x)} from the commitments to the coefficients of f with Horner's rule.
+func evaluateCommitment(coefficients []*e.G2, x int) *e.G2 {
+	point := new(e.Scalar)
+	point.SetUint64(uint64(x))
+	result := new(e.G2)
+	result.SetIdentity()
+	for k := len(coefficients) - 1; k >= 0; k-- {
+		shifted := new(e.G2)
+		shifted.ScalarMult(point, result)
+		result.Add(shifted, coefficients[k])
+	}
+	return result
+}
+
+// hasNil reports whether any element is missing.
+func hasNil(elements []*e.G2) bool {
+	for _, element := range elements {
+		if element == nil {
+			return true
+		}
+	}
+	return false
+}
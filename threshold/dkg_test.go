@@ -0,0 +1,210 @@
+package threshold
+
+import (
+	"testing"
+
+	"github.com/aniagut/msc-bbs-anonymous-credentials/issue"
+	"github.com/aniagut/msc-bbs-anonymous-credentials/setup"
+	e "github.com/cloudflare/circl/ecc/bls12381"
+	"github.com/stretchr/testify/assert"
+)
+
+// RunDKG runs a distributed key generation among in-process participants, letting tamper modify the shares in transit
+func RunDKG(t *testing.T, threshold, n int, tamper func([]Share)) ([]KeyShare, []GroupKey, []error) {
+	participants := make([]*Participant, n)
+	var commitments []Commitment
+	var shares []Share
+	for i := range participants {
+		p, err := NewParticipant(i+1, threshold, n)
+		assert.NoError(t, err, "Expected no error creating the participant")
+		participants[i] = p
+		c, s := p.Deal()
+		commitments = append(commitments, c)
+		shares = append(shares, s...)
+	}
+	if tamper != nil {
+		tamper(shares)
+	}
+
+	var complaints []Complaint
+	for _, p := range participants {
+		complaints = append(complaints, p.Receive(commitments, shares)...)
+	}
+	var justifications []Justification
+	for _, p := range participants {
+		justifications = append(justifications, p.Justify(complaints)...)
+	}
+
+	keyShares := make([]KeyShare, n)
+	groups := make([]GroupKey, n)
+	errs := make([]error, n)
+	for i, p := range participants {
+		keyShares[i], groups[i], errs[i] = p.Finalize(complaints, justifications)
+	}
+	return keyShares, groups, errs
+}
+
+// Test for a distributed key generation among honest participants
+func TestDKG_Honest(t *testing.T) {
+	keyShares, groups, errs := RunDKG(t, 3, 5, nil)
+	for i := range errs {
+		assert.NoError(t, errs[i], "Expected no error finalizing participant %d", i+1)
+		assert.True(t, groups[i].PublicKey.X2.IsEqual(groups[0].PublicKey.X2), "Expected every participant to agree on the public key")
+		assert.True(t, commitG2(keyShares[i].X).IsEqual(groups[0].VerificationShares[i]), "Expected the verification share to match the key share")
+	}
+
+	// Any three shares interpolate the secret behind the joint public key
+	indices := []int{1, 3, 5}
+	x := new(e.Scalar)
+	for _, index := range indices {
+		lambda, err := lagrangeCoefficient(index, indices)
+		assert.NoError(t, err, "Expected no error computing the Lagrange coefficient")
+		term := new(e.Scalar)
+		term.Mul(lambda, keyShares[index-1].X)
+		x.Add(x, term)
+	}
+	assert.True(t, commitG2(x).IsEqual(groups[0].PublicKey.X2), "Expected the shares to interpolate the secret key")
+}
+
+// Test for complaints against a dealer that sends an invalid share
+func TestDKG_Complaints(t *testing.T) {
+	// Dealer 2 sends a wrong share to participant 4, and justifies with the correct one
+	keyShares, groups, errs := RunDKG(t, 2, 4, func(shares []Share) {
+		for i := range shares {
+			if shares[i].Dealer == 2 && shares[i].Recipient == 4 {
+				shares[i].Value = new(e.Scalar)
+			}
+		}
+	})
+	for i := range errs {
+		assert.NoError(t, errs[i], "Expected no error finalizing participant %d", i+1)
+		assert.True(t, commitG2(keyShares[i].X).IsEqual(groups[i].VerificationShares[i]), "Expected the justified share to be adopted")
+	}
+}
+
+// Test for the disqualification of a dealer that does not justify its shares
+func TestDKG_Disqualification(t *testing.T) {
+	n := 4
+	participants := make([]*Participant, n)
+	var commitments []Commitment
+	var shares []Share
+	for i := range participants {
+		participants[i], _ = NewParticipant(i+1, 2, n)
+		c, s := participants[i].Deal()
+		commitments = append(commitments, c)
+		// Dealer 3 withholds the share of participant 1
+		for _, share := range s {
+			if share.Dealer != 3 || share.Recipient != 1 {
+				shares = append(shares, share)
+			}
+		}
+	}
+
+	var complaints []Complaint
+	for _, p := range participants {
+		complaints = append(complaints, p.Receive(commitments, shares)...)
+	}
+	assert.Equal(t, []Complaint{{Accuser: 1, Dealer: 3}}, complaints, "Expected a complaint against dealer 3")
+
+	// Dealer 3 stays silent
+	var justifications []Justification
+	for i, p := range participants {
+		if i != 2 {
+			justifications = append(justifications, p.Justify(complaints)...)
+		}
+	}
+
+	var keys []KeyShare
+	var groups []GroupKey
+	for i, p := range participants {
+		key, group, err := p.Finalize(complaints, justifications)
+		assert.NoError(t, err, "Expected no error finalizing participant %d", i+1)
+		keys, groups = append(keys, key), append(groups, group)
+	}
+
+	// Everyone excludes dealer 3, so the shares stay consistent
+	expected := new(e.G2)
+	expected.SetIdentity()
+	for _, c := range commitments {
+		if c.Dealer != 3 {
+			expected.Add(expected, c.Coefficients[0])
+		}
+	}
+	for i := range keys {
+		assert.True(t, groups[i].PublicKey.X2.IsEqual(expected), "Expected the public key without dealer 3")
+		assert.True(t, commitG2(keys[i].X).IsEqual(groups[0].VerificationShares[i]), "Expected consistent key shares")
+	}
+}
+
+// Test for signing with a key and presignatures generated without a dealer
+func TestDKG_Sign(t *testing.T) {
+	threshold, n := 2, 3
+	keyShares, groups, errs := RunDKG(t, threshold, n, nil)
+	for _, err := range errs {
+		assert.NoError(t, err, "Expected no error generating the key")
+	}
+	randomShares, randomGroups, errs := RunDKG(t, threshold, n, nil)
+	for _, err := range errs {
+		assert.NoError(t, err, "Expected no error generating the random scalar")
+	}
+
+	// Reshare the products r_i·x_i, with signer 3 cheating on its product
+	multipliers := make([]*Multiplier, n)
+	var commitments []ProductCommitment
+	var shares []Share
+	for i := range multipliers {
+		m, err := NewMultiplier(keyShares[i], groups[i], randomShares[i], randomGroups[i])
+		assert.NoError(t, err, "Expected no error creating the multiplier")
+		multipliers[i] = m
+		c, s := m.Deal()
+		if i == 2 {
+			c.R1 = e.G1Generator()
+		}
+		commitments = append(commitments, c)
+		shares = append(shares, s...)
+	}
+	for _, m := range multipliers[:2] {
+		assert.Empty(t, m.Receive(commitments, shares), "Expected the cheating product to be rejected publicly")
+		_, _, err := m.Finalize(nil, nil)
+		assert.ErrorIs(t, err, ErrNotEnoughShares, "Expected 2t-1 honest signers to be required")
+	}
+
+	// Run again with honest signers
+	commitments, shares = nil, nil
+	for i := range multipliers {
+		multipliers[i], _ = NewMultiplier(keyShares[i], groups[i], randomShares[i], randomGroups[i])
+		c, s := multipliers[i].Deal()
+		commitments = append(commitments, c)
+		shares = append(shares, s...)
+	}
+	signers := make([]*Signer, n)
+	var commitment PresignatureCommitment
+	for i, m := range multipliers {
+		assert.Empty(t, m.Receive(commitments, shares), "Expected no complaints")
+		presignature, c, err := m.Finalize(nil, nil)
+		assert.NoError(t, err, "Expected no error finalizing the presignature")
+		if i > 0 {
+			assert.Equal(t, commitment.ID, c.ID, "Expected every signer to agree on the presignature")
+		}
+		commitment = c
+		signers[i] = NewSigner(keyShares[i])
+		assert.NoError(t, signers[i].AddPresignatures(presignature), "Expected no error storing the presignature")
+	}
+
+	// Sign with two of the three signers
+	publicParams, err := setup.DeriveParameters(groups[0].PublicKey, 2)
+	assert.NoError(t, err, "Expected no error deriving the parameters")
+	attributes := []string{"Alice", "30"}
+	var partials []PartialSignature
+	for _, signer := range signers[1:] {
+		partial, err := signer.Sign(commitment.ID, attributes, publicParams)
+		assert.NoError(t, err, "Expected no error producing the partial signature")
+		partials = append(partials, partial)
+	}
+	signature, misbehaving, err := Combine(groups[0], commitment, partials, attributes, publicParams)
+	assert.NoError(t, err, "Expected no error combining the partial signatures")
+	assert.Empty(t, misbehaving, "Expected no misbehaving signers")
+	valid, err := issue.VerifyCredential(attributes, signature, publicParams, groups[0].PublicKey)
+	assert.NoError(t, err, "Expected no error verifying the signature")
+	assert.True(t, valid, "Expected the signature to verify under the jointly generated public key")
+}
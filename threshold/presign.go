@@ -0,0 +1,151 @@
+package threshold
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+
+	e "github.com/cloudflare/circl/ecc/bls12381"
+)
+
+// Presignatures for a key without a dealer are generated in two rounds. First, the signers run a distributed key
+// generation for the random scalar r, which yields the shares r_i and the commitments g2^{r_i}. Then every signer
+// reshares the product z_i = r_i·x_i with Feldman VSS, broadcasting g1^{r_i} so that everyone can check that the
+// constant term g2^{z_i} of its commitment is the product: e(g1^{r_i}, g2) = e(g1, g2^{r_i}) and
+// e(g1^{r_i}, g2^{x_i}) = e(g1, g2^{z_i}). The z_i lie on a polynomial of degree 2t-2, so the shares of w = r·x are
+// the Lagrange combination of the reshares of 2t-1 qualified signers, which requires n ≥ 2t-1.
+
+// ProductCommitment is a signer's broadcast commitment to its resharing of z_i = r_i·x_i.
+// It contains the following elements:
+// - Commitment: The Feldman commitment, whose constant term is g2^{z_i}.
+// - R1: The element g1^{r_i}, linking the constant term to g2^{r_i} and g2^{x_i}.
+type ProductCommitment struct {
+	Commitment
+	R1 *e.G1
+}
+
+// Multiplier runs the second round of distributed presignature generation.
+//
+// The protocol has four steps, each taking the messages of the previous one from all signers:
+// Deal, Receive, Justify and Finalize.
+type Multiplier struct {
+	group       GroupKey
+	random      KeyShare
+	randomGroup GroupKey
+	session     *session
+}
+
+// NewMultiplier creates a signer's multiplier for the random scalar generated by a distributed key generation.
+//
+// Parameters:
+//   - key: The key share of the signer.
+//   - group: The group key.
+//   - random: The signer's share r_i of the random scalar r.
+//   - randomGroup: The output of the distributed key generation of r, with the commitments g2^{r_i}.
+//
+// Returns:
+//   - *Multiplier: The multiplier.
+//   - error: An error if the shares do not belong to the same signer and group, or n < 2t-1.
+func NewMultiplier(key KeyShare, group GroupKey, random KeyShare, randomGroup GroupKey) (*Multiplier, error) {
+	// Step 1: Check that both sharings belong to the same signer and group
+	t, n := group.Threshold, len(group.VerificationShares)
+	if randomGroup.Threshold != t || len(randomGroup.VerificationShares) != n {
+		return nil, fmt.Errorf("%w: the random scalar is shared in another group", ErrInvalidShare)
+	}
+	if n < 2*t-1 {
+		return nil, fmt.Errorf("%w: %d signers cannot multiply shares of threshold %d", ErrInvalidThreshold, n, t)
+	}
+	if key.Index != random.Index || key.X == nil || random.X == nil {
+		return nil, fmt.Errorf("%w: shares of different signers", ErrInvalidShare)
+	}
+
+	// Step 2: Reshare z_i = r_i·x_i
+	z := new(e.Scalar)
+	z.Mul(random.X, key.X)
+	s, err := newSession(key.Index, t, n, z)
+	if err != nil {
+		return nil, err
+	}
+	return &Multiplier{group: group, random: random, randomGroup: randomGroup, session: s}, nil
+}
+
+// Deal returns the product commitment to broadcast and the shares to send privately to the other signers.
+func (m *Multiplier) Deal() (ProductCommitment, []Share) {
+	commitment, shares := m.session.deal()
+	r1 := new(e.G1)
+	r1.ScalarMult(m.random.X, e.G1Generator())
+	return ProductCommitment{Commitment: commitment, R1: r1}, shares
+}
+
+// Receive verifies the broadcast product commitments and the shares received, and returns the complaints to
+// broadcast. Product commitments whose constant term is not r_i·x_i are publicly rejected.
+func (m *Multiplier) Receive(commitments []ProductCommitment, shares []Share) []Complaint {
+	r1 := make(map[int]*e.G1, len(commitments))
+	plain := make([]Commitment, 0, len(commitments))
+	for _, c := range commitments {
+		if _, seen := r1[c.Dealer]; !seen && c.R1 != nil {
+			r1[c.Dealer] = c.R1
+			plain = append(plain, c.Commitment)
+		}
+	}
+	return m.session.receive(plain, shares, func(c Commitment) error {
+		return m.checkProduct(c, r1[c.Dealer])
+	})
+}
+
+// Justify returns the justifications to broadcast in answer to the complaints against this signer.
+func (m *Multiplier) Justify(complaints []Complaint) []Justification {
+	return m.session.justify(complaints)
+}
+
+// Finalize disqualifies the signers that did not justify their shares and computes the presignature.
+//
+// Parameters:
+//   - complaints: The complaints broadcast by all signers.
+//   - justifications: The justifications broadcast by all signers.
+//
+// Returns:
+//   - PresignatureShare: The presignature share of this signer, to be added to its Signer.
+//   - PresignatureCommitment: The commitment to the presignature, identical for all signers.
+//   - error: An error if fewer than 2t-1 signers are qualified.
+func (m *Multiplier) Finalize(complaints []Complaint, justifications []Justification) (PresignatureShare, PresignatureCommitment, error) {
+	// Step 1: Interpolate from the first 2t-1 qualified signers
+	qualified := m.session.qualify(complaints, justifications)
+	needed := 2*m.session.threshold - 1
+	if len(qualified) < needed {
+		return PresignatureShare{}, PresignatureCommitment{}, fmt.Errorf("%w: %d qualified signers, %d needed", ErrNotEnoughShares, len(qualified), needed)
+	}
+	qualified = qualified[:needed]
+	weights := make([]*e.Scalar, needed)
+	for i, index := range qualified {
+		lambda, err := lagrangeCoefficient(index, qualified)
+		if err != nil {
+			return PresignatureShare{}, PresignatureCommitment{}, err
+		}
+		weights[i] = lambda
+	}
+
+	// Step 2: Compute the share of w = r·x and the commitments g2^{w_i}
+	w, w2, _ := m.session.output(qualified, weights)
+	digest := sha256.Sum256(m.randomGroup.PublicKey.X2.BytesCompressed())
+	id := hex.EncodeToString(digest[:16])
+	return PresignatureShare{ID: id, Index: m.session.index, R: m.random.X, W: w},
+		PresignatureCommitment{ID: id, R2: m.randomGroup.VerificationShares, W2: w2},
+		nil
+}
+
+// checkProduct checks that the constant term g2^{z_i} of a product commitment is the product of r_i and x_i.
+func (m *Multiplier) checkProduct(c Commitment, r1 *e.G1) error {
+	i := c.Dealer - 1
+	if r1 == nil || m.randomGroup.VerificationShares[i] == nil || m.group.VerificationShares[i] == nil {
+		return fmt.Errorf("%w: missing commitment of signer %d", ErrInvalidShare, c.Dealer)
+	}
+	g1, g2 := e.G1Generator(), e.G2Generator()
+	if !e.Pair(r1, g2).IsEqual(e.Pair(g1, m.randomGroup.VerificationShares[i])) {
+		return fmt.Errorf("%w: g1^r of signer %d does not match its share of r", ErrInvalidShare, c.Dealer)
+	}
+	if !e.Pair(r1, m.group.VerificationShares[i]).IsEqual(e.Pair(g1, c.Coefficients[0])) {
+		return fmt.Errorf("%w: product of signer %d does not match its shares", ErrInvalidShare, c.Dealer)
+	}
+	return nil
+}
@@ -0,0 +1,76 @@
+package threshold
+
+import (
+	"fmt"
+
+	"github.com/aniagut/msc-bbs-anonymous-credentials/utils"
+	e "github.com/cloudflare/circl/ecc/bls12381"
+)
+
+// randomPolynomial samples a polynomial of the given degree with the secret as its constant term.
+func randomPolynomial(secret *e.Scalar, degree int) ([]e.Scalar, error) {
+	coefficients := make([]e.Scalar, degree+1)
+	coefficients[0].Set(secret)
+	for i := 1; i <= degree; i++ {
+		c, err := utils.RandomScalar()
+		if err != nil {
+			return nil, err
+		}
+		coefficients[i] = c
+	}
+	return coefficients, nil
+}
+
+// evaluate evaluates the polynomial at x with Horner's rule.
+func evaluate(coefficients []e.Scalar, x int) *e.Scalar {
+	point := new(e.Scalar)
+	point.SetUint64(uint64(x))
+	result := new(e.Scalar)
+	for i := len(coefficients) - 1; i >= 0; i-- {
+		result.Mul(result, point)
+		result.Add(result, &coefficients[i])
+	}
+	return result
+}
+
+// share splits the secret into the n shares f(1), ..., f(n) of a random polynomial f of degree t-1 with f(0) = secret.
+func share(secret *e.Scalar, t, n int) ([]e.Scalar, error) {
+	coefficients, err := randomPolynomial(secret, t-1)
+	if err != nil {
+		return nil, err
+	}
+	shares := make([]e.Scalar, n)
+	for i := range shares {
+		shares[i] = *evaluate(coefficients, i+1)
+	}
+	return shares, nil
+}
+
+// lagrangeCoefficient computes the coefficient λ_i = ∏_{j ≠ i} j / (j - i) that interpolates f(0) from the shares
+// of the given distinct signer indices.
+func lagrangeCoefficient(index int, indices []int) (*e.Scalar, error) {
+	numerator := new(e.Scalar)
+	numerator.SetOne()
+	denominator := new(e.Scalar)
+	denominator.SetOne()
+
+	i := new(e.Scalar)
+	i.SetUint64(uint64(index))
+	for _, other := range indices {
+		if other == index {
+			continue
+		}
+		j := new(e.Scalar)
+		j.SetUint64(uint64(other))
+		difference := new(e.Scalar)
+		difference.Sub(j, i)
+		if difference.IsZero() == 1 {
+			return nil, fmt.Errorf("%w: duplicate signer index %d", ErrInvalidShare, other)
+		}
+		numerator.Mul(numerator, j)
+		denominator.Mul(denominator, difference)
+	}
+	denominator.Inv(denominator)
+	numerator.Mul(numerator, denominator)
+	return numerator, nil
+}
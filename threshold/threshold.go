@@ -0,0 +1,435 @@
+// Package threshold implements t-of-n threshold issuance of BBS++ signatures.
+//
+// The secret scalar x is Shamir-shared among n signers with a polynomial of degree t-1. Signing uses one-time
+// presignatures: random shares r_i of a scalar r and shares w_i of w = r·x, committed to as g2^{r_i} and g2^{w_i}.
+// For a credential with commitment C = g1 * ∏ h_1[j]^m[j], the exponent e is derived from the presignature ID and
+// C, and signer i returns the partial signature (R_i = C^{r_i}, u_i = w_i + e·r_i). Any t valid partials interpolate
+// to R = C^r and u = r·(x+e), and A = R^{1/u} = C^{1/(x+e)} is a standard signature under the unchanged public key.
+// Partials are checked against the commitments, so misbehaving signers are identified and skipped.
+//
+// A presignature must never be used twice: two partials for different attributes reveal r_i and then x_i.
+// Signers consume their presignature shares. Split and DealPresignatures assume a trusted dealer who knows x;
+// NewParticipant and NewMultiplier generate the key and the presignatures without one.
+//
+// Signing only uses the presignature shares, which carry w = r·x, so the key shares matter only through the way the
+// presignatures are generated. DealPresignatures needs x for every batch: a group fed by it keeps x in one place for as
+// long as it signs, which does not remove the single point of compromise. To remove it, delete x after Split (or
+// never create it with NewParticipant) and generate the presignatures from the key shares with NewMultiplier, whose
+// product checks tie every presignature to the verification shares of the group.
+package threshold
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/aniagut/msc-bbs-anonymous-credentials/issue"
+	"github.com/aniagut/msc-bbs-anonymous-credentials/models"
+	"github.com/aniagut/msc-bbs-anonymous-credentials/utils"
+	e "github.com/cloudflare/circl/ecc/bls12381"
+)
+
+// Errors returned by threshold issuance.
+var (
+	// ErrInvalidThreshold is returned when the threshold is not between 1 and the number of signers.
+	ErrInvalidThreshold = errors.New("invalid threshold")
+	// ErrInvalidShare is returned when a key, presignature or partial signature share is inconsistent with its commitment.
+	ErrInvalidShare = errors.New("invalid share")
+	// ErrNotEnoughShares is returned when fewer than threshold valid partial signatures are available.
+	ErrNotEnoughShares = errors.New("not enough valid partial signatures")
+	// ErrUnknownPresignature is returned when a signer has no unused presignature share with the requested ID.
+	ErrUnknownPresignature = errors.New("unknown or already used presignature")
+)
+
+// KeyShare is the share of the secret key held by a signer.
+// It contains the following elements:
+// - Index: The index i of the signer, from 1 to n.
+// - X: The share x_i = f(i) of the secret scalar x = f(0).
+type KeyShare struct {
+	Index int
+	X     *e.Scalar
+}
+
+// GroupKey is the public information of a signing group.
+// It contains the following elements:
+// - Threshold: The number t of signers needed to sign.
+// - PublicKey: The public key X2 = g2^x, unchanged from single-issuer BBS++.
+// - VerificationShares: The commitments g2^{x_i} to the key shares, signer i at position i-1.
+type GroupKey struct {
+	Threshold          int
+	PublicKey          models.PublicKey
+	VerificationShares []*e.G2
+}
+
+// PresignatureShare is the share of a one-time presignature held by a signer.
+// It contains the following elements:
+// - ID: The identifier of the presignature.
+// - Index: The index of the signer.
+// - R: The share r_i of the random scalar r.
+// - W: The share w_i of w = r·x.
+type PresignatureShare struct {
+	ID    string
+	Index int
+	R     *e.Scalar
+	W     *e.Scalar
+}
+
+// PresignatureCommitment is the public commitment to a presignature, used to check partial signatures.
+// It contains the following elements:
+// - ID: The identifier of the presignature.
+// - R2: The commitments g2^{r_i}, signer i at position i-1.
+// - W2: The commitments g2^{w_i}, signer i at position i-1.
+type PresignatureCommitment struct {
+	ID string
+	R2 []*e.G2
+	W2 []*e.G2
+}
+
+// PartialSignature is a signer's share of a signature.
+// It contains the following elements:
+// - ID: The identifier of the presignature used.
+// - Index: The index of the signer.
+// - R: The element R_i = C^{r_i}.
+// - U: The scalar u_i = w_i + e·r_i.
+type PartialSignature struct {
+	ID    string
+	Index int
+	R     *e.G1
+	U     *e.Scalar
+}
+
+// Split shares a secret key among n signers so that any t of them can sign.
+//
+// Parameters:
+//   - secretKey: The secret key of the issuer.
+//   - t: The threshold.
+//   - n: The number of signers.
+//
+// Returns:
+//   - GroupKey: The public information of the group.
+//   - []KeyShare: The key shares, signer i at position i-1.
+//   - error: An error if the threshold is invalid or sampling fails.
+func Split(secretKey models.SecretKey, t, n int) (GroupKey, []KeyShare, error) {
+	// Step 1: Validate the threshold and the key
+	if t < 1 || t > n {
+		return GroupKey{}, nil, fmt.Errorf("%w: %d of %d", ErrInvalidThreshold, t, n)
+	}
+	if secretKey.X == nil {
+		return GroupKey{}, nil, fmt.Errorf("%w: missing X", models.ErrInvalidEncoding)
+	}
+
+	// Step 2: Share x with a random polynomial of degree t-1
+	shares, err := share(secretKey.X, t, n)
+	if err != nil {
+		return GroupKey{}, nil, err
+	}
+
+	// Step 3: Commit to the shares and compute the public key X2 = g2^x
+	keyShares := make([]KeyShare, n)
+	verificationShares := make([]*e.G2, n)
+	for i := range shares {
+		keyShares[i] = KeyShare{Index: i + 1, X: &shares[i]}
+		verificationShares[i] = commitG2(&shares[i])
+	}
+	return GroupKey{
+		Threshold:          t,
+		PublicKey:          models.PublicKey{X2: commitG2(secretKey.X)},
+		VerificationShares: verificationShares,
+	}, keyShares, nil
+}
+
+// DealPresignatures generates one-time presignatures as a trusted dealer who knows the secret key.
+// The presignatures do not depend on the key shares, and the dealer must keep x for every new batch, so this mode
+// does not remove the single point of compromise of the secret key; NewMultiplier derives presignatures from the key
+// shares instead.
+//
+// Parameters:
+//   - secretKey: The secret key of the issuer.
+//   - group: The group the presignatures are dealt to.
+//   - count: The number of presignatures.
+//
+// Returns:
+//   - []PresignatureCommitment: The public commitments, one per presignature.
+//   - [][]PresignatureShare: The presignature shares of signer i at position i-1.
+//   - error: An error if the group is invalid or sampling fails.
+func DealPresignatures(secretKey models.SecretKey, group GroupKey, count int) ([]PresignatureCommitment, [][]PresignatureShare, error) {
+	n := len(group.VerificationShares)
+	if group.Threshold < 1 || group.Threshold > n {
+		return nil, nil, fmt.Errorf("%w: %d of %d", ErrInvalidThreshold, group.Threshold, n)
+	}
+	if secretKey.X == nil {
+		return nil, nil, fmt.Errorf("%w: missing X", models.ErrInvalidEncoding)
+	}
+
+	commitments := make([]PresignatureCommitment, count)
+	shares := make([][]PresignatureShare, n)
+	for k := 0; k < count; k++ {
+		// Step 1: Sample r and compute w = r·x
+		r, err := utils.RandomScalar()
+		if err != nil {
+			return nil, nil, err
+		}
+		w := new(e.Scalar)
+		w.Mul(&r, secretKey.X)
+
+		// Step 2: Share r and w with polynomials of degree t-1
+		rShares, err := share(&r, group.Threshold, n)
+		if err != nil {
+			return nil, nil, err
+		}
+		wShares, err := share(w, group.Threshold, n)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		// Step 3: Commit to the shares
+		id, err := newID()
+		if err != nil {
+			return nil, nil, err
+		}
+		commitment := PresignatureCommitment{ID: id, R2: make([]*e.G2, n), W2: make([]*e.G2, n)}
+		for i := 0; i < n; i++ {
+			commitment.R2[i] = commitG2(&rShares[i])
+			commitment.W2[i] = commitG2(&wShares[i])
+			shares[i] = append(shares[i], PresignatureShare{ID: id, Index: i + 1, R: &rShares[i], W: &wShares[i]})
+		}
+		commitments[k] = commitment
+	}
+	return commitments, shares, nil
+}
+
+// Signer is a member of the signing group holding a key share and unused presignature shares.
+// It is safe for concurrent use.
+type Signer struct {
+	share         KeyShare
+	mu            sync.Mutex
+	presignatures map[string]PresignatureShare
+}
+
+// NewSigner creates a signer for the key share.
+func NewSigner(share KeyShare) *Signer {
+	return &Signer{share: share, presignatures: make(map[string]PresignatureShare)}
+}
+
+// Index returns the index of the signer.
+func (s *Signer) Index() int {
+	return s.share.Index
+}
+
+// AddPresignatures stores presignature shares for later use.
+//
+// Parameters:
+//   - shares: The presignature shares of the signer.
+//
+// Returns:
+//   - error: An error if a share belongs to another signer or is incomplete.
+func (s *Signer) AddPresignatures(shares ...PresignatureShare) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, p := range shares {
+		if p.Index != s.share.Index || p.R == nil || p.W == nil {
+			return fmt.Errorf("%w: presignature %s is not a share of signer %d", ErrInvalidShare, p.ID, s.share.Index)
+		}
+		s.presignatures[p.ID] = p
+	}
+	return nil
+}
+
+// Sign produces a partial signature over the attributes, consuming the presignature share.
+//
+// Parameters:
+//   - id: The identifier of the presignature chosen by the combiner.
+//   - attributes: The attributes to be signed.
+//   - publicParams: The public parameters of the system.
+//
+// Returns:
+//   - PartialSignature: The partial signature.
+//   - error: An error if the presignature is unknown or already used, or the attributes do not match the generators.
+func (s *Signer) Sign(id string, attributes []string, publicParams models.PublicParameters) (PartialSignature, error) {
+	// Step 1: Compute the commitment C and the exponent e
+	C, exponent, err := signatureInputs(id, attributes, publicParams)
+	if err != nil {
+		return PartialSignature{}, err
+	}
+
+	// Step 2: Consume the presignature share, so that it is never used for other attributes
+	s.mu.Lock()
+	p, ok := s.presignatures[id]
+	delete(s.presignatures, id)
+	s.mu.Unlock()
+	if !ok {
+		return PartialSignature{}, fmt.Errorf("%w: %s", ErrUnknownPresignature, id)
+	}
+
+	// Step 3: Compute R_i = C^{r_i} and u_i = w_i + e·r_i
+	R := new(e.G1)
+	R.ScalarMult(p.R, C)
+	u := new(e.Scalar)
+	u.Mul(exponent, p.R)
+	u.Add(u, p.W)
+	return PartialSignature{ID: id, Index: s.share.Index, R: R, U: u}, nil
+}
+
+// VerifyPartial checks a partial signature against the presignature commitment:
+// g2^{u_i} = g2^{w_i} * (g2^{r_i})^e and e(R_i, g2) = e(C, g2^{r_i}).
+//
+// Parameters:
+//   - partial: The partial signature.
+//   - commitment: The commitment to the presignature.
+//   - attributes: The signed attributes.
+//   - publicParams: The public parameters of the system.
+//
+// Returns:
+//   - error: An error wrapping ErrInvalidShare if the partial signature is invalid.
+func VerifyPartial(partial PartialSignature, commitment PresignatureCommitment, attributes []string, publicParams models.PublicParameters) error {
+	C, exponent, err := signatureInputs(commitment.ID, attributes, publicParams)
+	if err != nil {
+		return err
+	}
+	return verifyPartial(partial, commitment, C, exponent)
+}
+
+// Combine assembles a signature from partial signatures, skipping those that fail verification.
+//
+// Parameters:
+//   - group: The public information of the group.
+//   - commitment: The commitment to the presignature used by the signers.
+//   - partials: The partial signatures.
+//   - attributes: The signed attributes.
+//   - publicParams: The public parameters of the system.
+//
+// Returns:
+//   - models.Signature: The signature, verifiable under group.PublicKey.
+//   - []int: The indices of the signers whose partial signatures were invalid.
+//   - error: An error if fewer than threshold partial signatures are valid.
+func Combine(group GroupKey, commitment PresignatureCommitment, partials []PartialSignature, attributes []string, publicParams models.PublicParameters) (models.Signature, []int, error) {
+	// Step 1: Compute the commitment C and the exponent e
+	C, exponent, err := signatureInputs(commitment.ID, attributes, publicParams)
+	if err != nil {
+		return models.Signature{}, nil, err
+	}
+
+	// Step 2: Keep the first valid partial signature of each signer, recording misbehaving signers
+	valid := make(map[int]PartialSignature)
+	var misbehaving []int
+	for _, partial := range partials {
+		if _, seen := valid[partial.Index]; seen {
+			continue
+		}
+		if err := verifyPartial(partial, commitment, C, exponent); err != nil {
+			utils.Logger().Debug("invalid partial signature", "signer", partial.Index, "error", err)
+			misbehaving = append(misbehaving, partial.Index)
+			continue
+		}
+		valid[partial.Index] = partial
+	}
+	if len(valid) < group.Threshold {
+		return models.Signature{}, misbehaving, fmt.Errorf("%w: %d valid, threshold %d", ErrNotEnoughShares, len(valid), group.Threshold)
+	}
+
+	// Step 3: Interpolate R = C^r and u = r·(x+e) from t partial signatures
+	indices := make([]int, 0, len(valid))
+	for index := range valid {
+		indices = append(indices, index)
+	}
+	sort.Ints(indices)
+	indices = indices[:group.Threshold]
+	R := new(e.G1)
+	R.SetIdentity()
+	u := new(e.Scalar)
+	for _, index := range indices {
+		lambda, err := lagrangeCoefficient(index, indices)
+		if err != nil {
+			return models.Signature{}, misbehaving, err
+		}
+		term := new(e.G1)
+		term.ScalarMult(lambda, valid[index].R)
+		R.Add(R, term)
+		weighted := new(e.Scalar)
+		weighted.Mul(lambda, valid[index].U)
+		u.Add(u, weighted)
+	}
+
+	// Step 4: Compute A = R^{1/u} = C^{1/(x+e)}
+	if u.IsZero() == 1 {
+		return models.Signature{}, misbehaving, fmt.Errorf("%w: degenerate presignature", ErrInvalidShare)
+	}
+	u.Inv(u)
+	A := new(e.G1)
+	A.ScalarMult(u, R)
+	signature := models.Signature{A: A, E: exponent}
+
+	// Step 5: Check the signature, which fails if the commitments do not match the group key
+	ok, err := issue.VerifyCredential(attributes, signature, publicParams, group.PublicKey)
+	if err != nil || !ok {
+		return models.Signature{}, misbehaving, fmt.Errorf("%w: combined signature does not verify", ErrInvalidShare)
+	}
+	return signature, misbehaving, nil
+}
+
+// verifyPartial checks a partial signature for the given commitment C and exponent e.
+func verifyPartial(partial PartialSignature, commitment PresignatureCommitment, C *e.G1, exponent *e.Scalar) error {
+	// Step 1: Check the structure of the partial signature
+	if partial.ID != commitment.ID {
+		return fmt.Errorf("%w: partial signature for presignature %s", ErrInvalidShare, partial.ID)
+	}
+	if partial.Index < 1 || partial.Index > len(commitment.R2) || len(commitment.R2) != len(commitment.W2) {
+		return fmt.Errorf("%w: signer index %d out of range", ErrInvalidShare, partial.Index)
+	}
+	if partial.R == nil || partial.U == nil {
+		return fmt.Errorf("%w: incomplete partial signature", ErrInvalidShare)
+	}
+	r2, w2 := commitment.R2[partial.Index-1], commitment.W2[partial.Index-1]
+	if r2 == nil || w2 == nil {
+		return fmt.Errorf("%w: missing commitment of signer %d", ErrInvalidShare, partial.Index)
+	}
+	g2 := e.G2Generator()
+
+	// Step 2: Check g2^{u_i} = g2^{w_i} * (g2^{r_i})^e
+	expected := new(e.G2)
+	expected.ScalarMult(exponent, r2)
+	expected.Add(expected, w2)
+	actual := new(e.G2)
+	actual.ScalarMult(partial.U, g2)
+	if !actual.IsEqual(expected) {
+		return fmt.Errorf("%w: u of signer %d does not match the commitment", ErrInvalidShare, partial.Index)
+	}
+
+	// Step 3: Check e(R_i, g2) = e(C, g2^{r_i})
+	if !e.Pair(partial.R, g2).IsEqual(e.Pair(C, r2)) {
+		return fmt.Errorf("%w: R of signer %d does not match the commitment", ErrInvalidShare, partial.Index)
+	}
+	return nil
+}
+
+// signatureInputs computes the commitment C = g1 * ∏ h_1[j]^m[j] and the exponent e = H(id, C).
+func signatureInputs(id string, attributes []string, publicParams models.PublicParameters) (*e.G1, *e.Scalar, error) {
+	C, err := utils.ComputeCommitment(attributes, publicParams.H1, publicParams.G1)
+	if err != nil {
+		return nil, nil, err
+	}
+	exponent, err := utils.HashToScalar([]byte("threshold-signature"), []byte(id), utils.SerializeG1(C))
+	if err != nil {
+		return nil, nil, err
+	}
+	return C, &exponent, nil
+}
+
+// commitG2 computes g2^s.
+func commitG2(s *e.Scalar) *e.G2 {
+	c := new(e.G2)
+	c.ScalarMult(s, e.G2Generator())
+	return c
+}
+
+// newID generates a random presignature identifier.
+func newID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("%w: %v", models.ErrRandomness, err)
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,215 @@
+package threshold
+
+import (
+	"testing"
+
+	"github.com/aniagut/msc-bbs-anonymous-credentials/issue"
+	"github.com/aniagut/msc-bbs-anonymous-credentials/models"
+	"github.com/aniagut/msc-bbs-anonymous-credentials/presentation"
+	"github.com/aniagut/msc-bbs-anonymous-credentials/setup"
+	"github.com/aniagut/msc-bbs-anonymous-credentials/verify"
+	e "github.com/cloudflare/circl/ecc/bls12381"
+	"github.com/stretchr/testify/assert"
+)
+
+// MockGroup sets up an issuer, splits its key among n signers and deals presignatures
+func MockGroup(t *testing.T, threshold, n, count int) (models.SetupResult, GroupKey, []*Signer, []PresignatureCommitment) {
+	setupResult, err := setup.Setup(3)
+	assert.NoError(t, err, "Expected no error during setup")
+	group, keyShares, err := Split(setupResult.SecretKey, threshold, n)
+	assert.NoError(t, err, "Expected no error splitting the key")
+	commitments, presignatures, err := DealPresignatures(setupResult.SecretKey, group, count)
+	assert.NoError(t, err, "Expected no error dealing the presignatures")
+
+	signers := make([]*Signer, n)
+	for i := range signers {
+		signers[i] = NewSigner(keyShares[i])
+		assert.NoError(t, signers[i].AddPresignatures(presignatures[i]...), "Expected no error storing the presignatures")
+	}
+	return setupResult, group, signers, commitments
+}
+
+// Test for splitting a key
+func TestSplit(t *testing.T) {
+	setupResult, err := setup.Setup(1)
+	assert.NoError(t, err, "Expected no error during setup")
+	group, shares, err := Split(setupResult.SecretKey, 3, 5)
+	assert.NoError(t, err, "Expected no error splitting the key")
+	assert.True(t, group.PublicKey.X2.IsEqual(setupResult.PublicKey.X2), "Expected the public key to be unchanged")
+
+	// Any three shares interpolate x
+	for _, indices := range [][]int{{1, 2, 3}, {2, 4, 5}, {1, 3, 5}} {
+		x := new(e.Scalar)
+		for _, index := range indices {
+			lambda, err := lagrangeCoefficient(index, indices)
+			assert.NoError(t, err, "Expected no error computing the Lagrange coefficient")
+			term := new(e.Scalar)
+			term.Mul(lambda, shares[index-1].X)
+			x.Add(x, term)
+		}
+		assert.Equal(t, 1, x.IsEqual(setupResult.SecretKey.X), "Expected the shares %v to interpolate x", indices)
+	}
+
+	_, _, err = Split(setupResult.SecretKey, 0, 3)
+	assert.ErrorIs(t, err, ErrInvalidThreshold, "Expected an error for a zero threshold")
+	_, _, err = Split(setupResult.SecretKey, 4, 3)
+	assert.ErrorIs(t, err, ErrInvalidThreshold, "Expected an error for a threshold above the number of signers")
+}
+
+// Test for threshold issuance with different subsets of signers
+func TestCombine_Success(t *testing.T) {
+	setupResult, group, signers, commitments := MockGroup(t, 3, 5, 2)
+	attributes := []string{"Alice", "30", "PL"}
+
+	for k, subset := range [][]int{{0, 1, 2}, {1, 3, 4}} {
+		partials := make([]PartialSignature, 0, len(subset))
+		for _, i := range subset {
+			partial, err := signers[i].Sign(commitments[k].ID, attributes, setupResult.PublicParameters)
+			assert.NoError(t, err, "Expected no error producing the partial signature")
+			assert.NoError(t, VerifyPartial(partial, commitments[k], attributes, setupResult.PublicParameters), "Expected the partial signature to verify")
+			partials = append(partials, partial)
+		}
+
+		signature, misbehaving, err := Combine(group, commitments[k], partials, attributes, setupResult.PublicParameters)
+		assert.NoError(t, err, "Expected no error combining the partial signatures")
+		assert.Empty(t, misbehaving, "Expected no misbehaving signers")
+		valid, err := issue.VerifyCredential(attributes, signature, setupResult.PublicParameters, setupResult.PublicKey)
+		assert.NoError(t, err, "Expected no error verifying the signature")
+		assert.True(t, valid, "Expected the combined signature to verify under the unchanged public key")
+
+		// The signature is a standard credential
+		nonce := []byte("nonce")
+		proof, err := presentation.Presentation(attributes, signature, []int{0}, setupResult.PublicParameters, nonce)
+		assert.NoError(t, err, "Expected no error presenting the credential")
+		valid, err = verify.Verify(proof, nonce, []string{"Alice"}, []int{0}, setupResult.PublicParameters, setupResult.PublicKey)
+		assert.NoError(t, err, "Expected the presentation to verify")
+		assert.True(t, valid, "Expected the presentation to verify")
+	}
+}
+
+// Test for detecting misbehaving signers
+func TestCombine_Misbehaving(t *testing.T) {
+	setupResult, group, signers, commitments := MockGroup(t, 2, 4, 1)
+	attributes := []string{"Alice", "30", "PL"}
+	id := commitments[0].ID
+
+	partials := make([]PartialSignature, len(signers))
+	for i, signer := range signers {
+		partial, err := signer.Sign(id, attributes, setupResult.PublicParameters)
+		assert.NoError(t, err, "Expected no error producing the partial signature")
+		partials[i] = partial
+	}
+
+	// Signer 1 sends a wrong scalar and signer 2 a wrong group element
+	one := new(e.Scalar)
+	one.SetOne()
+	partials[0].U.Add(partials[0].U, one)
+	partials[1].R = e.G1Generator()
+	assert.ErrorIs(t, VerifyPartial(partials[0], commitments[0], attributes, setupResult.PublicParameters), ErrInvalidShare, "Expected the wrong scalar to be detected")
+	assert.ErrorIs(t, VerifyPartial(partials[1], commitments[0], attributes, setupResult.PublicParameters), ErrInvalidShare, "Expected the wrong group element to be detected")
+
+	signature, misbehaving, err := Combine(group, commitments[0], partials, attributes, setupResult.PublicParameters)
+	assert.NoError(t, err, "Expected the honest signers to reach the threshold")
+	assert.Equal(t, []int{1, 2}, misbehaving, "Expected the misbehaving signers to be identified")
+	valid, _ := issue.VerifyCredential(attributes, signature, setupResult.PublicParameters, setupResult.PublicKey)
+	assert.True(t, valid, "Expected the combined signature to verify")
+
+	_, misbehaving, err = Combine(group, commitments[0], partials[:3], attributes, setupResult.PublicParameters)
+	assert.ErrorIs(t, err, ErrNotEnoughShares, "Expected an error below the threshold of valid partial signatures")
+	assert.Equal(t, []int{1, 2}, misbehaving, "Expected the misbehaving signers to be reported")
+
+	_, _, err = Combine(group, commitments[0], partials[2:], []string{"Mallory", "30", "PL"}, setupResult.PublicParameters)
+	assert.ErrorIs(t, err, ErrNotEnoughShares, "Expected partial signatures over other attributes to be rejected")
+}
+
+// Test for the single use of presignatures
+func TestSigner_PresignatureReuse(t *testing.T) {
+	setupResult, _, signers, commitments := MockGroup(t, 2, 3, 1)
+	id := commitments[0].ID
+
+	_, err := signers[0].Sign(id, []string{"a", "b", "c"}, setupResult.PublicParameters)
+	assert.NoError(t, err, "Expected no error producing the partial signature")
+	_, err = signers[0].Sign(id, []string{"x", "y", "z"}, setupResult.PublicParameters)
+	assert.ErrorIs(t, err, ErrUnknownPresignature, "Expected a used presignature to be refused")
+
+	_, err = signers[1].Sign("unknown", []string{"a", "b", "c"}, setupResult.PublicParameters)
+	assert.ErrorIs(t, err, ErrUnknownPresignature, "Expected an unknown presignature to be refused")
+
+	err = signers[1].AddPresignatures(PresignatureShare{ID: "other", Index: 3, R: new(e.Scalar), W: new(e.Scalar)})
+	assert.ErrorIs(t, err, ErrInvalidShare, "Expected a presignature of another signer to be refused")
+}
+
+// Test for presignatures generated from the key shares of a split key, without the secret key
+func TestSplit_PresignaturesFromShares(t *testing.T) {
+	threshold, n := 2, 3
+	setupResult, err := setup.Setup(2)
+	assert.NoError(t, err, "Expected no error during setup")
+	group, keyShares, err := Split(setupResult.SecretKey, threshold, n)
+	assert.NoError(t, err, "Expected no error splitting the key")
+	randomShares, randomGroups, errs := RunDKG(t, threshold, n, nil)
+	for _, err := range errs {
+		assert.NoError(t, err, "Expected no error generating the random scalar")
+	}
+	multiply := func(keyShares []KeyShare) ([]PresignatureShare, PresignatureCommitment, error) {
+		var commitments []ProductCommitment
+		var shares []Share
+		multipliers := make([]*Multiplier, n)
+		for i := range multipliers {
+			multipliers[i], err = NewMultiplier(keyShares[i], group, randomShares[i], randomGroups[i])
+			assert.NoError(t, err, "Expected no error creating the multiplier")
+			c, s := multipliers[i].Deal()
+			commitments = append(commitments, c)
+			shares = append(shares, s...)
+		}
+		presignatures := make([]PresignatureShare, n)
+		var commitment PresignatureCommitment
+		for i, m := range multipliers {
+			m.Receive(commitments, shares)
+			if presignatures[i], commitment, err = m.Finalize(nil, nil); err != nil {
+				return nil, PresignatureCommitment{}, err
+			}
+		}
+		return presignatures, commitment, nil
+	}
+
+	// A key share that does not match its verification share is rejected
+	tampered := append([]KeyShare(nil), keyShares...)
+	one := new(e.Scalar)
+	one.SetOne()
+	tampered[2].X = new(e.Scalar)
+	tampered[2].X.Add(keyShares[2].X, one)
+	_, _, err = multiply(tampered)
+	assert.ErrorIs(t, err, ErrNotEnoughShares, "Expected a product over a wrong key share to be rejected")
+
+	// The shares w_i interpolate r·x, so the partial signatures depend on the key shares
+	presignatures, commitment, err := multiply(keyShares)
+	assert.NoError(t, err, "Expected no error generating the presignature from the key shares")
+	indices := []int{1, 3}
+	r, w := new(e.Scalar), new(e.Scalar)
+	for _, index := range indices {
+		lambda, err := lagrangeCoefficient(index, indices)
+		assert.NoError(t, err, "Expected no error computing the Lagrange coefficient")
+		term := new(e.Scalar)
+		term.Mul(lambda, presignatures[index-1].R)
+		r.Add(r, term)
+		term.Mul(lambda, presignatures[index-1].W)
+		w.Add(w, term)
+	}
+	r.Mul(r, setupResult.SecretKey.X)
+	assert.Equal(t, 1, w.IsEqual(r), "Expected the presignature to share r·x")
+
+	// The presignature signs under the public key of the split key
+	attributes := []string{"Alice", "30"}
+	var partials []PartialSignature
+	for _, index := range indices {
+		signer := NewSigner(keyShares[index-1])
+		assert.NoError(t, signer.AddPresignatures(presignatures[index-1]), "Expected no error storing the presignature")
+		partial, err := signer.Sign(commitment.ID, attributes, setupResult.PublicParameters)
+		assert.NoError(t, err, "Expected no error producing the partial signature")
+		partials = append(partials, partial)
+	}
+	signature, _, err := Combine(group, commitment, partials, attributes, setupResult.PublicParameters)
+	assert.NoError(t, err, "Expected no error combining the partial signatures")
+	valid, _ := issue.VerifyCredential(attributes, signature, setupResult.PublicParameters, setupResult.PublicKey)
+	assert.True(t, valid, "Expected the signature to verify under the public key of the split key")
+}
@@ -0,0 +1,36 @@
+package utils
+
+import (
+	"context"
+	"log/slog"
+	"sync/atomic"
+)
+
+// logger holds the logger used by the setup, issue, presentation and verify packages.
+var logger atomic.Pointer[slog.Logger]
+
+func init() {
+	SetLogger(nil)
+}
+
+// SetLogger sets the logger used to report the steps of the protocols.
+// Passing nil disables logging, which is the default.
+func SetLogger(l *slog.Logger) {
+	if l == nil {
+		l = slog.New(discardHandler{})
+	}
+	logger.Store(l)
+}
+
+// Logger returns the logger used to report the steps of the protocols.
+func Logger() *slog.Logger {
+	return logger.Load()
+}
+
+// discardHandler is a slog.Handler that drops every record.
+type discardHandler struct{}
+
+func (discardHandler) Enabled(context.Context, slog.Level) bool  { return false }
+func (discardHandler) Handle(context.Context, slog.Record) error { return nil }
+func (d discardHandler) WithAttrs([]slog.Attr) slog.Handler      { return d }
+func (d discardHandler) WithGroup(string) slog.Handler           { return d }
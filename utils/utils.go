@@ -2,10 +2,11 @@ package utils
 
 import (
 	"crypto/rand"
-	"errors"
+	"encoding/binary"
 	"math/big"
     "fmt"
     "crypto/sha256"
+	"github.com/aniagut/msc-bbs-anonymous-credentials/models"
 	e "github.com/cloudflare/circl/ecc/bls12381"
 )
 
@@ -15,7 +16,7 @@ func RandomG1Element() (e.G1, error) {
     randomBytes := make([]byte, 48)
     _, err := rand.Read(randomBytes)
     if err != nil {
-        return e.G1{}, errors.New("failed to generate random input for hashing to G1")
+        return e.G1{}, fmt.Errorf("%w: random input for hashing to G1: %v", models.ErrRandomness, err)
     }
 
     // Hash the random bytes to the curve using a domain separation tag
@@ -36,24 +37,73 @@ func GenerateLRandomG1Elements(l int) ([]e.G1, error) {
 	return elements, nil
 }
 
+// randomScalarBytes is the number of random bytes reduced into a scalar, so that the bias of the reduction is negligible (2^-256).
+const randomScalarBytes = 64
+
+// limbSize is the size of the limbs the random bytes are reduced in. Limbs are smaller than the order,
+// so they are loaded without a reduction.
+const limbSize = 16
+
+// limbShift is the scalar 2^128 shifting the reduced limbs.
+var limbShift = func() e.Scalar {
+    var shift e.Scalar
+    b := make([]byte, e.ScalarSize)
+    b[e.ScalarSize-limbSize-1] = 1
+    if err := shift.UnmarshalBinary(b); err != nil {
+        panic(err)
+    }
+    return shift
+}()
+
 // RandomScalar generates a random scalar in Z_p* (the field of scalars modulo the curve order).
+// The scalar is reduced from 64 random bytes with constant-time field arithmetic rather than math/big,
+// so it can be used for secret material.
 func RandomScalar() (e.Scalar, error) {
-    order := OrderAsBigInt()
-    bigIntScalar, err := rand.Int(rand.Reader, order)
-    if err != nil {
-        return e.Scalar{}, errors.New("failed to generate random scalar")
+    // Step 1: Read the random bytes
+    randomBytes := make([]byte, randomScalarBytes)
+    defer clear(randomBytes)
+    if _, err := rand.Read(randomBytes); err != nil {
+        return e.Scalar{}, fmt.Errorf("%w: random scalar: %v", models.ErrRandomness, err)
     }
 
-    if bigIntScalar.Sign() == 0 { // Ensure it's nonzero
-        return RandomScalar()
+    // Step 2: Reduce the bytes limb by limb, computing s ← s * 2^128 + limb
+    var scalar, limb e.Scalar
+    limbBytes := make([]byte, e.ScalarSize)
+    defer clear(limbBytes)
+    for i := 0; i < randomScalarBytes; i += limbSize {
+        copy(limbBytes[e.ScalarSize-limbSize:], randomBytes[i:i+limbSize])
+        if err := limb.UnmarshalBinary(limbBytes); err != nil {
+            return e.Scalar{}, fmt.Errorf("%w: random scalar: %v", models.ErrRandomness, err)
+        }
+        scalar.Mul(&scalar, &limbShift)
+        scalar.Add(&scalar, &limb)
     }
+    ZeroizeScalars(&limb)
 
-    // Convert to a scalar
-    var scalar e.Scalar
-    scalar.SetBytes(bigIntScalar.Bytes())
+    // Step 3: Ensure it's nonzero
+    if scalar.IsZero() == 1 {
+        return RandomScalar()
+    }
     return scalar, nil
 }
 
+// ZeroizeScalars overwrites the scalars with zero. Copies made by the runtime or by value passing are not reached,
+// so the zeroization is best effort.
+func ZeroizeScalars(scalars ...*e.Scalar) {
+    for _, s := range scalars {
+        if s != nil {
+            s.SetUint64(0)
+        }
+    }
+}
+
+// ZeroizeScalarSlice overwrites every scalar of the slice with zero.
+func ZeroizeScalarSlice(scalars []e.Scalar) {
+    for i := range scalars {
+        scalars[i].SetUint64(0)
+    }
+}
+
 // OrderAsBigInt returns the order of the elliptic curve as a big.Int.
 func OrderAsBigInt() *big.Int {
     return new(big.Int).SetBytes(e.Order())
@@ -68,7 +118,7 @@ func SerializeString(s string) []byte {
 func ComputeCommitment(M []string, h1 []e.G1, g1 *e.G1) (*e.G1, error) {
 	// Ensure the message vector length matches the length of h1
     if len(M) != len(h1) {
-        return nil, errors.New("message vector length does not match h1 length")
+        return nil, fmt.Errorf("%w: message vector has %d elements, h1 has %d", models.ErrLengthMismatch, len(M), len(h1))
     }
 
 	// Initialize the commitment C with g1
@@ -96,7 +146,7 @@ func ComputeCommitment(M []string, h1 []e.G1, g1 *e.G1) (*e.G1, error) {
 func ComputeH1Exp(h1 []e.G1, v []e.Scalar) (*e.G1, error) {
     // Ensure the attributes vector length matches the length of h1
     if len(v) != len(h1) {
-        return nil, errors.New("attributes vector length does not match h1 length")
+        return nil, fmt.Errorf("%w: attributes vector has %d elements, h1 has %d", models.ErrLengthMismatch, len(v), len(h1))
     }
 
     // Initialize the result
@@ -114,6 +164,27 @@ func ComputeH1Exp(h1 []e.G1, v []e.Scalar) (*e.G1, error) {
     return result, nil
 }
 
+// LinearCombination computes ∏_i points[i]^scalars[i].
+// The points and scalars are expected to have the same length.
+func LinearCombination(points []*e.G1, scalars []*e.Scalar) *e.G1 {
+    result := new(e.G1)
+    result.SetIdentity()
+    term := new(e.G1)
+    for i := range points {
+        term.ScalarMult(scalars[i], points[i])
+        result.Add(result, term)
+    }
+    return result
+}
+
+// Negate returns -s, leaving s unchanged.
+func Negate(s *e.Scalar) *e.Scalar {
+    neg := new(e.Scalar)
+    neg.Set(s)
+    neg.Neg()
+    return neg
+}
+
 
 // HashToScalar hashes a series of byte slices into a scalar in Z_p*.
 func HashToScalar(inputs ...[]byte) (e.Scalar, error) {
@@ -123,7 +194,7 @@ func HashToScalar(inputs ...[]byte) (e.Scalar, error) {
     for _, input := range inputs {
         _, err := hash.Write(input)
         if err != nil {
-            return e.Scalar{}, errors.New("failed to hash input")
+            return e.Scalar{}, fmt.Errorf("%w: %v", models.ErrHashing, err)
         }
     }
     digest := hash.Sum(nil)
@@ -159,23 +230,64 @@ func ComputeChallenge(nonce []byte, U *e.G1, aPrim *e.G1, bPrim *e.G1, aI []stri
     
     hash, err := HashToScalar(nonce, SerializeG1(U), SerializeG1(aPrim), SerializeG1(bPrim), attributesSerialized)
     if err != nil {
-        return e.Scalar{}, errors.New("failed to compute challenge")
+        return e.Scalar{}, fmt.Errorf("failed to compute challenge: %w", err)
+    }
+    return hash, nil
+}
+
+// ComputeExtendedChallenge computes the challenge scalar for a zero-knowledge proof extended with additional
+// statements. The commitments of each extension are appended to the inputs of ComputeChallenge with a length prefix,
+// and without extensions the challenge equals the one of ComputeChallenge.
+func ComputeExtendedChallenge(nonce []byte, U *e.G1, aPrim *e.G1, bPrim *e.G1, aI []string, extensions [][]byte) (e.Scalar, error) {
+    if len(extensions) == 0 {
+        return ComputeChallenge(nonce, U, aPrim, bPrim, aI)
+    }
+    inputs := [][]byte{nonce, SerializeG1(U), SerializeG1(aPrim), SerializeG1(bPrim), SerializeListStrings(aI), []byte("presentation-extensions")}
+    for _, extension := range extensions {
+        inputs = append(inputs, binary.BigEndian.AppendUint64(nil, uint64(len(extension))), extension)
+    }
+    hash, err := HashToScalar(inputs...)
+    if err != nil {
+        return e.Scalar{}, fmt.Errorf("failed to compute challenge: %w", err)
     }
     return hash, nil
 }
 
+// HiddenIndices returns the sorted indices of the attributes that are not revealed.
+func HiddenIndices(l int, revealed []int) []int {
+    revealedMap := make(map[int]bool, len(revealed))
+    for _, index := range revealed {
+        revealedMap[index] = true
+    }
+    hidden := make([]int, 0, l)
+    for i := 0; i < l; i++ {
+        if !revealedMap[i] {
+            hidden = append(hidden, i)
+        }
+    }
+    return hidden
+}
+
+// HiddenPosition returns the position of an attribute index among the sorted hidden indices, which is also the
+// position of its response in the Zi of a proof.
+func HiddenPosition(hidden []int, index int) (int, error) {
+    for position, hiddenIndex := range hidden {
+        if hiddenIndex == index {
+            return position, nil
+        }
+    }
+    return 0, fmt.Errorf("%w: attribute %d is not hidden", models.ErrIndexOutOfRange, index)
+}
+
 // ComputeRevealedAndHiddenH computes the h values for the given revealed and hidden attributes.
 func ComputeRevealedAndHiddenH(h1 []e.G1, revealed []int) ([]e.G1, []e.G1, error) {
-	if len(revealed) == 0 {
-		return nil, nil, fmt.Errorf("no revealed attributes provided")
-	}
 	if len(revealed) > len(h1) {
-		return nil, nil, fmt.Errorf("revealed attributes exceed total attributes")
+		return nil, nil, models.ErrTooManyRevealed
 	}
 	// Check if revealed indexes are valid
 	for _, index := range revealed {
 		if index < 0 || index >= len(h1) {
-			return nil, nil, fmt.Errorf("revealed index %d out of bounds", index)
+			return nil, nil, fmt.Errorf("%w: index %d, %d attributes", models.ErrIndexOutOfRange, index, len(h1))
 		}
 	}
 	
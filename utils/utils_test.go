@@ -1,8 +1,11 @@
 package utils
 
 import (
+    "bytes"
+    "log/slog"
     "testing"
 
+    "github.com/aniagut/msc-bbs-anonymous-credentials/models"
     "github.com/stretchr/testify/assert"
     e "github.com/cloudflare/circl/ecc/bls12381"
 )
@@ -32,6 +35,39 @@ func TestRandomScalar(t *testing.T) {
     assert.NotNil(t, scalar, "Generated scalar should not be nil")
 }
 
+// Test for the reduction of RandomScalar, which must produce distinct non-zero scalars spanning the whole field
+func TestRandomScalar_Reduction(t *testing.T) {
+    var shift e.Scalar
+    shift.SetBytes([]byte{1, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0})
+    assert.Equal(t, 1, limbShift.IsEqual(&shift), "Expected the limb shift to be 2^128")
+
+    seen := make(map[string]bool)
+    highByteSet := false
+    for i := 0; i < 32; i++ {
+        scalar, err := RandomScalar()
+        assert.NoError(t, err, "Expected no error during random scalar generation")
+        assert.Equal(t, 0, scalar.IsZero(), "Expected a non-zero scalar")
+        b, _ := scalar.MarshalBinary()
+        seen[string(b)] = true
+        highByteSet = highByteSet || b[0] != 0
+    }
+    assert.Len(t, seen, 32, "Expected distinct scalars")
+    assert.True(t, highByteSet, "Expected scalars larger than a single limb")
+}
+
+// Test for ZeroizeScalars and ZeroizeScalarSlice
+func TestZeroizeScalars(t *testing.T) {
+    a, _ := RandomScalar()
+    slice := []e.Scalar{a, a}
+    ZeroizeScalars(&a, nil)
+    ZeroizeScalarSlice(slice)
+
+    assert.Equal(t, 1, a.IsZero(), "Expected the scalar to be zeroized")
+    for i := range slice {
+        assert.Equal(t, 1, slice[i].IsZero(), "Expected the slice to be zeroized")
+    }
+}
+
 // Test for SerializeString
 func TestSerializeString(t *testing.T) {
     str := "test"
@@ -102,6 +138,38 @@ func TestComputeChallenge(t *testing.T) {
     assert.NotNil(t, challenge, "Challenge scalar should not be nil")
 }
 
+// Test for ComputeExtendedChallenge
+func TestComputeExtendedChallenge(t *testing.T) {
+    nonce := []byte("random_nonce")
+    G := e.G1Generator()
+    attributes := []string{"attribute1", "attribute2"}
+
+    plain, err := ComputeChallenge(nonce, G, G, G, attributes)
+    assert.NoError(t, err, "Expected no error during challenge computation")
+    unextended, err := ComputeExtendedChallenge(nonce, G, G, G, attributes, nil)
+    assert.NoError(t, err, "Expected no error during challenge computation")
+    extended, err := ComputeExtendedChallenge(nonce, G, G, G, attributes, [][]byte{[]byte("extension")})
+    assert.NoError(t, err, "Expected no error during challenge computation")
+    split, err := ComputeExtendedChallenge(nonce, G, G, G, attributes, [][]byte{[]byte("exten"), []byte("sion")})
+    assert.NoError(t, err, "Expected no error during challenge computation")
+
+    assert.Equal(t, 1, plain.IsEqual(&unextended), "Expected the plain challenge without extensions")
+    assert.Equal(t, 0, plain.IsEqual(&extended), "Expected extensions to change the challenge")
+    assert.Equal(t, 0, extended.IsEqual(&split), "Expected the extensions to be length-prefixed")
+}
+
+// Test for HiddenIndices and HiddenPosition
+func TestHiddenIndices(t *testing.T) {
+    hidden := HiddenIndices(5, []int{1, 3})
+    assert.Equal(t, []int{0, 2, 4}, hidden, "Expected the indices that are not revealed")
+
+    position, err := HiddenPosition(hidden, 4)
+    assert.NoError(t, err, "Expected a hidden attribute to have a position")
+    assert.Equal(t, 2, position, "Expected the position among the hidden attributes")
+    _, err = HiddenPosition(hidden, 3)
+    assert.ErrorIs(t, err, models.ErrIndexOutOfRange, "Expected an error for a revealed attribute")
+}
+
 // Test for ComputeRevealedAndHiddenH
 func TestComputeRevealedAndHiddenH(t *testing.T) {
     h1 := []e.G1{*e.G1Generator(), *e.G1Generator(), *e.G1Generator()}
@@ -112,4 +180,48 @@ func TestComputeRevealedAndHiddenH(t *testing.T) {
     assert.NoError(t, err, "Expected no error during revealed and hidden H computation")
     assert.Equal(t, 2, len(revealedH), "Expected correct number of revealed H elements")
     assert.Equal(t, 1, len(hiddenH), "Expected correct number of hidden H elements")
-}
\ No newline at end of file
+}
+
+// Test for SetLogger
+func TestSetLogger(t *testing.T) {
+    var buf bytes.Buffer
+    SetLogger(slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug})))
+    Logger().Debug("step completed")
+    assert.Contains(t, buf.String(), "step completed", "Expected the injected logger to receive records")
+
+    buf.Reset()
+    SetLogger(nil)
+    Logger().Debug("step completed")
+    assert.Empty(t, buf.String(), "Expected logging to be disabled after resetting the logger")
+}
+
+// Test for ComputeRevealedAndHiddenH with no revealed attributes
+func TestComputeRevealedAndHiddenH_NoneRevealed(t *testing.T) {
+    h1 := []e.G1{*e.G1Generator(), *e.G1Generator()}
+
+    revealedH, hiddenH, err := ComputeRevealedAndHiddenH(h1, []int{})
+
+    assert.NoError(t, err, "Expected no error when no attribute is revealed")
+    assert.Empty(t, revealedH, "Expected no revealed H elements")
+    assert.Equal(t, 2, len(hiddenH), "Expected every H element to be hidden")
+}
+
+// Test for LinearCombination and Negate
+func TestLinearCombination(t *testing.T) {
+    g := e.G1Generator()
+    two, three := new(e.Scalar), new(e.Scalar)
+    two.SetUint64(2)
+    three.SetUint64(3)
+
+    // g^2 * g^3 * g^(-3) = g^2
+    result := LinearCombination([]*e.G1{g, g, g}, []*e.Scalar{two, three, Negate(three)})
+    expected := new(e.G1)
+    expected.ScalarMult(two, g)
+    assert.True(t, result.IsEqual(expected), "Expected the linear combination to match")
+    unchanged := new(e.Scalar)
+    unchanged.SetUint64(3)
+    assert.Equal(t, 1, three.IsEqual(unchanged), "Expected Negate to leave its argument unchanged")
+
+    empty := LinearCombination(nil, nil)
+    assert.True(t, empty.IsIdentity(), "Expected the empty combination to be the identity")
+}
@@ -0,0 +1,505 @@
+// Package vc secures W3C Verifiable Credentials with Data Integrity proofs of the bbs-2023 cryptosuite,
+// using a JSON-only profile: documents are canonicalized with JCS (RFC 8785) instead of JSON-LD RDF canonicalization.
+//
+// A document is split into statements, the attributes of package jsonattr: one per JSON pointer to a scalar,
+// array or empty object, sorted by their canonical form. Attribute 0 of the credential is the bbs-2023 header, SHA-256(proof configuration) ||
+// SHA-256(mandatory statements), and is always disclosed; statement i is attribute i+1, and unused attributes are
+// padded with empty strings so that the number of statements stays hidden. Documents need fewer statements than
+// the issuer's public parameters have generators.
+//
+// Proof values follow the bbs-2023 layout: the multibase base64url encoding of the 0xd95d02 (base) or 0xd95d03
+// (derived) header followed by a CBOR array of proof components. As there are no blank nodes in JSON documents,
+// the HMAC key of base proofs is empty and the label map of derived proofs is an empty map.
+package vc
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aniagut/msc-bbs-anonymous-credentials/cbor"
+	"github.com/aniagut/msc-bbs-anonymous-credentials/issue"
+	"github.com/aniagut/msc-bbs-anonymous-credentials/jcs"
+	"github.com/aniagut/msc-bbs-anonymous-credentials/jsonattr"
+	"github.com/aniagut/msc-bbs-anonymous-credentials/models"
+	"github.com/aniagut/msc-bbs-anonymous-credentials/presentation"
+	"github.com/aniagut/msc-bbs-anonymous-credentials/verify"
+)
+
+const (
+	// ProofType is the type of Data Integrity proofs.
+	ProofType = "DataIntegrityProof"
+	// Cryptosuite is the name of the cryptosuite.
+	Cryptosuite = "bbs-2023"
+)
+
+var (
+	baseProofHeader    = []byte{0xd9, 0x5d, 0x02}
+	derivedProofHeader = []byte{0xd9, 0x5d, 0x03}
+)
+
+// ProofOptions holds the options of a base proof.
+// It contains the following elements:
+// - VerificationMethod: The identifier of the issuer's public key.
+// - ProofPurpose: The purpose of the proof, typically "assertionMethod".
+// - Created: The creation time of the proof, omitted when zero.
+type ProofOptions struct {
+	VerificationMethod string
+	ProofPurpose       string
+	Created            time.Time
+}
+
+// Sign adds a bbs-2023 base proof to a credential document.
+//
+// Parameters:
+//   - document: The JSON credential document, without a proof.
+//   - mandatoryPointers: The JSON pointers of the statements every derived proof must disclose.
+//   - options: The options of the proof.
+//   - publicParams: The public parameters of the issuer.
+//   - secretKey: The secret key of the issuer.
+//   - publicKey: The public key of the issuer, embedded in the base proof.
+//
+// Returns:
+//   - []byte: The canonical JSON of the secured document.
+//   - error: An error if the document cannot be signed with the parameters.
+func Sign(document []byte, mandatoryPointers []string, options ProofOptions, publicParams models.PublicParameters, secretKey models.SecretKey, publicKey models.PublicKey) ([]byte, error) {
+	// Step 1: Split the document into statements
+	doc, existing, err := parseDocument(document)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return nil, errors.New("the document already has a proof")
+	}
+	statements, err := jsonattr.Flatten(doc)
+	if err != nil {
+		return nil, err
+	}
+	mandatory, err := jsonattr.Select(statements, mandatoryPointers)
+	if err != nil {
+		return nil, err
+	}
+
+	// Step 2: Compute the header SHA-256(proof configuration) || SHA-256(mandatory statements)
+	proof := map[string]interface{}{
+		"type":               ProofType,
+		"cryptosuite":        Cryptosuite,
+		"verificationMethod": options.VerificationMethod,
+		"proofPurpose":       options.ProofPurpose,
+	}
+	if !options.Created.IsZero() {
+		proof["created"] = options.Created.UTC().Format(time.RFC3339)
+	}
+	header, err := bbsHeader(proof, jsonattr.Pick(statements, mandatory))
+	if err != nil {
+		return nil, err
+	}
+
+	// Step 3: Sign the header and the statements
+	attributes, err := toAttributes(header, statements, len(publicParams.H1))
+	if err != nil {
+		return nil, err
+	}
+	signature, err := issue.Issue(attributes, publicParams, secretKey)
+	if err != nil {
+		return nil, err
+	}
+
+	// Step 4: Serialize the base proof [signature, header, public key, HMAC key, mandatory pointers]
+	signatureBytes, err := signature.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	publicKeyBytes, err := publicKey.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	pointers := mandatoryPointers
+	if pointers == nil {
+		pointers = []string{}
+	}
+	proofValue, err := encodeProofValue(baseProofHeader, []interface{}{signatureBytes, header, publicKeyBytes, []byte{}, pointers})
+	if err != nil {
+		return nil, err
+	}
+	proof["proofValue"] = proofValue
+	doc["proof"] = proof
+	return jcs.Marshal(doc)
+}
+
+// VerifyBase checks the base proof of a secured document, as the holder does after issuance.
+//
+// Parameters:
+//   - document: The JSON secured document.
+//   - publicParams: The public parameters of the issuer.
+//   - publicKey: The public key of the issuer.
+//
+// Returns:
+//   - error: An error if the proof is malformed or does not verify.
+func VerifyBase(document []byte, publicParams models.PublicParameters, publicKey models.PublicKey) error {
+	doc, proof, signature, header, mandatoryPointers, err := parseBase(document)
+	if err != nil {
+		return err
+	}
+
+	// Step 1: Recompute the header from the document and check it matches the signed one
+	statements, err := jsonattr.Flatten(doc)
+	if err != nil {
+		return err
+	}
+	mandatory, err := jsonattr.Select(statements, mandatoryPointers)
+	if err != nil {
+		return err
+	}
+	expected, err := bbsHeader(proof, jsonattr.Pick(statements, mandatory))
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(expected, header) {
+		return fmt.Errorf("%w: header does not match the document", models.ErrMalformedProof)
+	}
+
+	// Step 2: Verify the signature over the header and the statements
+	attributes, err := toAttributes(header, statements, len(publicParams.H1))
+	if err != nil {
+		return err
+	}
+	valid, err := issue.VerifyCredential(attributes, signature, publicParams, publicKey)
+	if err != nil {
+		return err
+	}
+	if !valid {
+		return models.ErrPairingFailed
+	}
+	return nil
+}
+
+// Derive creates a selectively disclosed document with a derived proof from a secured document.
+//
+// Parameters:
+//   - document: The JSON secured document with a base proof.
+//   - selectivePointers: The JSON pointers of the statements to disclose in addition to the mandatory ones.
+//   - presentationHeader: The verifier's nonce the derived proof is bound to.
+//   - publicParams: The public parameters of the issuer.
+//
+// Returns:
+//   - []byte: The canonical JSON of the disclosed document with its derived proof.
+//   - error: An error if the base proof is malformed or the proof generation fails.
+func Derive(document []byte, selectivePointers []string, presentationHeader []byte, publicParams models.PublicParameters) ([]byte, error) {
+	doc, proof, signature, header, mandatoryPointers, err := parseBase(document)
+	if err != nil {
+		return nil, err
+	}
+
+	// Step 1: Compute the disclosed statements, the mandatory ones and the selected ones
+	statements, err := jsonattr.Flatten(doc)
+	if err != nil {
+		return nil, err
+	}
+	mandatory, err := jsonattr.Select(statements, mandatoryPointers)
+	if err != nil {
+		return nil, err
+	}
+	selective, err := jsonattr.Select(statements, selectivePointers)
+	if err != nil {
+		return nil, err
+	}
+	disclosed := union(mandatory, selective)
+
+	// Step 2: Generate the proof disclosing the header and the disclosed statements
+	attributes, err := toAttributes(header, statements, len(publicParams.H1))
+	if err != nil {
+		return nil, err
+	}
+	revealed := make([]int, 0, len(disclosed)+1)
+	revealed = append(revealed, 0)
+	for _, position := range disclosed {
+		revealed = append(revealed, position+1)
+	}
+	zkpProof, err := presentation.Presentation(attributes, signature, revealed, publicParams, presentationHeader)
+	if err != nil {
+		return nil, err
+	}
+
+	// Step 3: Serialize the derived proof [proof, label map, mandatory indexes, selective indexes, presentation header].
+	// Mandatory indexes are positions within the disclosed statements, selective indexes are the positions of
+	// the disclosed statements within all statements.
+	mandatoryIndexes := make([]int, 0, len(mandatory))
+	for i, position := range disclosed {
+		if contains(mandatory, position) {
+			mandatoryIndexes = append(mandatoryIndexes, i)
+		}
+	}
+	proofBytes, err := zkpProof.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	proofValue, err := encodeProofValue(derivedProofHeader, []interface{}{proofBytes, map[string]interface{}{}, mandatoryIndexes, disclosed, presentationHeader})
+	if err != nil {
+		return nil, err
+	}
+
+	// Step 4: Build the disclosed document
+	revealedDoc, err := jsonattr.Reconstruct(jsonattr.Pick(statements, disclosed))
+	if err != nil {
+		return nil, err
+	}
+	proof["proofValue"] = proofValue
+	revealedDoc["proof"] = proof
+	return jcs.Marshal(revealedDoc)
+}
+
+// VerifyDerived checks the derived proof of a disclosed document.
+//
+// Parameters:
+//   - document: The JSON disclosed document with a derived proof.
+//   - presentationHeader: The nonce the verifier expects the proof to be bound to.
+//   - publicParams: The public parameters of the issuer.
+//   - publicKey: The public key of the issuer.
+//
+// Returns:
+//   - error: An error if the proof is malformed, bound to another nonce or does not verify.
+func VerifyDerived(document []byte, presentationHeader []byte, publicParams models.PublicParameters, publicKey models.PublicKey) error {
+	// Step 1: Parse the document and the derived proof
+	doc, proofObject, err := parseDocument(document)
+	if err != nil {
+		return err
+	}
+	proof, components, err := decodeProof(proofObject, derivedProofHeader, 5)
+	if err != nil {
+		return err
+	}
+	proofBytes, okProof := components[0].([]byte)
+	mandatoryIndexes, okMandatory := toInts(components[2])
+	selectiveIndexes, okSelective := toInts(components[3])
+	boundHeader, okHeader := components[4].([]byte)
+	if !okProof || !okMandatory || !okSelective || !okHeader {
+		return fmt.Errorf("%w: unexpected derived proof components", models.ErrMalformedProof)
+	}
+	if !bytes.Equal(boundHeader, presentationHeader) {
+		return fmt.Errorf("%w: proof is bound to another presentation header", models.ErrChallengeMismatch)
+	}
+	var zkpProof models.SignatureProof
+	if err := zkpProof.UnmarshalBinary(proofBytes); err != nil {
+		return err
+	}
+
+	// Step 2: Match the disclosed statements with their positions
+	statements, err := jsonattr.Flatten(doc)
+	if err != nil {
+		return err
+	}
+	if len(statements) != len(selectiveIndexes) || !increasing(selectiveIndexes, len(publicParams.H1)-1) || !increasing(mandatoryIndexes, len(statements)) {
+		return fmt.Errorf("%w: disclosed statements do not match the proof indexes", models.ErrMalformedProof)
+	}
+
+	// Step 3: Recompute the header from the proof configuration and the mandatory statements
+	mandatory := make([]jsonattr.Attribute, len(mandatoryIndexes))
+	for i, index := range mandatoryIndexes {
+		mandatory[i] = statements[index]
+	}
+	header, err := bbsHeader(proof, mandatory)
+	if err != nil {
+		return err
+	}
+
+	// Step 4: Verify the proof over the header and the disclosed statements
+	revealedAttributes := make([]string, 0, len(statements)+1)
+	revealedIndices := make([]int, 0, len(statements)+1)
+	revealedAttributes = append(revealedAttributes, headerAttribute(header))
+	revealedIndices = append(revealedIndices, 0)
+	for i, s := range statements {
+		revealedAttributes = append(revealedAttributes, s.Message())
+		revealedIndices = append(revealedIndices, selectiveIndexes[i]+1)
+	}
+	valid, err := verify.Verify(zkpProof, presentationHeader, revealedAttributes, revealedIndices, publicParams, publicKey)
+	if err != nil {
+		return err
+	}
+	if !valid {
+		return models.ErrPairingFailed
+	}
+	return nil
+}
+
+// parseBase parses a secured document and its base proof.
+func parseBase(document []byte) (map[string]interface{}, map[string]interface{}, models.Signature, []byte, []string, error) {
+	doc, proofObject, err := parseDocument(document)
+	if err != nil {
+		return nil, nil, models.Signature{}, nil, nil, err
+	}
+	proof, components, err := decodeProof(proofObject, baseProofHeader, 5)
+	if err != nil {
+		return nil, nil, models.Signature{}, nil, nil, err
+	}
+	signatureBytes, okSignature := components[0].([]byte)
+	header, okHeader := components[1].([]byte)
+	pointerValues, okPointers := components[4].([]interface{})
+	if !okSignature || !okHeader || !okPointers {
+		return nil, nil, models.Signature{}, nil, nil, fmt.Errorf("%w: unexpected base proof components", models.ErrMalformedProof)
+	}
+	pointers := make([]string, len(pointerValues))
+	for i, value := range pointerValues {
+		pointer, ok := value.(string)
+		if !ok {
+			return nil, nil, models.Signature{}, nil, nil, fmt.Errorf("%w: mandatory pointer is not a string", models.ErrMalformedProof)
+		}
+		pointers[i] = pointer
+	}
+	var signature models.Signature
+	if err := signature.UnmarshalBinary(signatureBytes); err != nil {
+		return nil, nil, models.Signature{}, nil, nil, err
+	}
+	return doc, proof, signature, header, pointers, nil
+}
+
+// decodeProof checks the proof object and decodes the components of its proof value.
+func decodeProof(proofObject interface{}, prefix []byte, count int) (map[string]interface{}, []interface{}, error) {
+	proof, ok := proofObject.(map[string]interface{})
+	if !ok {
+		return nil, nil, fmt.Errorf("%w: missing proof", models.ErrMalformedProof)
+	}
+	if proof["type"] != ProofType || proof["cryptosuite"] != Cryptosuite {
+		return nil, nil, fmt.Errorf("%w: not a %s proof", models.ErrMalformedProof, Cryptosuite)
+	}
+	proofValue, ok := proof["proofValue"].(string)
+	if !ok || len(proofValue) == 0 || proofValue[0] != 'u' {
+		return nil, nil, fmt.Errorf("%w: proof value is not multibase base64url", models.ErrInvalidEncoding)
+	}
+	data, err := base64.RawURLEncoding.DecodeString(proofValue[1:])
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: proof value: %v", models.ErrInvalidEncoding, err)
+	}
+	if !bytes.HasPrefix(data, prefix) {
+		return nil, nil, fmt.Errorf("%w: unexpected proof value header", models.ErrMalformedProof)
+	}
+	value, err := cbor.Unmarshal(data[len(prefix):])
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: proof value: %v", models.ErrInvalidEncoding, err)
+	}
+	components, ok := value.([]interface{})
+	if !ok || len(components) != count {
+		return nil, nil, fmt.Errorf("%w: expected %d proof components", models.ErrMalformedProof, count)
+	}
+	return proof, components, nil
+}
+
+// parseDocument parses a JSON document and separates its proof.
+func parseDocument(data []byte) (map[string]interface{}, interface{}, error) {
+	document, err := jsonattr.Parse(data)
+	if err != nil {
+		return nil, nil, err
+	}
+	proof := document["proof"]
+	delete(document, "proof")
+	return document, proof, nil
+}
+
+// encodeProofValue serializes proof components as a multibase base64url proof value.
+func encodeProofValue(prefix []byte, components []interface{}) (string, error) {
+	data, err := cbor.Marshal(components)
+	if err != nil {
+		return "", err
+	}
+	return "u" + base64.RawURLEncoding.EncodeToString(append(append([]byte(nil), prefix...), data...)), nil
+}
+
+// bbsHeader computes SHA-256(proof configuration) || SHA-256(mandatory statements).
+// The proof configuration is the proof without its value.
+func bbsHeader(proof map[string]interface{}, mandatory []jsonattr.Attribute) ([]byte, error) {
+	config := make(map[string]interface{}, len(proof))
+	for key, value := range proof {
+		if key != "proofValue" {
+			config[key] = value
+		}
+	}
+	canonicalConfig, err := jcs.Marshal(config)
+	if err != nil {
+		return nil, err
+	}
+
+	canonicalMandatory := make([]interface{}, len(mandatory))
+	for i, s := range mandatory {
+		canonicalMandatory[i] = string(s.Canonical())
+	}
+	canonicalStatements, err := jcs.Marshal(canonicalMandatory)
+	if err != nil {
+		return nil, err
+	}
+
+	proofHash := sha256.Sum256(canonicalConfig)
+	mandatoryHash := sha256.Sum256(canonicalStatements)
+	return append(proofHash[:], mandatoryHash[:]...), nil
+}
+
+// toAttributes builds the signed attributes: the header followed by the statements, padded to l attributes.
+func toAttributes(header []byte, statements []jsonattr.Attribute, l int) ([]string, error) {
+	return jsonattr.PaddedMessages([]string{headerAttribute(header)}, statements, l)
+}
+
+// headerAttribute returns the signed attribute of the header.
+func headerAttribute(header []byte) string {
+	digest := sha256.Sum256(header)
+	return string(digest[:])
+}
+
+// union merges two sorted position lists.
+func union(a, b []int) []int {
+	merged := make([]int, 0, len(a)+len(b))
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case j == len(b) || (i < len(a) && a[i] < b[j]):
+			merged = append(merged, a[i])
+			i++
+		case i == len(a) || b[j] < a[i]:
+			merged = append(merged, b[j])
+			j++
+		default:
+			merged = append(merged, a[i])
+			i++
+			j++
+		}
+	}
+	return merged
+}
+
+// contains reports whether a sorted position list contains the position.
+func contains(positions []int, position int) bool {
+	for _, p := range positions {
+		if p == position {
+			return true
+		}
+	}
+	return false
+}
+
+// increasing reports whether the indexes are strictly increasing and below the limit.
+func increasing(indexes []int, limit int) bool {
+	for i, index := range indexes {
+		if index < 0 || index >= limit || (i > 0 && index <= indexes[i-1]) {
+			return false
+		}
+	}
+	return true
+}
+
+// toInts converts a decoded CBOR array of unsigned integers.
+func toInts(value interface{}) ([]int, bool) {
+	array, ok := value.([]interface{})
+	if !ok {
+		return nil, false
+	}
+	ints := make([]int, len(array))
+	for i, element := range array {
+		n, ok := element.(uint64)
+		if !ok || n > 1<<31 {
+			return nil, false
+		}
+		ints[i] = int(n)
+	}
+	return ints, true
+}
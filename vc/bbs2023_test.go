@@ -0,0 +1,111 @@
+package vc
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/aniagut/msc-bbs-anonymous-credentials/models"
+	"github.com/aniagut/msc-bbs-anonymous-credentials/setup"
+	"github.com/stretchr/testify/assert"
+)
+
+// credential is the document used in the tests
+const credential = `{
+	"@context": ["https://www.w3.org/ns/credentials/v2"],
+	"type": ["VerifiableCredential", "EmployeeCredential"],
+	"issuer": "did:example:issuer",
+	"validFrom": "2024-01-01T00:00:00Z",
+	"credentialSubject": {
+		"name": "Alice Smith",
+		"birthYear": 1990,
+		"address": {"city": "Warsaw", "country": "PL"},
+		"roles": ["admin", "dev"]
+	}
+}`
+
+// MockSigned sets up an issuer and signs the test credential
+func MockSigned(t *testing.T) ([]byte, models.SetupResult) {
+	setupResult, err := setup.Setup(16)
+	assert.NoError(t, err, "Expected no error during setup")
+	secured, err := Sign([]byte(credential), []string{"/issuer", "/type"}, ProofOptions{
+		VerificationMethod: "did:example:issuer#key-1",
+		ProofPurpose:       "assertionMethod",
+		Created:            time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+	}, setupResult.PublicParameters, setupResult.SecretKey, setupResult.PublicKey)
+	assert.NoError(t, err, "Expected no error signing the credential")
+	return secured, setupResult
+}
+
+// Test for signing and verifying a base proof
+func TestSignAndVerifyBase(t *testing.T) {
+	secured, setupResult := MockSigned(t)
+
+	err := VerifyBase(secured, setupResult.PublicParameters, setupResult.PublicKey)
+	assert.NoError(t, err, "Expected the base proof to verify")
+
+	var doc map[string]interface{}
+	assert.NoError(t, json.Unmarshal(secured, &doc), "Expected the secured document to be JSON")
+	proof := doc["proof"].(map[string]interface{})
+	assert.Equal(t, Cryptosuite, proof["cryptosuite"], "Expected a bbs-2023 proof")
+	assert.Equal(t, "u2V0C", proof["proofValue"].(string)[:5], "Expected the base proof header 0xd95d02")
+
+	tampered := []byte(string(secured[:len(secured)-1]) + `,"extra":true}`)
+	err = VerifyBase(tampered, setupResult.PublicParameters, setupResult.PublicKey)
+	assert.Error(t, err, "Expected a modified document to fail verification")
+}
+
+// Test for deriving and verifying a selective disclosure proof
+func TestDeriveAndVerify(t *testing.T) {
+	secured, setupResult := MockSigned(t)
+	nonce := []byte("verifier-nonce")
+
+	derived, err := Derive(secured, []string{"/credentialSubject/address/country", "/credentialSubject/roles"}, nonce, setupResult.PublicParameters)
+	assert.NoError(t, err, "Expected no error deriving the proof")
+
+	var doc map[string]interface{}
+	assert.NoError(t, json.Unmarshal(derived, &doc), "Expected the derived document to be JSON")
+	subject := doc["credentialSubject"].(map[string]interface{})
+	assert.Equal(t, map[string]interface{}{"country": "PL"}, subject["address"], "Expected only the country to be disclosed")
+	assert.NotContains(t, subject, "name", "Expected the name to stay hidden")
+	assert.Equal(t, "did:example:issuer", doc["issuer"], "Expected the mandatory issuer to be disclosed")
+
+	err = VerifyDerived(derived, nonce, setupResult.PublicParameters, setupResult.PublicKey)
+	assert.NoError(t, err, "Expected the derived proof to verify")
+
+	err = VerifyDerived(derived, []byte("other-nonce"), setupResult.PublicParameters, setupResult.PublicKey)
+	assert.ErrorIs(t, err, models.ErrChallengeMismatch, "Expected a proof bound to another nonce to fail")
+}
+
+// Test for a derived document whose disclosed values were modified
+func TestVerifyDerived_Tampered(t *testing.T) {
+	secured, setupResult := MockSigned(t)
+	nonce := []byte("verifier-nonce")
+	derived, err := Derive(secured, []string{"/credentialSubject/birthYear"}, nonce, setupResult.PublicParameters)
+	assert.NoError(t, err, "Expected no error deriving the proof")
+
+	var doc map[string]interface{}
+	assert.NoError(t, json.Unmarshal(derived, &doc), "Expected the derived document to be JSON")
+	doc["credentialSubject"].(map[string]interface{})["birthYear"] = 2010
+	tampered, _ := json.Marshal(doc)
+	err = VerifyDerived(tampered, nonce, setupResult.PublicParameters, setupResult.PublicKey)
+	assert.Error(t, err, "Expected a modified disclosed value to fail verification")
+
+	delete(doc, "issuer")
+	doc["credentialSubject"].(map[string]interface{})["birthYear"] = 1990
+	tampered, _ = json.Marshal(doc)
+	err = VerifyDerived(tampered, nonce, setupResult.PublicParameters, setupResult.PublicKey)
+	assert.Error(t, err, "Expected a document missing a mandatory statement to fail verification")
+}
+
+// Test for documents that cannot be signed
+func TestSign_Invalid(t *testing.T) {
+	setupResult, err := setup.Setup(3)
+	assert.NoError(t, err, "Expected no error during setup")
+
+	_, err = Sign([]byte(credential), nil, ProofOptions{}, setupResult.PublicParameters, setupResult.SecretKey, setupResult.PublicKey)
+	assert.ErrorIs(t, err, models.ErrLengthMismatch, "Expected an error for more statements than generators")
+
+	_, err = Sign([]byte(`{"a":1}`), []string{"/b"}, ProofOptions{}, setupResult.PublicParameters, setupResult.SecretKey, setupResult.PublicKey)
+	assert.Error(t, err, "Expected an error for a mandatory pointer selecting nothing")
+}
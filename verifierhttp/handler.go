@@ -0,0 +1,183 @@
+// Package verifierhttp exposes presentation verification over HTTP with a challenge/response flow.
+//
+// The handler serves the following endpoints:
+//
+//	GET  /health       - liveness of the verifier.
+//	GET  /presentation - issues a presentation request bound to a fresh single-use nonce.
+//	POST /presentation - verifies the holder's response to a previously issued request.
+package verifierhttp
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/aniagut/msc-bbs-anonymous-credentials/internal/httpjson"
+	"github.com/aniagut/msc-bbs-anonymous-credentials/models"
+	"github.com/aniagut/msc-bbs-anonymous-credentials/nonce"
+	"github.com/aniagut/msc-bbs-anonymous-credentials/request"
+)
+
+// maxRequestSize bounds the size of presentation bodies.
+const maxRequestSize = 1 << 20
+
+// ChallengeResponse is the body of GET /presentation.
+type ChallengeResponse struct {
+	Request models.PresentationRequest `json:"request"`
+}
+
+// PresentationSubmission is the body of POST /presentation.
+// It contains the following elements:
+// - Nonce: The nonce of the presentation request being answered.
+// - Response: The holder's response, with the encoded proof and the revealed attributes.
+type PresentationSubmission struct {
+	Nonce    []byte                      `json:"nonce"`
+	Response models.PresentationResponse `json:"response"`
+}
+
+// Verdict is the body of every answered POST /presentation.
+// It contains the following elements:
+// - Valid: Whether the presentation satisfies the request.
+// - IssuerKeyID: The identifier of the issuer key the presentation was checked against.
+// - Attributes: The revealed attributes by name, set only for valid presentations.
+// - Error: The reason for rejecting an invalid presentation.
+type Verdict struct {
+	Valid       bool              `json:"valid"`
+	IssuerKeyID string            `json:"issuerKeyId,omitempty"`
+	Attributes  map[string]string `json:"attributes,omitempty"`
+	Error       string            `json:"error,omitempty"`
+}
+
+// Config holds the configuration of the verifier handler.
+// It contains the following elements:
+// - Nonces: The manager issuing and consuming the nonces of the presentation requests.
+// - Issuers: The trusted issuers. Presentations are accepted from any of them.
+// - RequestedAttributes: The names of the attributes that must be revealed.
+// - Predicates: The conditions the revealed attributes must satisfy.
+type Config struct {
+	Nonces              *nonce.Manager
+	Issuers             []request.Issuer
+	RequestedAttributes []string
+	Predicates          []models.Predicate
+}
+
+// Handler serves the verifier endpoints.
+type Handler struct {
+	config  Config
+	issuers map[string]request.Issuer
+	keyIDs  []string
+	mux     *http.ServeMux
+}
+
+// NewHandler creates a verifier handler.
+//
+// Parameters:
+//   - config: The configuration of the verifier.
+//
+// Returns:
+//   - *Handler: The verifier handler, to be mounted on a server or under a prefix with http.StripPrefix.
+//   - error: An error if the configuration is incomplete or a trusted issuer cannot answer the request.
+func NewHandler(config Config) (*Handler, error) {
+	if config.Nonces == nil {
+		return nil, errors.New("a nonce manager is required")
+	}
+	if len(config.Issuers) == 0 {
+		return nil, errors.New("at least one trusted issuer is required")
+	}
+
+	h := &Handler{
+		config:  config,
+		issuers: make(map[string]request.Issuer, len(config.Issuers)),
+		keyIDs:  make([]string, 0, len(config.Issuers)),
+		mux:     http.NewServeMux(),
+	}
+	for _, issuer := range config.Issuers {
+		if issuer.PublicKey.X2 == nil {
+			return nil, fmt.Errorf("%w: missing issuer public key", models.ErrInvalidPublicKey)
+		}
+		// Every trusted issuer must be able to answer the request with its schema
+		if _, err := request.RevealedIndices(h.request(nil), issuer.Schema); err != nil {
+			return nil, err
+		}
+		keyID := issuer.PublicKey.KeyID()
+		if _, ok := h.issuers[keyID]; !ok {
+			h.keyIDs = append(h.keyIDs, keyID)
+		}
+		h.issuers[keyID] = issuer
+	}
+	sort.Strings(h.keyIDs)
+
+	h.mux.HandleFunc("GET /health", h.health)
+	h.mux.HandleFunc("GET /presentation", h.challenge)
+	h.mux.HandleFunc("POST /presentation", h.verify)
+	return h, nil
+}
+
+// ServeHTTP implements http.Handler.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.mux.ServeHTTP(w, r)
+}
+
+func (h *Handler) health(w http.ResponseWriter, r *http.Request) {
+	httpjson.WriteJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+func (h *Handler) challenge(w http.ResponseWriter, r *http.Request) {
+	n, err := h.config.Nonces.Issue()
+	if err != nil {
+		httpjson.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+	req := h.request(n)
+	if ttl := h.config.Nonces.TTL(); ttl > 0 {
+		req.Expiry = time.Now().Add(ttl).UTC()
+	}
+	httpjson.WriteJSON(w, http.StatusOK, ChallengeResponse{Request: req})
+}
+
+func (h *Handler) verify(w http.ResponseWriter, r *http.Request) {
+	// Step 1: Decode the submission
+	var submission PresentationSubmission
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRequestSize))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&submission); err != nil {
+		httpjson.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid presentation: %w", err))
+		return
+	}
+	if len(submission.Nonce) == 0 {
+		httpjson.WriteError(w, http.StatusBadRequest, errors.New("invalid presentation: missing nonce"))
+		return
+	}
+
+	// Step 2: Consume the nonce, so that the request can be answered only once whatever the outcome
+	if err := h.config.Nonces.Consume(submission.Nonce); err != nil {
+		httpjson.WriteJSON(w, http.StatusOK, Verdict{Error: err.Error()})
+		return
+	}
+
+	// Step 3: Check the response against the request identified by the nonce.
+	// The nonce manager already enforced the expiry of the request.
+	attributes, err := request.Check(h.request(submission.Nonce), submission.Response, h.issuers)
+	if err != nil {
+		httpjson.WriteJSON(w, http.StatusOK, Verdict{IssuerKeyID: submission.Response.IssuerKeyID, Error: err.Error()})
+		return
+	}
+	httpjson.WriteJSON(w, http.StatusOK, Verdict{
+		Valid:       true,
+		IssuerKeyID: submission.Response.IssuerKeyID,
+		Attributes:  attributes,
+	})
+}
+
+// request builds the presentation request of the verifier for the given nonce.
+func (h *Handler) request(n []byte) models.PresentationRequest {
+	return models.PresentationRequest{
+		RequestedAttributes: h.config.RequestedAttributes,
+		Predicates:          h.config.Predicates,
+		Nonce:               n,
+		IssuerKeyIDs:        h.keyIDs,
+	}
+}
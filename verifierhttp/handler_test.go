@@ -0,0 +1,147 @@
+package verifierhttp
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/aniagut/msc-bbs-anonymous-credentials/issue"
+	"github.com/aniagut/msc-bbs-anonymous-credentials/models"
+	"github.com/aniagut/msc-bbs-anonymous-credentials/nonce"
+	"github.com/aniagut/msc-bbs-anonymous-credentials/request"
+	"github.com/aniagut/msc-bbs-anonymous-credentials/setup"
+	"github.com/stretchr/testify/assert"
+)
+
+// MockIssuer sets up an issuer and a credential issued by it for testing
+func MockIssuer(t *testing.T, attributes []string) (request.Issuer, models.Credential) {
+	schema := []string{"name", "age", "country"}
+	setupResult, err := setup.Setup(len(schema))
+	assert.NoError(t, err, "Expected no error during setup")
+	signature, err := issue.Issue(attributes, setupResult.PublicParameters, setupResult.SecretKey)
+	assert.NoError(t, err, "Expected no error during issuance")
+
+	issuer := request.Issuer{
+		PublicParameters: setupResult.PublicParameters,
+		PublicKey:        setupResult.PublicKey,
+		Schema:           schema,
+	}
+	credential := models.Credential{
+		Schema:      schema,
+		Attributes:  attributes,
+		Signature:   signature,
+		IssuerKeyID: setupResult.PublicKey.KeyID(),
+	}
+	return issuer, credential
+}
+
+// MockServer starts a verifier server trusting the given issuers for testing
+func MockServer(t *testing.T, issuers ...request.Issuer) *httptest.Server {
+	handler, err := NewHandler(Config{
+		Nonces:              nonce.NewManager(nonce.NewMemoryStore(), time.Minute),
+		Issuers:             issuers,
+		RequestedAttributes: []string{"country"},
+		Predicates:          []models.Predicate{{Attribute: "age", Operator: models.PredicateGreaterOrEqual, Value: "18"}},
+	})
+	assert.NoError(t, err, "Expected no error when creating the handler")
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	return server
+}
+
+// getRequest fetches a fresh presentation request from the verifier
+func getRequest(t *testing.T, server *httptest.Server) models.PresentationRequest {
+	resp, err := http.Get(server.URL + "/presentation")
+	assert.NoError(t, err, "Expected no error fetching the request")
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode, "Expected the request to be issued")
+
+	var challenge ChallengeResponse
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&challenge), "Expected no error decoding the request")
+	return challenge.Request
+}
+
+// submit sends a presentation to the verifier and decodes the verdict
+func submit(t *testing.T, server *httptest.Server, submission PresentationSubmission) (int, Verdict) {
+	body, err := json.Marshal(submission)
+	assert.NoError(t, err, "Expected no error encoding the submission")
+	resp, err := http.Post(server.URL+"/presentation", "application/json", bytes.NewReader(body))
+	assert.NoError(t, err, "Expected no error sending the submission")
+	defer resp.Body.Close()
+
+	var verdict Verdict
+	if resp.StatusCode == http.StatusOK {
+		assert.NoError(t, json.NewDecoder(resp.Body).Decode(&verdict), "Expected no error decoding the verdict")
+	}
+	return resp.StatusCode, verdict
+}
+
+// Test for the challenge/response flow with several trusted issuers
+func TestHandler_ChallengeResponse(t *testing.T) {
+	first, _ := MockIssuer(t, []string{"Bob", "40", "DE"})
+	second, credential := MockIssuer(t, []string{"Alice", "30", "PL"})
+	server := MockServer(t, first, second)
+
+	req := getRequest(t, server)
+	assert.ElementsMatch(t, []string{first.PublicKey.KeyID(), second.PublicKey.KeyID()}, req.IssuerKeyIDs, "Expected every trusted issuer to be accepted")
+	assert.False(t, req.Expiry.IsZero(), "Expected the request to expire with its nonce")
+
+	resp, err := request.Respond(req, credential, second.PublicParameters)
+	assert.NoError(t, err, "Expected no error building the response")
+
+	status, verdict := submit(t, server, PresentationSubmission{Nonce: req.Nonce, Response: resp})
+	assert.Equal(t, http.StatusOK, status, "Expected a verdict")
+	assert.True(t, verdict.Valid, "Expected the presentation to be valid: "+verdict.Error)
+	assert.Equal(t, credential.IssuerKeyID, verdict.IssuerKeyID, "Expected the issuer of the credential")
+	assert.Equal(t, map[string]string{"age": "30", "country": "PL"}, verdict.Attributes, "Expected the revealed attributes by name")
+
+	// Replaying the same presentation must fail
+	_, verdict = submit(t, server, PresentationSubmission{Nonce: req.Nonce, Response: resp})
+	assert.False(t, verdict.Valid, "Expected a replayed presentation to be rejected")
+}
+
+// Test for presentations the verifier must reject
+func TestHandler_Rejections(t *testing.T) {
+	trusted, _ := MockIssuer(t, []string{"Bob", "40", "DE"})
+	untrusted, credential := MockIssuer(t, []string{"Alice", "30", "PL"})
+	server := MockServer(t, trusted)
+
+	// A credential of an untrusted issuer
+	req := getRequest(t, server)
+	req.IssuerKeyIDs = nil
+	resp, err := request.Respond(req, credential, untrusted.PublicParameters)
+	assert.NoError(t, err, "Expected no error building the response")
+	_, verdict := submit(t, server, PresentationSubmission{Nonce: req.Nonce, Response: resp})
+	assert.False(t, verdict.Valid, "Expected a presentation of an untrusted issuer to be rejected")
+
+	// A proof bound to another nonce
+	other := getRequest(t, server)
+	_, verdict = submit(t, server, PresentationSubmission{Nonce: other.Nonce, Response: resp})
+	assert.False(t, verdict.Valid, "Expected a presentation bound to another nonce to be rejected")
+
+	// A nonce never issued by the verifier
+	_, verdict = submit(t, server, PresentationSubmission{Nonce: []byte("unknown"), Response: resp})
+	assert.False(t, verdict.Valid, "Expected an unknown nonce to be rejected")
+
+	// A malformed submission
+	status, _ := submit(t, server, PresentationSubmission{Response: resp})
+	assert.Equal(t, http.StatusBadRequest, status, "Expected a submission without a nonce to be malformed")
+}
+
+// Test for an incomplete configuration
+func TestNewHandler_InvalidConfig(t *testing.T) {
+	issuer, _ := MockIssuer(t, []string{"Alice", "30", "PL"})
+
+	_, err := NewHandler(Config{Issuers: []request.Issuer{issuer}})
+	assert.Error(t, err, "Expected an error without a nonce manager")
+
+	_, err = NewHandler(Config{
+		Nonces:              nonce.NewManager(nonce.NewMemoryStore(), time.Minute),
+		Issuers:             []request.Issuer{issuer},
+		RequestedAttributes: []string{"email"},
+	})
+	assert.Error(t, err, "Expected an error for an attribute missing from the issuer schema")
+}
@@ -0,0 +1,49 @@
+package verify
+
+import (
+	"github.com/aniagut/msc-bbs-anonymous-credentials/models"
+	"github.com/aniagut/msc-bbs-anonymous-credentials/utils"
+	e "github.com/cloudflare/circl/ecc/bls12381"
+)
+
+// Extension is the verifier side of a presentation.Extension: it recomputes the commitments of the statement from
+// the responses of the proof and of its own. A wrong statement yields other commitments and a challenge mismatch.
+type Extension interface {
+	// Commit recomputes the commitments of the extension, which are appended to the input of the challenge.
+	Commit(responses Responses) ([]byte, error)
+}
+
+// Responses holds the responses of a proof that extensions recompute their commitments from.
+// It contains the following elements:
+// - Ch: The challenge of the proof.
+// - Zr: The response for the randomizer r.
+// - Hidden: The indices of the hidden attributes, in increasing order.
+// - Z: The responses for r * a[j], aligned with Hidden.
+type Responses struct {
+	Ch     *e.Scalar
+	Zr     *e.Scalar
+	Hidden []int
+	Z      []e.Scalar
+}
+
+// Position returns the position of an attribute among the hidden attributes.
+func (r Responses) Position(index int) (int, error) {
+	return utils.HiddenPosition(r.Hidden, index)
+}
+
+// commitExtensions recomputes the commitments of the extensions.
+func commitExtensions(extensions []Extension, zkpProof models.SignatureProof, l int, revealedIndices []int) ([][]byte, error) {
+	if len(extensions) == 0 {
+		return nil, nil
+	}
+	responses := Responses{Ch: zkpProof.Ch, Zr: zkpProof.Zr, Hidden: utils.HiddenIndices(l, revealedIndices), Z: zkpProof.Zi}
+	commitments := make([][]byte, len(extensions))
+	for i, extension := range extensions {
+		commitment, err := extension.Commit(responses)
+		if err != nil {
+			return nil, err
+		}
+		commitments[i] = commitment
+	}
+	return commitments, nil
+}
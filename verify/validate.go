@@ -0,0 +1,64 @@
+package verify
+
+import (
+	"fmt"
+
+	"github.com/aniagut/msc-bbs-anonymous-credentials/models"
+)
+
+// ValidateProof checks the structure of a proof and of the public inputs of the verification before any
+// heavy computation runs. It rejects nil proof components, an identity APrim (which, with an identity BPrim,
+// trivially satisfies the pairing equation), points outside of the G1 subgroup, revealed indices that are
+// out of range, unsorted or duplicated, and response vectors whose length does not match the hidden attributes.
+//
+// Parameters:
+//   - zkpProof: The zero-knowledge proof to be validated.
+//   - revealedAttributes: The list of revealed attributes.
+//   - revealedIndices: The list of indices for revealed attributes.
+//   - publicParams: The public parameters of the system.
+//   - publicKey: The public key of the system.
+//
+// Returns:
+//   - error: An error wrapping models.ErrMalformedProof, models.ErrInvalidPublicKey, models.ErrIndexOutOfRange,
+//     models.ErrUnsortedIndices or models.ErrLengthMismatch if the inputs are invalid, nil otherwise.
+func ValidateProof(zkpProof models.SignatureProof, revealedAttributes []string, revealedIndices []int, publicParams models.PublicParameters, publicKey models.PublicKey) error {
+	// Step 1: Check the public inputs
+	if publicParams.G1 == nil || publicParams.G2 == nil || publicKey.X2 == nil {
+		return fmt.Errorf("%w: missing generator or verification key", models.ErrInvalidPublicKey)
+	}
+	if publicKey.X2.IsIdentity() || !publicKey.X2.IsOnG2() {
+		return fmt.Errorf("%w: X2 is not a valid element of G2", models.ErrInvalidPublicKey)
+	}
+
+	// Step 2: Check that all proof components are present
+	if zkpProof.APrim == nil || zkpProof.BPrim == nil || zkpProof.Ch == nil || zkpProof.Zr == nil || zkpProof.Ze == nil {
+		return fmt.Errorf("%w: missing proof component", models.ErrMalformedProof)
+	}
+
+	// Step 3: Check that APrim and BPrim are elements of G1 and that APrim is not the identity
+	if zkpProof.APrim.IsIdentity() {
+		return fmt.Errorf("%w: APrim is the identity", models.ErrMalformedProof)
+	}
+	if !zkpProof.APrim.IsOnG1() || !zkpProof.BPrim.IsOnG1() {
+		return fmt.Errorf("%w: APrim or BPrim is not an element of G1", models.ErrMalformedProof)
+	}
+
+	// Step 4: Check that the revealed indices are in range and strictly increasing
+	if len(revealedAttributes) != len(revealedIndices) {
+		return fmt.Errorf("%w: %d revealed attributes, %d revealed indices", models.ErrLengthMismatch, len(revealedAttributes), len(revealedIndices))
+	}
+	for i, index := range revealedIndices {
+		if index < 0 || index >= len(publicParams.H1) {
+			return fmt.Errorf("%w: index %d, %d attributes", models.ErrIndexOutOfRange, index, len(publicParams.H1))
+		}
+		if i > 0 && index <= revealedIndices[i-1] {
+			return fmt.Errorf("%w: index %d follows %d", models.ErrUnsortedIndices, index, revealedIndices[i-1])
+		}
+	}
+
+	// Step 5: Check that there is exactly one response per hidden attribute
+	if len(zkpProof.Zi) != len(publicParams.H1)-len(revealedIndices) {
+		return fmt.Errorf("%w: %d responses for %d hidden attributes", models.ErrMalformedProof, len(zkpProof.Zi), len(publicParams.H1)-len(revealedIndices))
+	}
+	return nil
+}
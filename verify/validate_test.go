@@ -0,0 +1,72 @@
+package verify
+
+import (
+	"testing"
+
+	"github.com/aniagut/msc-bbs-anonymous-credentials/models"
+	"github.com/aniagut/msc-bbs-anonymous-credentials/setup"
+	"github.com/aniagut/msc-bbs-anonymous-credentials/utils"
+	e "github.com/cloudflare/circl/ecc/bls12381"
+	"github.com/stretchr/testify/assert"
+)
+
+// Test for a forged proof with identity APrim and BPrim
+func TestVerify_IdentityForgery(t *testing.T) {
+	setupResult, err := setup.Setup(2)
+	assert.NoError(t, err, "Expected no error during setup")
+	publicParams := setupResult.PublicParameters
+	revealedAttributes := []string{"forged"}
+	revealedIndices := []int{0}
+	nonce := []byte("random_nonce")
+
+	// Without knowing any signature, pick the responses and derive a matching challenge
+	identity := new(e.G1)
+	identity.SetIdentity()
+	zR, zE := new(e.Scalar), new(e.Scalar)
+	zR.SetUint64(1)
+	zE.SetUint64(1)
+	zI := make([]e.Scalar, 1)
+	zI[0].SetUint64(1)
+	CRev, _ := utils.ComputeCommitment(revealedAttributes, publicParams.H1[:1], publicParams.G1)
+	hiddenH1Exp, _ := utils.ComputeH1Exp(publicParams.H1[1:], zI)
+	U := new(e.G1)
+	U.ScalarMult(zR, CRev)
+	U.Add(U, hiddenH1Exp)
+	ch, _ := utils.ComputeChallenge(nonce, U, identity, identity, revealedAttributes)
+
+	forged := models.SignatureProof{APrim: identity, BPrim: identity, Ch: &ch, Zr: zR, Zi: zI, Ze: zE}
+	valid, err := Verify(forged, nonce, revealedAttributes, revealedIndices, publicParams, setupResult.PublicKey)
+
+	assert.ErrorIs(t, err, models.ErrMalformedProof, "Expected an identity APrim to be rejected")
+	assert.False(t, valid, "Expected the forged proof to be invalid")
+}
+
+// Test for structurally invalid proofs and revealed indices
+func TestValidateProof(t *testing.T) {
+	attributes := []string{"attribute1", "attribute2", "attribute3"}
+	revealed := []int{0, 2}
+	proof, setupResult := MockPresentation(t, attributes, revealed, []byte("random_nonce"))
+	publicParams, publicKey := setupResult.PublicParameters, setupResult.PublicKey
+	revealedAttributes := []string{"attribute1", "attribute3"}
+
+	assert.NoError(t, ValidateProof(proof, revealedAttributes, revealed, publicParams, publicKey), "Expected a valid proof to pass")
+
+	missing := proof
+	missing.Ch = nil
+	assert.ErrorIs(t, ValidateProof(missing, revealedAttributes, revealed, publicParams, publicKey), models.ErrMalformedProof, "Expected a nil challenge to be rejected")
+
+	missing = proof
+	missing.BPrim = nil
+	assert.ErrorIs(t, ValidateProof(missing, revealedAttributes, revealed, publicParams, publicKey), models.ErrMalformedProof, "Expected a nil BPrim to be rejected")
+
+	assert.ErrorIs(t, ValidateProof(proof, revealedAttributes, []int{2, 0}, publicParams, publicKey), models.ErrUnsortedIndices, "Expected unsorted indices to be rejected")
+	assert.ErrorIs(t, ValidateProof(proof, revealedAttributes, []int{0, 0}, publicParams, publicKey), models.ErrUnsortedIndices, "Expected duplicate indices to be rejected")
+	assert.ErrorIs(t, ValidateProof(proof, revealedAttributes, []int{0, 3}, publicParams, publicKey), models.ErrIndexOutOfRange, "Expected out of range indices to be rejected")
+	assert.ErrorIs(t, ValidateProof(proof, revealedAttributes[:1], revealed, publicParams, publicKey), models.ErrLengthMismatch, "Expected mismatched revealed attributes to be rejected")
+
+	extended := proof
+	extended.Zi = append(append([]e.Scalar{}, proof.Zi...), proof.Zi...)
+	assert.ErrorIs(t, ValidateProof(extended, revealedAttributes, revealed, publicParams, publicKey), models.ErrMalformedProof, "Expected extra responses to be rejected")
+
+	assert.ErrorIs(t, ValidateProof(proof, revealedAttributes, revealed, publicParams, models.PublicKey{}), models.ErrInvalidPublicKey, "Expected a missing public key to be rejected")
+}
@@ -4,8 +4,7 @@ import (
     e "github.com/cloudflare/circl/ecc/bls12381"
     "github.com/aniagut/msc-bbs-anonymous-credentials/models"
     "github.com/aniagut/msc-bbs-anonymous-credentials/utils"
-    "log"
-    "errors"
+    "fmt"
 )
 
 // Verify checks the validity of ZKP proof for a signature (ensures the credental was signed by the issuer) and binds the revealed attributes to the proof
@@ -24,10 +23,35 @@ import (
 //   - error: An error if the verification process fails.
 //
 func Verify(zkpProof models.SignatureProof, nonce []byte, revealedAttributes []string, revealedIndices []int, publicParams models.PublicParameters, publicKey models.PublicKey) (bool, error) {
+    return VerifyWithExtensions(zkpProof, nonce, revealedAttributes, revealedIndices, publicParams, publicKey)
+}
+
+// VerifyWithExtensions checks a proof generated with presentation.PresentationWithExtensions, together with the
+// statements of the extensions proven in the same transcript.
+//
+// Parameters:
+//   - zkpProof: The zero-knowledge proof to be verified.
+//   - nonce: A random nonce used for the proof.
+//   - revealedAttributes: The list of revealed attributes.
+//   - revealedIndices: The list of indices for revealed attributes.
+//   - publicParams: The public parameters of the system.
+//   - publicKey: The public key of the system.
+//   - extensions: The verifier sides of the extensions, in the order used by the holder.
+//
+// Returns:
+//   - bool: true if the proof and the statements of the extensions are valid, false otherwise.
+//   - error: An error if the verification process fails.
+func VerifyWithExtensions(zkpProof models.SignatureProof, nonce []byte, revealedAttributes []string, revealedIndices []int, publicParams models.PublicParameters, publicKey models.PublicKey, extensions ...Extension) (bool, error) {
+    // Step 0: Validate the proof and the public inputs before any heavy computation
+    if err := ValidateProof(zkpProof, revealedAttributes, revealedIndices, publicParams, publicKey); err != nil {
+        utils.Logger().Debug("error validating proof", "error", err)
+        return false, err
+    }
+
     // Step 1: Compute the h values h₁[i] ← g1^m[i] for revealed and hidden attributes a[i]
     revealedH, hiddenH, err := utils.ComputeRevealedAndHiddenH(publicParams.H1, revealedIndices)
     if err != nil {
-        log.Printf("Error computing revealed and hidden h values: %v", err)
+        utils.Logger().Debug("error computing revealed and hidden h values", "error", err)
         return false, err
     }
 
@@ -35,43 +59,48 @@ func Verify(zkpProof models.SignatureProof, nonce []byte, revealedAttributes []s
     // where z_j is the j-th revealed hidden.
     hiddenH1Exp, err := utils.ComputeH1Exp(hiddenH, zkpProof.Zi)
     if err != nil {
-        log.Printf("Error computing hidden h1 exponent: %v", err)
-        return false, err
+        utils.Logger().Debug("error computing hidden h1 exponent", "error", err)
+        return false, fmt.Errorf("%w: %w", models.ErrMalformedProof, err)
     }
 
-    // Step 3: Compute the commitment for revealed attributes CRev ← g1 * ∏_i h₁[i]^a[i]
+    // Step 3: Compute the commitment for revealed attributes CRev ← g1 * ∏_i h₁[i]^a[i] (CRev = g1 if nothing is revealed)
     CRev, err := utils.ComputeCommitment(revealedAttributes, revealedH, publicParams.G1)
     if err != nil {
-        log.Printf("Error computing commitment: %v", err)
+        utils.Logger().Debug("error computing commitment", "error", err)
         return false, err
     }
 
     // Step 4: Recompute U ← CRev^Zr * ∏_j h₁[j]^Zi * APrim^Ze * BPrim^(-ch) for j ∈ hidden
     U := ComputeU(zkpProof, CRev, hiddenH1Exp)
 
-    // Step 5: Recompute the challenge scalar ch ← H(nonce, U, APrim, BPrim, {a_j}) for j ∈ revealed
-    ch, err := utils.ComputeChallenge(nonce, U, zkpProof.APrim, zkpProof.BPrim, revealedAttributes)
+    // Step 5: Recompute the commitments of the extensions and the challenge scalar ch ← H(nonce, U, APrim, BPrim, {a_j}, extensions) for j ∈ revealed
+    commitments, err := commitExtensions(extensions, zkpProof, len(publicParams.H1), revealedIndices)
+    if err != nil {
+        utils.Logger().Debug("error recomputing extension commitments", "error", err)
+        return false, err
+    }
+    ch, err := utils.ComputeExtendedChallenge(nonce, U, zkpProof.APrim, zkpProof.BPrim, revealedAttributes, commitments)
     if err != nil {
-        log.Printf("Error computing hash to scalar: %v", err)
+        utils.Logger().Debug("error computing hash to scalar", "error", err)
         return false, err
     }
 
     // Step 6: Verify that the recomputed challenge ch matches the signature's challenge
     if ch.IsEqual(zkpProof.Ch) != 1 {
-        log.Printf("Challenge mismatch: expected %v, got %v", zkpProof.Ch, ch)
-        return false, errors.New("challenge mismatch")
+        utils.Logger().Debug("challenge mismatch", "expected", zkpProof.Ch, "got", ch)
+        return false, models.ErrChallengeMismatch
     }
-    log.Printf("Challenge verified successfully")
+    utils.Logger().Debug("challenge verified successfully")
 
     // Step 7: Verify the credential
     // Check if e(APrim, publicKey.X2) == e(BPrim, publicParams.G2)
     if !PairingCheck(zkpProof.APrim, publicKey.X2, zkpProof.BPrim, publicParams.G2) {
-        log.Printf("Pairing check failed: e(APrim, publicKey.X2) != e(BPrim, publicParams.G2)")
-        return false, errors.New("pairing check failed")
+        utils.Logger().Debug("pairing check failed: e(APrim, publicKey.X2) != e(BPrim, publicParams.G2)")
+        return false, models.ErrPairingFailed
     }
     
     // Step 8: Credentials are valid, return true
-    log.Printf("Credential verification successful")
+    utils.Logger().Debug("credential verification successful")
     return true, nil
 }
 
@@ -87,7 +116,7 @@ func PairingCheck(aPrim *e.G1, x2 *e.G2, bPrim *e.G1, g2 *e.G2) bool {
     if pairing1.IsEqual(pairing2) == false {
         return false
     }
-    log.Printf("Pairing check passed: e(APrim, X2) == e(BPrim, G2)")
+    utils.Logger().Debug("pairing check passed: e(APrim, X2) == e(BPrim, G2)")
     return true
 }
 
@@ -3,10 +3,14 @@ package verify
 import (
     "testing"
 
+    "github.com/aniagut/msc-bbs-anonymous-credentials/issue"
     "github.com/aniagut/msc-bbs-anonymous-credentials/models"
+    "github.com/aniagut/msc-bbs-anonymous-credentials/presentation"
+    "github.com/aniagut/msc-bbs-anonymous-credentials/setup"
     "github.com/aniagut/msc-bbs-anonymous-credentials/utils"
 
     e "github.com/cloudflare/circl/ecc/bls12381"
+    "github.com/stretchr/testify/assert"
 )
 
 func TestVerify_Success(t *testing.T) {
@@ -120,4 +124,50 @@ func TestVerify_Success(t *testing.T) {
     if !valid {
         t.Fatalf("Expected proof to verify but it failed")
     }
-}
\ No newline at end of file
+}
+
+// MockPresentation issues a credential and presents it for testing
+func MockPresentation(t *testing.T, attributes []string, revealed []int, nonce []byte) (models.SignatureProof, models.SetupResult) {
+    setupResult, err := setup.Setup(len(attributes))
+    if err != nil {
+        t.Fatalf("Setup failed: %v", err)
+    }
+    signature, err := issue.Issue(attributes, setupResult.PublicParameters, setupResult.SecretKey)
+    if err != nil {
+        t.Fatalf("Issue failed: %v", err)
+    }
+    proof, err := presentation.Presentation(attributes, signature, revealed, setupResult.PublicParameters, nonce)
+    if err != nil {
+        t.Fatalf("Presentation failed: %v", err)
+    }
+    return proof, setupResult
+}
+
+// Test for typed verification errors
+func TestVerify_TypedErrors(t *testing.T) {
+    attributes := []string{"attribute1", "attribute2", "attribute3"}
+    revealed := []int{0}
+    nonce := []byte("random_nonce")
+    proof, setupResult := MockPresentation(t, attributes, revealed, nonce)
+    publicParams, publicKey := setupResult.PublicParameters, setupResult.PublicKey
+
+    // A proof bound to another nonce fails the challenge check
+    _, err := Verify(proof, []byte("other_nonce"), attributes[:1], revealed, publicParams, publicKey)
+    assert.ErrorIs(t, err, models.ErrChallengeMismatch, "Expected a challenge mismatch for another nonce")
+
+    // A proof checked against another issuer key fails the pairing check
+    otherSetup, err := setup.Setup(len(attributes))
+    assert.NoError(t, err, "Expected no error during setup")
+    _, err = Verify(proof, nonce, attributes[:1], revealed, publicParams, otherSetup.PublicKey)
+    assert.ErrorIs(t, err, models.ErrPairingFailed, "Expected a pairing failure for another issuer key")
+
+    // A proof with missing responses is malformed
+    truncated := proof
+    truncated.Zi = proof.Zi[:1]
+    _, err = Verify(truncated, nonce, attributes[:1], revealed, publicParams, publicKey)
+    assert.ErrorIs(t, err, models.ErrMalformedProof, "Expected a malformed proof error for missing responses")
+
+    // A revealed index outside of the attributes is out of range
+    _, err = Verify(proof, nonce, attributes[:1], []int{7}, publicParams, publicKey)
+    assert.ErrorIs(t, err, models.ErrIndexOutOfRange, "Expected an index out of range error")
+}